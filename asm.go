@@ -0,0 +1,53 @@
+package main
+
+import (
+	"chip8/asm"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// asmCommand assembles a source file into a ROM, written next to it with the .ch8 extension unless -o is given
+func asmCommand(args []string) int {
+	var output string
+
+	flags := flag.NewFlagSet("asm", flag.ExitOnError)
+	flags.StringVar(&output, "o", "", "output path, the source path with the .ch8 extension by default")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chip8 asm [-o out.ch8] source.asm")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	source := flags.Arg(0)
+	if output == "" {
+		output = strings.TrimSuffix(source, filepath.Ext(source)) + ".ch8"
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer f.Close()
+
+	rom, err := asm.Assemble(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", source, err)
+		return 1
+	}
+
+	if err := os.WriteFile(output, rom, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,358 @@
+// Package asm assembles the mnemonics chip8.Instruction prints into ROMs
+//
+// Source is line based and ; starts a comment. A line holds an optional label followed by an instruction,
+// a db or dw directive, or a constant definition:
+//
+//	SPEED = 4
+//	loop:
+//	    ADD V0,SPEED        ; values are hex, with or without 0x, or binary with %
+//	    LOAD I,sprite
+//	    DRAW V0,V1,2
+//	    JUMP loop
+//	sprite:
+//	    db %00111100, 0x42
+//	    dw loop
+//
+// The output of the disassembler assembles back into the same bytes
+package asm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Origin is the address ROMs are loaded at, the address of the first assembled byte
+const Origin = 0x200
+
+// maxAddress is the last address of the largest memory, XO-CHIP's
+const maxAddress = 0xFFFF
+
+var (
+	ErrSyntax          = errors.New("syntax error")
+	ErrUnknownMnemonic = errors.New("unknown mnemonic")
+	ErrUndefined       = errors.New("undefined symbol")
+	ErrDuplicate       = errors.New("symbol already defined")
+	ErrInvalidName     = errors.New("invalid symbol name")
+	ErrOutOfRange      = errors.New("value out of range")
+)
+
+// errMismatch means an operand doesn't have the shape a template expects, another template may match it
+var errMismatch = errors.New("operand mismatch")
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Error is an assembly error with the source line it was found at
+type Error struct {
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// statement is an instruction or data directive, operands are kept as text until every label is known
+type statement struct {
+	line     int
+	mnemonic string
+	operands []string
+}
+
+type assembler struct {
+	symbols    map[string]int
+	statements []statement
+	lines      map[int]uint16
+}
+
+// Assemble reads source and returns the ROM it describes, loaded at Origin
+func Assemble(r io.Reader) ([]byte, error) {
+	rom, _, err := AssembleWithLines(r)
+	return rom, err
+}
+
+// AssembleWithLines is Assemble also returning the address of each source line that emits bytes, for debuggers
+func AssembleWithLines(r io.Reader) ([]byte, map[int]uint16, error) {
+	a := &assembler{symbols: map[string]int{}, lines: map[int]uint16{}}
+	if err := a.parse(r); err != nil {
+		return nil, nil, err
+	}
+
+	rom, err := a.encode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rom, a.lines, nil
+}
+
+// parse is the first pass, it splits lines into statements and gives labels their addresses
+func (a *assembler) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	address := Origin
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if j := strings.IndexByte(text, ';'); j >= 0 {
+			text = text[:j]
+		}
+		text = strings.TrimSpace(text)
+
+		if label, rest, ok := strings.Cut(text, ":"); ok {
+			if err := a.define(strings.TrimSpace(label), address); err != nil {
+				return &Error{Line: line, Err: err}
+			}
+			text = strings.TrimSpace(rest)
+		}
+
+		if text == "" {
+			continue
+		}
+
+		if name, expression, ok := strings.Cut(text, "="); ok {
+			value, err := a.value(strings.TrimSpace(expression))
+			if err == nil {
+				err = a.define(strings.TrimSpace(name), value)
+			}
+			if err != nil {
+				return &Error{Line: line, Err: err}
+			}
+			continue
+		}
+
+		s := statement{line: line, mnemonic: text}
+		if j := strings.IndexAny(text, " \t"); j >= 0 {
+			s.mnemonic = text[:j]
+			for _, operand := range strings.Split(text[j+1:], ",") {
+				s.operands = append(s.operands, strings.TrimSpace(operand))
+			}
+		}
+		s.mnemonic = strings.ToUpper(s.mnemonic)
+
+		switch s.mnemonic {
+		case "DB":
+			address += len(s.operands)
+		case "DW":
+			address += 2 * len(s.operands)
+		default:
+			address += 2
+		}
+		if address > maxAddress+1 {
+			return &Error{Line: line, Err: fmt.Errorf("%w: program doesn't fit in memory", ErrOutOfRange)}
+		}
+
+		a.statements = append(a.statements, s)
+	}
+
+	return scanner.Err()
+}
+
+// encode is the second pass, it turns statements into bytes now that every label is known
+func (a *assembler) encode() ([]byte, error) {
+	var rom []byte
+
+	for _, s := range a.statements {
+		a.lines[s.line] = uint16(Origin + len(rom))
+
+		switch s.mnemonic {
+		case "DB":
+			for _, operand := range s.operands {
+				value, err := a.number(operand, 0xFF)
+				if err != nil {
+					return nil, &Error{Line: s.line, Err: err}
+				}
+				rom = append(rom, uint8(value))
+			}
+		case "DW":
+			for _, operand := range s.operands {
+				value, err := a.number(operand, 0xFFFF)
+				if err != nil {
+					return nil, &Error{Line: s.line, Err: err}
+				}
+				rom = append(rom, uint8(value>>8), uint8(value))
+			}
+		default:
+			opcode, err := a.instruction(s.mnemonic, s.operands)
+			if err != nil {
+				return nil, &Error{Line: s.line, Err: err}
+			}
+			rom = append(rom, uint8(opcode>>8), uint8(opcode))
+		}
+	}
+
+	return rom, nil
+}
+
+// instruction encodes the first template matching the mnemonic and its operands
+func (a *assembler) instruction(mnemonic string, operands []string) (uint16, error) {
+	known := false
+	var firstErr error
+
+	for _, t := range templates {
+		if t.mnemonic != mnemonic {
+			continue
+		}
+		known = true
+		if len(t.operands) != len(operands) {
+			continue
+		}
+
+		opcode := t.opcode
+		var err error
+		for j, pattern := range t.operands {
+			var bits uint16
+			if bits, err = a.operand(pattern, operands[j]); err != nil {
+				break
+			}
+			opcode |= bits
+		}
+		if err == nil {
+			return opcode, nil
+		}
+		if firstErr == nil && !errors.Is(err, errMismatch) {
+			firstErr = err
+		}
+	}
+
+	switch {
+	case !known:
+		return 0, fmt.Errorf("%w %q", ErrUnknownMnemonic, mnemonic)
+	case firstErr != nil:
+		return 0, firstErr
+	default:
+		return 0, fmt.Errorf("%w: invalid operands %q for %s", ErrSyntax, strings.Join(operands, ","), mnemonic)
+	}
+}
+
+// operand returns the opcode bits of an operand matching a template pattern
+func (a *assembler) operand(pattern, text string) (uint16, error) {
+	switch pattern {
+	case "Vx", "Vy":
+		r, ok := register(text)
+		if !ok {
+			return 0, errMismatch
+		}
+		if pattern == "Vx" {
+			return r << 8, nil
+		}
+		return r << 4, nil
+	case "V0-Vx":
+		from, to, ok := strings.Cut(text, "-")
+		x, okTo := register(to)
+		if r, okFrom := register(from); !ok || !okFrom || !okTo || r != 0 {
+			return 0, errMismatch
+		}
+		return x << 8, nil
+	case "Vx..Vy":
+		from, to, ok := strings.Cut(text, "..")
+		x, okFrom := register(from)
+		y, okTo := register(to)
+		if !ok || !okFrom || !okTo {
+			return 0, errMismatch
+		}
+		return x<<8 | y<<4, nil
+	case "n":
+		return a.number(text, 0xF)
+	case "X":
+		value, err := a.number(text, 0xF)
+		return value << 8, err
+	case "nn":
+		return a.number(text, 0xFF)
+	case "nnn":
+		return a.number(text, 0xFFF)
+	case "nnn+V0":
+		if len(text) < 3 || !strings.EqualFold(text[len(text)-3:], "+V0") {
+			return 0, errMismatch
+		}
+		return a.number(text[:len(text)-3], 0xFFF)
+	default:
+		if !strings.EqualFold(text, pattern) {
+			return 0, errMismatch
+		}
+		return 0, nil
+	}
+}
+
+// number returns the value of a number or symbol, which must not be greater than max
+func (a *assembler) number(text string, max int) (uint16, error) {
+	value, err := a.value(text)
+	if err != nil {
+		return 0, err
+	}
+	if value > max {
+		return 0, fmt.Errorf("%w: %s is greater than 0x%x", ErrOutOfRange, text, max)
+	}
+
+	return uint16(value), nil
+}
+
+func (a *assembler) value(text string) (int, error) {
+	if value, ok := parseNumber(text); ok {
+		return value, nil
+	}
+	if value, ok := a.symbols[text]; ok {
+		return value, nil
+	}
+
+	if text == "" {
+		return 0, fmt.Errorf("%w: missing value", ErrSyntax)
+	}
+	return 0, fmt.Errorf("%w %q", ErrUndefined, text)
+}
+
+// define adds a label or constant, names must not read as numbers, registers or operand keywords
+func (a *assembler) define(name string, value int) error {
+	_, isNumber := parseNumber(name)
+	_, isRegister := register(name)
+	if !identifier.MatchString(name) || isNumber || isRegister || reserved[strings.ToUpper(name)] {
+		return fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+
+	if _, ok := a.symbols[name]; ok {
+		return fmt.Errorf("%w %q", ErrDuplicate, name)
+	}
+
+	a.symbols[name] = value
+	return nil
+}
+
+// parseNumber reads hexadecimal numbers, the 0x prefix being optional like in the mnemonics, or % binary ones
+func parseNumber(text string) (int, bool) {
+	base := 16
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(lower, "%"):
+		base, lower = 2, lower[1:]
+	case strings.HasPrefix(lower, "0x"):
+		lower = lower[2:]
+	}
+
+	value, err := strconv.ParseUint(lower, base, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return int(value), true
+}
+
+// register reads V0 to VF
+func register(text string) (uint16, bool) {
+	if len(text) != 2 || (text[0] != 'V' && text[0] != 'v') {
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(text[1:], 16, 4)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint16(value), true
+}
@@ -0,0 +1,139 @@
+package asm
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/disasm"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assemble(t *testing.T, source string) []byte {
+	t.Helper()
+
+	rom, err := Assemble(strings.NewReader(source))
+	require.NoError(t, err)
+	return rom
+}
+
+func TestAssemble(t *testing.T) {
+	source := `
+SPEED = 4               ; constants are symbols too
+	CLR
+loop:   ADD V0,SPEED
+	LOAD I,sprite
+	draw v0,v1,2        ; mnemonics and registers are case insensitive
+	SKE V1,05
+	JUMP loop
+	JUMP 0x0300+V0
+sprite:
+	db %00111100, 0x42
+	dw loop, ff
+`
+
+	assert.Equal(t, []byte{
+		0x00, 0xe0,
+		0x70, 0x04,
+		0xa2, 0x0e,
+		0xd0, 0x12,
+		0x31, 0x05,
+		0x12, 0x02,
+		0xb3, 0x00,
+		0x3c, 0x42,
+		0x02, 0x02, 0x00, 0xff,
+	}, assemble(t, source))
+}
+
+func TestAssembleWithLines(t *testing.T) {
+	source := "; comment\nstart: CLR\n\nADD V0,1\nx = 3\ndb 1, 2\nJUMP start\n"
+
+	rom, lines, err := AssembleWithLines(strings.NewReader(source))
+	require.NoError(t, err)
+	assert.Len(t, rom, 8)
+	assert.Equal(t, map[int]uint16{2: 0x200, 4: 0x202, 6: 0x204, 7: 0x206}, lines)
+}
+
+func TestAssembleEveryMnemonic(t *testing.T) {
+	for opcode := 0; opcode <= 0xFFFF; opcode++ {
+		instruction, ok := chip8.Decode(uint16(opcode))
+		if !ok || !disasm.Canonical(uint16(opcode)) {
+			continue
+		}
+
+		rom, err := Assemble(strings.NewReader(instruction.String()))
+		if assert.NoError(t, err, "%04x %s", opcode, instruction) {
+			assert.Equal(t, []byte{uint8(opcode >> 8), uint8(opcode)}, rom, "%04x %s", opcode, instruction)
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := map[string]error{
+		"FOO V0":             ErrUnknownMnemonic,
+		"JUMP nowhere":       ErrUndefined,
+		"LOAD V0,100":        ErrOutOfRange,
+		"DRAW V0,V1,10":      ErrOutOfRange,
+		"db 100":             ErrOutOfRange,
+		"LOAD V0":            ErrSyntax,
+		"WRITE V1-V2":        ErrSyntax,
+		"x:\nx:":             ErrDuplicate,
+		"abc:":               ErrInvalidName,
+		"v1 = 2":             ErrInvalidName,
+		"long = 2":           ErrInvalidName,
+		"SPEED = later":      ErrUndefined,
+		"1st:":               ErrInvalidName,
+		"CLR\nCLR\nLOAD V0,": ErrSyntax,
+	}
+
+	for source, expected := range tests {
+		_, err := Assemble(strings.NewReader(source))
+		assert.ErrorIs(t, err, expected, source)
+
+		var asmErr *Error
+		if assert.ErrorAs(t, err, &asmErr, source) {
+			assert.Equal(t, strings.Count(source, "\n")+1, asmErr.Line, source)
+		}
+	}
+}
+
+func TestRoundTripROMs(t *testing.T) {
+	entries, err := os.ReadDir("../roms")
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		t.Run(entry.Name(), func(t *testing.T) {
+			rom, err := os.ReadFile("../roms/" + entry.Name())
+			require.NoError(t, err)
+
+			var source bytes.Buffer
+			_, err = disasm.Disassemble(rom).WriteTo(&source)
+			require.NoError(t, err)
+
+			assert.Equal(t, rom, assemble(t, source.String()))
+		})
+	}
+}
+
+func TestRoundTripLongIndex(t *testing.T) {
+	rom := []byte{0xf0, 0x00, 0x02, 0x06, 0x00, 0xfd, 0xaa}
+
+	var source bytes.Buffer
+	_, err := disasm.Disassemble(rom).WriteTo(&source)
+	require.NoError(t, err)
+
+	assert.Equal(t, rom, assemble(t, source.String()))
+}
+
+func TestRoundTripAlternativeEncodings(t *testing.T) {
+	rom := []byte{0x01, 0xe0, 0x91, 0x21, 0x51, 0x2f, 0x0a, 0xee, 0x00, 0xfd}
+
+	var source bytes.Buffer
+	_, err := disasm.Disassemble(rom).WriteTo(&source)
+	require.NoError(t, err)
+
+	assert.Equal(t, rom, assemble(t, source.String()))
+}
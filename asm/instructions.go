@@ -0,0 +1,74 @@
+package asm
+
+// template describes the operands of a mnemonic and the opcode they are ORed into
+// Operand patterns: Vx and Vy are registers, V0-Vx a register range starting at V0 and Vx..Vy any range,
+// n, nn and nnn are values of 4, 8 and 12 bits, X is a 4 bit value stored in the X nibble, nnn+V0 the BNNN
+// address and anything else is a literal like DT or LONG
+type template struct {
+	mnemonic string
+	operands []string
+	opcode   uint16
+}
+
+// templates follows the mnemonics chip8.Instruction prints, see chip8.Decode
+var templates = []template{
+	{"CLR", nil, 0x00E0},
+	{"RTS", nil, 0x00EE},
+	{"JUMP", []string{"nnn"}, 0x1000},
+	{"JUMP", []string{"nnn+V0"}, 0xB000},
+	{"CALL", []string{"nnn"}, 0x2000},
+	{"SKE", []string{"Vx", "Vy"}, 0x5000},
+	{"SKE", []string{"Vx", "nn"}, 0x3000},
+	{"SKNE", []string{"Vx", "Vy"}, 0x9000},
+	{"SKNE", []string{"Vx", "nn"}, 0x4000},
+	{"LOAD", []string{"Vx", "Vy"}, 0x8000},
+	{"LOAD", []string{"Vx", "DT"}, 0xF007},
+	{"LOAD", []string{"Vx", "K"}, 0xF00A},
+	{"LOAD", []string{"Vx", "nn"}, 0x6000},
+	{"LOAD", []string{"DT", "Vx"}, 0xF015},
+	{"LOAD", []string{"ST", "Vx"}, 0xF018},
+	{"LOAD", []string{"I", "LONG"}, 0xF000},
+	{"LOAD", []string{"I", "Vx"}, 0xF029},
+	{"LOAD", []string{"I", "nnn"}, 0xA000},
+	{"LOAD", []string{"HI", "Vx"}, 0xF030},
+	{"ADD", []string{"I", "Vx"}, 0xF01E},
+	{"ADD", []string{"Vx", "Vy"}, 0x8004},
+	{"ADD", []string{"Vx", "nn"}, 0x7000},
+	{"OR", []string{"Vx", "Vy"}, 0x8001},
+	{"AND", []string{"Vx", "Vy"}, 0x8002},
+	{"XOR", []string{"Vx", "Vy"}, 0x8003},
+	{"SUB", []string{"Vx", "Vy"}, 0x8005},
+	{"SHR", []string{"Vx", "Vy"}, 0x8006},
+	{"RSB", []string{"Vx", "Vy"}, 0x8007},
+	{"SHL", []string{"Vx", "Vy"}, 0x800E},
+	{"RAND", []string{"Vx", "nn"}, 0xC000},
+	{"DRAW", []string{"Vx", "Vy", "n"}, 0xD000},
+	{"SKP", []string{"Vx"}, 0xE09E},
+	{"SKNP", []string{"Vx"}, 0xE0A1},
+	{"BCD", []string{"Vx"}, 0xF033},
+	{"WRITE", []string{"V0-Vx"}, 0xF055},
+	{"WRITE", []string{"R", "V0-Vx"}, 0xF075},
+	{"WRITE", []string{"Vx..Vy"}, 0x5002},
+	{"READ", []string{"V0-Vx"}, 0xF065},
+	{"READ", []string{"R", "V0-Vx"}, 0xF085},
+	{"READ", []string{"Vx..Vy"}, 0x5003},
+
+	// SUPER-CHIP
+	{"SCD", []string{"n"}, 0x00C0},
+	{"SCR", nil, 0x00FB},
+	{"SCL", nil, 0x00FC},
+	{"EXIT", nil, 0x00FD},
+	{"LOW", nil, 0x00FE},
+	{"HIGH", nil, 0x00FF},
+
+	// XO-CHIP
+	{"SCU", []string{"n"}, 0x00D0},
+	{"PLANE", []string{"X"}, 0xF001},
+	{"AUDIO", nil, 0xF002},
+	{"PITCH", []string{"Vx"}, 0xF03A},
+}
+
+// reserved names can't be used for labels or constants, they would read as operands
+var reserved = map[string]bool{
+	"DT": true, "K": true, "ST": true, "I": true, "HI": true, "R": true, "LONG": true,
+}
@@ -0,0 +1,15 @@
+package chip8
+
+import "math"
+
+const (
+	// AudioPatternBytes is the size of the XO-CHIP audio pattern buffer, 128 1-bit samples
+	AudioPatternBytes = 16
+	defaultPitch      = 64
+	patternBaseRateHz = 4000
+)
+
+// PatternRate returns how many audio pattern bits per second are played at a given XO-CHIP pitch
+func PatternRate(pitch uint8) float64 {
+	return patternBaseRateHz * math.Pow(2, (float64(pitch)-defaultPitch)/48)
+}
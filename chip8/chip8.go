@@ -2,51 +2,125 @@ package chip8
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"io"
 	"log/slog"
-	"math"
-
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 const (
 	fontStartMemoryAddress    = 0x50
+	bigFontStartMemoryAddress = fontStartMemoryAddress + fontLen
 	programStartMemoryAddress = 0x200
+	defaultIPF                = 15
 )
 
+// ErrExit is returned by Update and Cycle once the program executed 00FD, it is not a Fault
+var ErrExit = errors.New("interpreter exited")
+
 type Chip8 struct {
 	log        *slog.Logger
-	clockHz    uint
 	memory     Memory
 	index      uint16
 	registers  Registers
+	userFlags  Registers
 	stack      *Stack
 	fetcher    *Fetcher
 	delayTimer *Timer
+	soundTimer *Timer
+	ipf        uint
+	frames     uint64
 	input      *Input
 	screen     *Screen
-	sound      *Sound
+	display    Display
+	audio      Audio
+	keypad     Keypad
+	random     RandomSource
+	beeping    bool
+	pattern    [AudioPatternBytes]uint8
+	pitch      uint8
+	quirks     Quirks
+	vblankWait bool
+	exited     bool
+	// frameCycles counts the instructions executed in the current frame, which a debugger may pause midway
+	frameCycles uint
+	debugger    *Debugger
+	cycles      uint64
+	trace       TraceSink
 }
 
-func NewChip8(tps uint, log *slog.Logger) *Chip8 {
+// NewChip8 creates a headless machine. Attach a frontend with SetDisplay, SetAudio and SetKeypad
+func NewChip8(log *slog.Logger) *Chip8 {
 	return &Chip8{
 		log:        log,
-		clockHz:    tps,
-		memory:     Memory{},
+		memory:     NewMemory(memoryLocations),
 		index:      0,
 		registers:  Registers{},
+		userFlags:  Registers{},
 		stack:      NewStack(),
 		fetcher:    NewFetcher(programStartMemoryAddress),
-		delayTimer: NewTimer(tps),
+		delayTimer: NewTimer(),
+		soundTimer: NewTimer(),
+		ipf:        defaultIPF,
 		input:      NewInput(),
 		screen:     NewScreen(),
-		sound:      NewSound(NewTimer(tps)),
+		display:    headless{},
+		audio:      headless{},
+		keypad:     headless{},
+		random:     NewRandom(RandomSeed()),
+		quirks:     QuirksCOSMACVIP,
+		pitch:      defaultPitch,
 	}
 }
 
+// SetIPF sets how many instructions are executed per frame, 60 frames per second
+func (c *Chip8) SetIPF(ipf uint) {
+	c.ipf = ipf
+}
+
+// SetPlatform selects the quirks and memory size of a CHIP-8 variant
+// Memory is reset, so it must be called before loading the font and the ROM
+func (c *Chip8) SetPlatform(platform Platform) {
+	c.quirks = platform.Quirks
+	c.memory = NewMemory(platform.MemorySize)
+}
+
+// SetQuirks selects the interpreter behaviour, COSMAC VIP by default
+func (c *Chip8) SetQuirks(quirks Quirks) {
+	c.quirks = quirks
+}
+
+func (c *Chip8) SetDisplay(display Display) {
+	c.display = display
+}
+
+func (c *Chip8) SetAudio(audio Audio) {
+	c.audio = audio
+}
+
+func (c *Chip8) SetKeypad(keypad Keypad) {
+	c.keypad = keypad
+}
+
+// Frames returns how many 60 Hz frames have elapsed since the machine was created
+func (c *Chip8) Frames() uint64 {
+	return c.frames
+}
+
+// SetRandom replaces the CXNN random source, seeded from the operating system by default
+func (c *Chip8) SetRandom(random RandomSource) {
+	c.random = random
+}
+
+func (c *Chip8) Screen() *Screen {
+	return c.screen
+}
+
 func (c *Chip8) LoadFont() error {
-	_, err := c.memory.Write(fontStartMemoryAddress, bytes.NewReader(font[:]))
+	if _, err := c.memory.Write(fontStartMemoryAddress, bytes.NewReader(font[:])); err != nil {
+		return err
+	}
+
+	_, err := c.memory.Write(bigFontStartMemoryAddress, bytes.NewReader(bigFont[:]))
 	return err
 }
 
@@ -61,62 +135,95 @@ func (c *Chip8) LoadROM(r io.Reader) error {
 	return nil
 }
 
-func (c *Chip8) Run() error {
-	ebiten.SetWindowSize(screenWidth*screenMultiplier, screenHeight*screenMultiplier)
-	ebiten.SetWindowTitle("CHIP-8")
-	ebiten.SetTPS(int(c.clockHz))
+// Update runs a single 60 Hz frame: poll the keypad, execute IPF instructions, stopping early to wait for a key
+// or the vertical blank, count down timers and notify the frontend about display and sound changes
+// With a debugger attached, nothing runs while it's paused and a frame it pauses midway resumes where it stopped
+func (c *Chip8) Update() error {
+	if c.debugger != nil && c.debugger.paused {
+		return nil
+	}
 
-	return ebiten.RunGame(c)
-}
+	wait := c.input.Detect(c.keypad, &c.registers)
 
-func (c *Chip8) Update() error {
-	wait := c.input.Detect()
-	c.log.Info("input  :", slog.Any("keys", c.input))
+	for ; c.frameCycles < c.ipf && !wait && !c.vblankWait; c.frameCycles++ {
+		if c.debugger != nil && c.debugger.beforeCycle() {
+			return nil
+		}
 
-	if !wait {
 		if err := c.Cycle(); err != nil {
+			if c.debugger != nil && !errors.Is(err, ErrExit) {
+				c.debugger.fault(err)
+			}
 			return err
 		}
+
+		if c.debugger != nil {
+			c.debugger.triggered()
+		}
+		wait = c.input.isWaiting()
 	}
 
+	c.frameCycles = 0
+	if r, ok := c.random.(frameRandom); ok {
+		r.Frame()
+	}
 	c.delayTimer.Update()
-	c.sound.Update()
-
-	c.log.Info(
-		"execute:",
-		slog.String("PC", hexdump16(c.fetcher.counter)),
-		slog.String("I", hexdump16(c.index)),
-		slog.Any("V", c.registers),
-		slog.Any("S", c.stack),
-		slog.Any("DT", c.delayTimer),
-		slog.Any("ST", c.sound.timer),
-		slog.Int("TPS", int(math.Round(ebiten.ActualTPS()))),
-		slog.Int("FPS", int(math.Round(ebiten.ActualFPS()))),
-	)
+	c.soundTimer.Update()
+	c.frames++
+	c.vblankWait = false
+
+	if beeping := c.soundTimer.GetValue() > 0; beeping != c.beeping {
+		c.beeping = beeping
+		c.audio.SetBeep(beeping)
+	}
+
+	if c.screen.dirty {
+		c.screen.dirty = false
+		c.display.Refresh(c.screen)
+	}
 
 	return nil
 }
 
+// Cycle fetches, decodes and executes one instruction, sending it to the trace sink if any
 func (c *Chip8) Cycle() error {
+	if c.exited {
+		return ErrExit
+	}
+
 	pc := c.fetcher.counter
-	opcode := c.fetcher.Fetch(c)
-	c.log.Info("fetch  :", slog.String("PC", hexdump16(pc)), slog.String("opcode", hexdump16(opcode)))
+	opcode, err := c.fetcher.Fetch(c)
+	if err != nil {
+		return c.fault(err, pc, opcode)
+	}
 
-	instruction, ok := decode(opcode)
+	instruction, ok := Decode(opcode)
 	if !ok {
-		return fmt.Errorf("invalid opcode: %s", hexdump16(opcode))
+		return c.fault(ErrInvalidOpcode, pc, opcode)
 	}
-	c.log.Info("decode : " + instruction.String())
 
-	instruction.Execute(c)
-
-	return nil
-}
+	if c.trace != nil {
+		c.trace.Trace(&TraceRecord{
+			Cycle:       c.cycles,
+			Frame:       c.frames,
+			PC:          pc,
+			Opcode:      opcode,
+			Instruction: instruction,
+			Registers:   c.registers,
+			Index:       c.index,
+			SP:          c.stack.pointer,
+			DT:          c.delayTimer.GetValue(),
+			ST:          c.soundTimer.GetValue(),
+		})
+	}
+	c.cycles++
 
-func (c *Chip8) Draw(image *ebiten.Image) {
-	c.screen.Draw(image)
-}
+	if err := instruction.Execute(c); err != nil {
+		if errors.Is(err, ErrExit) {
+			return err
+		}
+		return c.fault(err, pc, opcode)
+	}
 
-func (c *Chip8) Layout(_, _ int) (w, h int) {
-	return c.screen.Layout()
+	return nil
 }
@@ -0,0 +1,134 @@
+package chip8
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFrontend struct {
+	keys      [KeyCount]bool
+	refreshes int
+	beep      bool
+	pattern   [AudioPatternBytes]uint8
+	pitch     uint8
+}
+
+func (f *fakeFrontend) Refresh(_ *Screen) {
+	f.refreshes++
+}
+
+func (f *fakeFrontend) SetBeep(on bool) {
+	f.beep = on
+}
+
+func (f *fakeFrontend) SetPattern(pattern [AudioPatternBytes]uint8, pitch uint8) {
+	f.pattern = pattern
+	f.pitch = pitch
+}
+
+func (f *fakeFrontend) Pressed() [KeyCount]bool {
+	return f.keys
+}
+
+func newTestChip8(t testing.TB, rom ...byte) (*Chip8, *fakeFrontend) {
+	c := NewChip8(slog.Default())
+	assert.NoError(t, c.LoadFont())
+	assert.NoError(t, c.LoadROM(bytes.NewReader(rom)))
+
+	c.SetIPF(1)
+
+	f := &fakeFrontend{}
+	c.SetDisplay(f)
+	c.SetAudio(f)
+	c.SetKeypad(f)
+
+	return c, f
+}
+
+func TestUpdateHeadless(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.SetIPF(1)
+	assert.NoError(t, c.LoadROM(bytes.NewReader([]byte{0x60, 0x2a})))
+
+	assert.NoError(t, c.Update())
+
+	assert.Equal(t, byte(0x2a), c.registers[0])
+}
+
+func TestUpdateRefreshesDisplayOnDraw(t *testing.T) {
+	// LOAD I,0x0050; DRAW V0,V0,5; JUMP 0x0204
+	c, f := newTestChip8(t, 0xa0, 0x50, 0xd0, 0x05, 0x12, 0x04)
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, 0, f.refreshes)
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, 1, f.refreshes)
+	assert.True(t, c.Screen().Get(0, 0))
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, 1, f.refreshes)
+}
+
+func TestUpdateBeepsWhileSoundTimerActive(t *testing.T) {
+	// LOAD V0,2; LOAD ST,V0; JUMP 0x0204
+	c, f := newTestChip8(t, 0x60, 0x02, 0xf0, 0x18, 0x12, 0x04)
+
+	assert.NoError(t, c.Update())
+	assert.False(t, f.beep)
+
+	assert.NoError(t, c.Update())
+	assert.True(t, f.beep)
+
+	assert.NoError(t, c.Update())
+	assert.False(t, f.beep)
+}
+
+func TestUpdateWaitsForKeyRelease(t *testing.T) {
+	// LOAD V3,K; JUMP 0x0202
+	c, f := newTestChip8(t, 0xf3, 0x0a, 0x12, 0x02)
+
+	assert.NoError(t, c.Update())
+	assert.True(t, c.input.isWaiting())
+
+	f.keys[0xb] = true
+	assert.NoError(t, c.Update())
+	assert.True(t, c.input.isWaiting())
+
+	f.keys[0xb] = false
+	assert.NoError(t, c.Update())
+	assert.False(t, c.input.isWaiting())
+	assert.Equal(t, byte(0xb), c.registers[3])
+}
+
+func TestUpdateRunsInstructionsPerFrame(t *testing.T) {
+	// ADD V0,1; JUMP 0x0200
+	c, _ := newTestChip8(t, 0x70, 0x01, 0x12, 0x00)
+	c.SetIPF(1000)
+
+	assert.NoError(t, c.Update())
+	assert.NoError(t, c.Update())
+
+	// 2 frames of 1000 instructions, half of them ADD
+	assert.Equal(t, byte(1000%256), c.registers[0])
+	assert.Equal(t, uint64(2), c.Frames())
+}
+
+func TestUpdateCountsDownTimersOncePerFrame(t *testing.T) {
+	// LOAD V0,10; LOAD DT,V0; JUMP 0x0204
+	c, _ := newTestChip8(t, 0x60, 0x0a, 0xf0, 0x15, 0x12, 0x04)
+	c.SetIPF(100)
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, uint8(9), c.delayTimer.GetValue())
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, uint8(8), c.delayTimer.GetValue())
+}
+
+func bytesOf(n int) *bytes.Reader {
+	return bytes.NewReader(make([]byte, n))
+}
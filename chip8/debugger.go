@@ -0,0 +1,316 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// StopReason tells why the debugger paused the machine
+type StopReason uint8
+
+const (
+	StopPaused StopReason = iota
+	StopStep
+	StopBreakpoint
+	StopWatchpoint
+	StopFault
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopPaused:
+		return "paused"
+	case StopStep:
+		return "step"
+	case StopBreakpoint:
+		return "breakpoint"
+	case StopWatchpoint:
+		return "watchpoint"
+	case StopFault:
+		return "fault"
+	}
+	return fmt.Sprintf("StopReason(%d)", uint8(r))
+}
+
+// Stop describes where and why the debugger paused, Watchpoint is the ID of the triggered watchpoint and
+// Err the fault, if any
+type Stop struct {
+	Reason     StopReason
+	PC         uint16
+	Watchpoint int
+	Err        error
+}
+
+// Debugger controls the execution of a machine: Update runs frames until a breakpoint or watchpoint pauses it,
+// then the machine is frozen until Step, StepOver, StepOut, RunTo or Continue
+type Debugger struct {
+	c                 *Chip8
+	breakpoints       map[uint16]bool
+	opcodeBreakpoints []opcodePattern
+	watchpoints       []*watchpoint
+	nextWatchpoint    int
+	paused            bool
+	resuming          bool
+	until             func(c *Chip8) bool
+	stop              Stop
+}
+
+// NewDebugger attaches a debugger to a machine, which keeps running until paused
+func NewDebugger(c *Chip8) *Debugger {
+	d := &Debugger{
+		c:              c,
+		breakpoints:    map[uint16]bool{},
+		nextWatchpoint: 1,
+	}
+	c.debugger = d
+
+	return d
+}
+
+// Paused returns true if the machine is paused, false otherwise
+func (d *Debugger) Paused() bool {
+	return d.paused
+}
+
+// Stopped returns why and where the machine is paused, false if it's running
+func (d *Debugger) Stopped() (Stop, bool) {
+	return d.stop, d.paused
+}
+
+// Pause stops the machine before its next instruction
+func (d *Debugger) Pause() {
+	d.pause(StopPaused)
+}
+
+// Continue runs the machine until a breakpoint or watchpoint
+func (d *Debugger) Continue() {
+	d.resume(nil)
+}
+
+// Step executes the next instruction and pauses. Nothing is executed while FX0A waits for a key
+func (d *Debugger) Step() error {
+	if d.c.input.isWaiting() {
+		d.pause(StopStep)
+		return nil
+	}
+
+	d.arm()
+	err := d.c.Cycle()
+	if err == nil {
+		d.c.frameCycles++
+	}
+
+	if d.paused = true; !d.triggered() {
+		d.stop = Stop{Reason: StopStep, PC: d.c.fetcher.GetCounter()}
+	}
+	if err != nil && !errors.Is(err, ErrExit) {
+		d.fault(err)
+	}
+
+	return err
+}
+
+// StepOver steps, running subroutines called by the next instruction until they return
+func (d *Debugger) StepOver() error {
+	pc := d.c.fetcher.GetCounter()
+	opcode, err := d.c.memory.ReadWord(pc)
+	if err != nil {
+		return d.Step()
+	}
+
+	instruction, ok := Decode(opcode)
+	if !ok || FlowOf(instruction).Kind != FlowCall {
+		return d.Step()
+	}
+
+	returnAddress, depth := pc+instructionBytes, d.c.stack.pointer
+	d.resume(func(c *Chip8) bool {
+		return c.fetcher.GetCounter() == returnAddress && c.stack.pointer == depth
+	})
+
+	return nil
+}
+
+// StepOut runs until the current subroutine returns, it steps if there is no subroutine to return from
+func (d *Debugger) StepOut() error {
+	depth := d.c.stack.pointer
+	if depth == 0 {
+		return d.Step()
+	}
+
+	d.resume(func(c *Chip8) bool {
+		return c.stack.pointer < depth
+	})
+
+	return nil
+}
+
+// RunTo runs until the next instruction is at address, or until a breakpoint or watchpoint
+func (d *Debugger) RunTo(address uint16) {
+	d.resume(func(c *Chip8) bool {
+		return c.fetcher.GetCounter() == address
+	})
+}
+
+func (d *Debugger) SetBreakpoint(address uint16) {
+	d.breakpoints[address] = true
+}
+
+func (d *Debugger) ClearBreakpoint(address uint16) {
+	delete(d.breakpoints, address)
+}
+
+// Breakpoints returns the breakpoint addresses in ascending order
+func (d *Debugger) Breakpoints() []uint16 {
+	addresses := make([]uint16, 0, len(d.breakpoints))
+	for address := range d.breakpoints {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+
+	return addresses
+}
+
+// SetOpcodeBreakpoint breaks before any opcode matching a pattern of 4 hex digits, where X, Y and N match any
+// digit: DXYN breaks before every draw, 8XY4 before every register addition and 00E0 before clears
+func (d *Debugger) SetOpcodeBreakpoint(pattern string) error {
+	p, err := parseOpcodePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	d.ClearOpcodeBreakpoint(p.text)
+	d.opcodeBreakpoints = append(d.opcodeBreakpoints, p)
+	return nil
+}
+
+func (d *Debugger) ClearOpcodeBreakpoint(pattern string) {
+	d.opcodeBreakpoints = slices.DeleteFunc(d.opcodeBreakpoints, func(p opcodePattern) bool {
+		return p.text == strings.ToUpper(pattern)
+	})
+}
+
+func (d *Debugger) OpcodeBreakpoints() []string {
+	patterns := make([]string, len(d.opcodeBreakpoints))
+	for j, p := range d.opcodeBreakpoints {
+		patterns[j] = p.text
+	}
+
+	return patterns
+}
+
+// AddWatchpoint watches a condition, see Watchpoint, and returns its ID
+func (d *Debugger) AddWatchpoint(condition string) (int, error) {
+	w, err := parseWatchpoint(d.nextWatchpoint, condition)
+	if err != nil {
+		return 0, err
+	}
+
+	d.nextWatchpoint++
+	d.watchpoints = append(d.watchpoints, w)
+	return w.ID, nil
+}
+
+func (d *Debugger) RemoveWatchpoint(id int) {
+	d.watchpoints = slices.DeleteFunc(d.watchpoints, func(w *watchpoint) bool {
+		return w.ID == id
+	})
+}
+
+func (d *Debugger) Watchpoints() []Watchpoint {
+	watchpoints := make([]Watchpoint, len(d.watchpoints))
+	for j, w := range d.watchpoints {
+		watchpoints[j] = w.Watchpoint
+	}
+
+	return watchpoints
+}
+
+func (d *Debugger) pause(reason StopReason) {
+	d.paused = true
+	d.stop = Stop{Reason: reason, PC: d.c.fetcher.GetCounter()}
+}
+
+// resume runs the machine until a breakpoint, a watchpoint or, if not nil, until is true
+// Breakpoints at the current instruction are ignored, or the machine would never leave them
+func (d *Debugger) resume(until func(c *Chip8) bool) {
+	d.paused = false
+	d.resuming = true
+	d.until = until
+}
+
+func (d *Debugger) fault(err error) {
+	d.pause(StopFault)
+	d.stop.Err = err
+}
+
+// beforeCycle returns true if the machine must pause before the next instruction, false otherwise
+func (d *Debugger) beforeCycle() bool {
+	if d.paused {
+		return true
+	}
+
+	if d.resuming {
+		d.resuming = false
+	} else if d.breaks() {
+		return true
+	}
+
+	d.arm()
+	return false
+}
+
+// breaks pauses the machine if the next instruction is a breakpoint or ends a step
+func (d *Debugger) breaks() bool {
+	pc := d.c.fetcher.GetCounter()
+
+	if d.until != nil && d.until(d.c) {
+		d.until = nil
+		d.pause(StopStep)
+		return true
+	}
+
+	if d.breakpoints[pc] {
+		d.until = nil
+		d.pause(StopBreakpoint)
+		return true
+	}
+
+	if len(d.opcodeBreakpoints) > 0 {
+		opcode, err := d.c.memory.ReadWord(pc)
+		if err != nil {
+			return false
+		}
+		for _, p := range d.opcodeBreakpoints {
+			if p.matches(opcode) {
+				d.until = nil
+				d.pause(StopBreakpoint)
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (d *Debugger) arm() {
+	for _, w := range d.watchpoints {
+		w.arm(d.c)
+	}
+}
+
+// triggered pauses the machine if the last instruction triggered a watchpoint, returning true, false otherwise
+func (d *Debugger) triggered() bool {
+	for _, w := range d.watchpoints {
+		if w.triggered(d.c) {
+			d.until = nil
+			d.pause(StopWatchpoint)
+			d.stop.Watchpoint = w.ID
+			return true
+		}
+	}
+
+	return false
+}
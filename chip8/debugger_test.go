@@ -0,0 +1,207 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// debuggerROM counts V0 up in a subroutine and draws:
+//
+//	0200: CALL 0x020a
+//	0202: LOAD I,0x0050
+//	0204: DRAW V1,V1,1
+//	0206: JUMP 0x0200
+//	0208: -
+//	020a: ADD V0,1
+//	020c: RTS
+var debuggerROM = []byte{0x22, 0x0a, 0xa0, 0x50, 0xd1, 0x11, 0x12, 0x00, 0x00, 0x00, 0x70, 0x01, 0x00, 0xee}
+
+func newTestDebugger(t *testing.T) (*Chip8, *Debugger) {
+	c, _ := newTestChip8(t, debuggerROM...)
+	c.SetQuirks(QuirksSuperCHIP)
+	c.SetIPF(10)
+
+	return c, NewDebugger(c)
+}
+
+// runUntilPaused updates until the debugger pauses, failing after a number of frames
+func runUntilPaused(t *testing.T, c *Chip8, d *Debugger) Stop {
+	t.Helper()
+
+	for frame := 0; frame < 100 && !d.Paused(); frame++ {
+		require.NoError(t, c.Update())
+	}
+	stop, paused := d.Stopped()
+	require.True(t, paused, "debugger didn't pause")
+
+	return stop
+}
+
+func TestDebuggerBreakpoint(t *testing.T) {
+	c, d := newTestDebugger(t)
+	d.SetBreakpoint(0x20c)
+
+	stop := runUntilPaused(t, c, d)
+	assert.Equal(t, Stop{Reason: StopBreakpoint, PC: 0x20c}, stop)
+	assert.Equal(t, uint8(1), c.registers[0])
+
+	// paused machines don't run, even for frames
+	frames := c.Frames()
+	assert.NoError(t, c.Update())
+	assert.Equal(t, frames, c.Frames())
+	assert.Equal(t, uint16(0x20c), c.PC())
+
+	d.Continue()
+	stop = runUntilPaused(t, c, d)
+	assert.Equal(t, uint16(0x20c), stop.PC)
+	assert.Equal(t, uint8(2), c.registers[0])
+
+	d.ClearBreakpoint(0x20c)
+	assert.Empty(t, d.Breakpoints())
+}
+
+func TestDebuggerResumesPausedFrame(t *testing.T) {
+	c, d := newTestDebugger(t)
+	d.SetBreakpoint(0x202)
+
+	runUntilPaused(t, c, d)
+	assert.Equal(t, uint64(0), c.Frames(), "the frame paused after 3 instructions")
+	assert.Equal(t, uint(3), c.frameCycles)
+
+	d.ClearBreakpoint(0x202)
+	d.Continue()
+	assert.NoError(t, c.Update())
+	assert.Equal(t, uint64(1), c.Frames())
+	assert.Equal(t, uint(0), c.frameCycles)
+}
+
+func TestDebuggerOpcodeBreakpoint(t *testing.T) {
+	c, d := newTestDebugger(t)
+	require.NoError(t, d.SetOpcodeBreakpoint("dxyn"))
+	assert.Equal(t, []string{"DXYN"}, d.OpcodeBreakpoints())
+
+	assert.Equal(t, Stop{Reason: StopBreakpoint, PC: 0x204}, runUntilPaused(t, c, d))
+
+	d.ClearOpcodeBreakpoint("DXYN")
+	assert.Empty(t, d.OpcodeBreakpoints())
+
+	assert.ErrorIs(t, d.SetOpcodeBreakpoint("DXY"), ErrInvalidOpcodePattern)
+	assert.ErrorIs(t, d.SetOpcodeBreakpoint("DXYZ"), ErrInvalidOpcodePattern)
+}
+
+func TestDebuggerStep(t *testing.T) {
+	c, d := newTestDebugger(t)
+	d.Pause()
+
+	require.NoError(t, d.Step())
+	assert.Equal(t, Stop{Reason: StopStep, PC: 0x20a}, d.stop)
+	assert.Equal(t, []uint16{0x202}, c.Stack())
+
+	require.NoError(t, d.Step())
+	require.NoError(t, d.Step())
+	assert.Equal(t, uint16(0x202), c.PC())
+	assert.Empty(t, c.Stack())
+	assert.True(t, d.Paused())
+}
+
+func TestDebuggerStepOver(t *testing.T) {
+	c, d := newTestDebugger(t)
+	d.Pause()
+
+	require.NoError(t, d.StepOver())
+	assert.Equal(t, Stop{Reason: StopStep, PC: 0x202}, runUntilPaused(t, c, d))
+	assert.Equal(t, uint8(1), c.registers[0])
+
+	// not a call, just a step
+	require.NoError(t, d.StepOver())
+	assert.Equal(t, uint16(0x204), c.PC())
+}
+
+func TestDebuggerStepOut(t *testing.T) {
+	c, d := newTestDebugger(t)
+	d.Pause()
+	require.NoError(t, d.Step())
+
+	require.NoError(t, d.StepOut())
+	assert.Equal(t, Stop{Reason: StopStep, PC: 0x202}, runUntilPaused(t, c, d))
+	assert.Empty(t, c.Stack())
+}
+
+func TestDebuggerRunTo(t *testing.T) {
+	c, d := newTestDebugger(t)
+	d.Pause()
+
+	d.RunTo(0x206)
+	assert.Equal(t, Stop{Reason: StopStep, PC: 0x206}, runUntilPaused(t, c, d))
+
+	// breakpoints win over run to
+	d.SetBreakpoint(0x20a)
+	d.RunTo(0x204)
+	assert.Equal(t, Stop{Reason: StopBreakpoint, PC: 0x20a}, runUntilPaused(t, c, d))
+}
+
+func TestDebuggerWatchpoints(t *testing.T) {
+	tests := map[string]struct {
+		pc     uint16
+		v0     uint8
+		frames int
+	}{
+		"V0 == 0x3":   {pc: 0x20c, v0: 3},
+		"v0 changed":  {pc: 0x20c, v0: 1},
+		"I >= 0x50":   {pc: 0x204, v0: 1},
+		"PC == 0x20a": {pc: 0x20a, v0: 0},
+		"SP changed":  {pc: 0x20a, v0: 0},
+	}
+
+	for condition, expected := range tests {
+		c, d := newTestDebugger(t)
+		id, err := d.AddWatchpoint(condition)
+		require.NoError(t, err, condition)
+
+		stop := runUntilPaused(t, c, d)
+		assert.Equal(t, Stop{Reason: StopWatchpoint, PC: expected.pc, Watchpoint: id}, stop, condition)
+		assert.Equal(t, expected.v0, c.registers[0], condition)
+	}
+}
+
+func TestDebuggerMemoryWatchpoint(t *testing.T) {
+	// LOAD I,0x0300; LOAD V0,7; WRITE V0-V0; JUMP 0x0206
+	c, _ := newTestChip8(t, 0xa3, 0x00, 0x60, 0x07, 0xf0, 0x55, 0x12, 0x06)
+	d := NewDebugger(c)
+
+	id, err := d.AddWatchpoint("mem[I] changed")
+	require.NoError(t, err)
+	assert.Equal(t, []Watchpoint{{ID: id, Condition: "mem[I] changed"}}, d.Watchpoints())
+
+	stop := runUntilPaused(t, c, d)
+	assert.Equal(t, StopWatchpoint, stop.Reason)
+	assert.Equal(t, uint16(0x206), stop.PC)
+
+	d.RemoveWatchpoint(id)
+	assert.Empty(t, d.Watchpoints())
+}
+
+func TestDebuggerInvalidWatchpoints(t *testing.T) {
+	_, d := newTestDebugger(t)
+
+	for _, condition := range []string{"", "V3", "VG == 1", "V3 ~ 1", "V3 == x", "mem[J] changed", "V3 == 0x10000", "V3 changed 1"} {
+		_, err := d.AddWatchpoint(condition)
+		assert.ErrorIs(t, err, ErrInvalidWatchpoint, condition)
+	}
+}
+
+func TestDebuggerFault(t *testing.T) {
+	// RTS with an empty stack
+	c, _ := newTestChip8(t, 0x00, 0xee)
+	d := NewDebugger(c)
+
+	err := c.Update()
+	assert.ErrorIs(t, err, ErrStackUnderflow)
+
+	stop, paused := d.Stopped()
+	assert.True(t, paused)
+	assert.Equal(t, StopFault, stop.Reason)
+	assert.ErrorIs(t, stop.Err, ErrStackUnderflow)
+}
@@ -1,5 +1,32 @@
 package chip8
 
+import "sync"
+
+const opcodeCount = 1 << 16
+
+// decoded holds the instruction of every opcode, nil for invalid ones. Instructions don't change once built, so
+// they are decoded once and shared by all machines instead of being allocated on every cycle
+var decoded = sync.OnceValue(func() *[opcodeCount]Instruction {
+	var table [opcodeCount]Instruction
+	for opcode := range table {
+		if instruction, ok := decode(uint16(opcode)); ok {
+			table[opcode] = instruction
+		}
+	}
+
+	return &table
+})
+
+// Decode returns the instruction an opcode encodes, false if it is not a valid opcode
+func Decode(encoded uint16) (Instruction, bool) {
+	if instruction := decoded()[encoded]; instruction != nil {
+		return instruction, true
+	}
+
+	return NoOperation(), false
+}
+
+// decode builds the instruction an opcode encodes, false if it is not a valid opcode
 func decode(encoded uint16) (Instruction, bool) {
 	op := encoded & 0xF000
 
@@ -16,11 +43,27 @@ func decode(encoded uint16) (Instruction, bool) {
 
 	switch op {
 	case 0x0000:
+		if x == 0x0 && y == 0xC {
+			return ScrollDown(n), true
+		}
+		if x == 0x0 && y == 0xD {
+			return ScrollUp(n), true
+		}
 		switch nn {
 		case 0xE0:
 			return ClearScreen(), true
 		case 0xEE:
 			return Return(), true
+		case 0xFB:
+			return ScrollRight(), true
+		case 0xFC:
+			return ScrollLeft(), true
+		case 0xFD:
+			return Exit(), true
+		case 0xFE:
+			return LowResolution(), true
+		case 0xFF:
+			return HighResolution(), true
 		}
 	case 0x1000:
 		return Jump(nnn), true
@@ -31,7 +74,14 @@ func decode(encoded uint16) (Instruction, bool) {
 	case 0x4000:
 		return SkipNotEqual(x, nn), true
 	case 0x5000:
-		return SkipEqualRegister(x, y), true
+		switch n {
+		case 0x2:
+			return WriteRange(x, y), true
+		case 0x3:
+			return ReadRange(x, y), true
+		default:
+			return SkipEqualRegister(x, y), true
+		}
 	case 0x6000:
 		return Load(x, nn), true
 	case 0x7000:
@@ -75,6 +125,15 @@ func decode(encoded uint16) (Instruction, bool) {
 			return SkipNotPressed(x), true
 		}
 	case 0xF000:
+		if encoded == longIndexOpcode {
+			return LoadLongIndex(), true
+		}
+		if nn == 0x01 {
+			return SelectPlanes(x), true
+		}
+		if encoded == 0xF002 {
+			return LoadAudioPattern(), true
+		}
 		switch nn {
 		case 0x07:
 			return LoadRegisterDelayTimer(x), true
@@ -88,12 +147,20 @@ func decode(encoded uint16) (Instruction, bool) {
 			return AddIndex(x), true
 		case 0x29:
 			return LoadDigitIndex(x), true
+		case 0x30:
+			return LoadBigDigitIndex(x), true
 		case 0x33:
 			return BCD(x), true
+		case 0x3A:
+			return LoadPitch(x), true
 		case 0x55:
 			return Write(x), true
 		case 0x65:
 			return Read(x), true
+		case 0x75:
+			return WriteUserFlags(x), true
+		case 0x85:
+			return ReadUserFlags(x), true
 		}
 	}
 
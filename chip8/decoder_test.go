@@ -0,0 +1,81 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkROM loops over a mix of common instructions
+//
+//	200: LOAD I,0x0050
+//	202: ADD V0,1
+//	204: ADD V1,V0
+//	206: SKE V0,0
+//	208: DRAW V0,V1,5
+//	20a: ADD I,V2
+//	20c: CALL 0x0210
+//	20e: JUMP 0x0200
+//	210: RTS
+var benchmarkROM = []byte{0xa0, 0x50, 0x70, 0x01, 0x81, 0x04, 0x30, 0x00, 0xd0, 0x15, 0xf2, 0x1e, 0x22, 0x10, 0x12, 0x00, 0x00, 0xee}
+
+func TestDecodeMatchesDecoder(t *testing.T) {
+	for opcode := 0; opcode < opcodeCount; opcode++ {
+		instruction, ok := Decode(uint16(opcode))
+		expected, expectedOK := decode(uint16(opcode))
+
+		if !assert.Equal(t, expectedOK, ok, "opcode %04x", opcode) {
+			return
+		}
+		if ok && !assert.Equal(t, expected.String(), instruction.String(), "opcode %04x", opcode) {
+			return
+		}
+	}
+}
+
+func TestCycleDoesNotAllocate(t *testing.T) {
+	c, _ := newTestChip8(t, benchmarkROM...)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		assert.NoError(t, c.Cycle())
+	})
+
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkCycle(b *testing.B) {
+	benchmarkInstructions(b, (*Chip8).Cycle)
+}
+
+// BenchmarkCycleDecodingEachOpcode is how Cycle ran before the decoded table, building the instruction every time
+func BenchmarkCycleDecodingEachOpcode(b *testing.B) {
+	benchmarkInstructions(b, func(c *Chip8) error {
+		opcode, err := c.fetcher.Fetch(c)
+		if err != nil {
+			return err
+		}
+
+		instruction, ok := decode(opcode)
+		if !ok {
+			return ErrInvalidOpcode
+		}
+
+		return instruction.Execute(c)
+	})
+}
+
+// benchmarkInstructions runs the benchmark ROM one instruction at a time, reporting instructions per second
+func benchmarkInstructions(b *testing.B, cycle func(c *Chip8) error) {
+	c, _ := newTestChip8(b, benchmarkROM...)
+	Decode(0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if err := cycle(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "instructions/s")
+}
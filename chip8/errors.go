@@ -0,0 +1,52 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStackOverflow     = errors.New("stack overflow")
+	ErrStackUnderflow    = errors.New("stack underflow")
+	ErrMemoryOutOfBounds = errors.New("memory access out of bounds")
+	ErrInvalidOpcode     = errors.New("invalid opcode")
+)
+
+// Fault is a machine fault raised by the instruction at PC, with the machine state when it happened
+// Use errors.Is with ErrStackOverflow, ErrStackUnderflow, ErrMemoryOutOfBounds or ErrInvalidOpcode to tell them apart
+type Fault struct {
+	Err       error
+	PC        uint16
+	Opcode    uint16
+	Index     uint16
+	SP        uint8
+	Registers Registers
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf(
+		"%s (PC:%s opcode:%s I:%s SP:%d V:[%s])",
+		f.Err, hexdump16(f.PC), hexdump16(f.Opcode), hexdump16(f.Index), f.SP, f.Registers,
+	)
+}
+
+func (f *Fault) Unwrap() error {
+	return f.Err
+}
+
+// fault wraps an instruction error with the current machine state
+func (c *Chip8) fault(err error, pc, opcode uint16) *Fault {
+	return &Fault{
+		Err:       err,
+		PC:        pc,
+		Opcode:    opcode,
+		Index:     c.index,
+		SP:        c.stack.pointer,
+		Registers: c.registers,
+	}
+}
+
+// outOfBounds returns ErrMemoryOutOfBounds for n bytes at address
+func outOfBounds(address uint16, n int) error {
+	return fmt.Errorf("%w: %d bytes at %s", ErrMemoryOutOfBounds, n, hexdump16(address))
+}
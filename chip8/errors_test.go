@@ -0,0 +1,105 @@
+package chip8
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackOverflow(t *testing.T) {
+	// CALL 0x0200
+	c, _ := newTestChip8(t, 0x22, 0x00)
+
+	for j := 0; j < stackLevels; j++ {
+		assert.NoError(t, c.Cycle())
+	}
+
+	err := c.Cycle()
+	assert.ErrorIs(t, err, ErrStackOverflow)
+
+	var fault *Fault
+	assert.True(t, errors.As(err, &fault))
+	assert.Equal(t, uint16(0x200), fault.PC)
+	assert.Equal(t, uint16(0x2200), fault.Opcode)
+	assert.Equal(t, uint8(stackLevels), fault.SP)
+}
+
+func TestStackUnderflow(t *testing.T) {
+	// RTS
+	c, _ := newTestChip8(t, 0x00, 0xee)
+
+	assert.ErrorIs(t, c.Cycle(), ErrStackUnderflow)
+}
+
+func TestMemoryOutOfBounds(t *testing.T) {
+	// LOAD I,0x0ffe; LOAD V3,7; WRITE V0-V3
+	c, _ := newTestChip8(t, 0xaf, 0xfe, 0x63, 0x07, 0xf3, 0x55)
+
+	assert.NoError(t, c.Cycle())
+	assert.NoError(t, c.Cycle())
+
+	err := c.Cycle()
+	assert.ErrorIs(t, err, ErrMemoryOutOfBounds)
+	assert.EqualError(t, err, "memory access out of bounds: 4 bytes at 0ffe (PC:0204 opcode:f355 I:0ffe SP:0 V:[0:00 1:00 2:00 3:07 4:00 5:00 6:00 7:00 8:00 9:00 a:00 b:00 c:00 d:00 e:00 f:00])")
+}
+
+func TestDrawSpriteOutOfBounds(t *testing.T) {
+	// LOAD I,0x0fff; DRAW V0,V0,2
+	c, _ := newTestChip8(t, 0xaf, 0xff, 0xd0, 0x02)
+
+	assert.NoError(t, c.Cycle())
+	assert.ErrorIs(t, c.Cycle(), ErrMemoryOutOfBounds)
+}
+
+func TestInvalidOpcode(t *testing.T) {
+	c, _ := newTestChip8(t, 0xe0, 0x00)
+
+	err := c.Cycle()
+	assert.ErrorIs(t, err, ErrInvalidOpcode)
+	assert.ErrorContains(t, err, "invalid opcode (PC:0200 opcode:e000")
+}
+
+func TestSkipPressedKeyOutOfRange(t *testing.T) {
+	// LOAD V0,0xff; SKP V0
+	c, _ := newTestChip8(t, 0x60, 0xff, 0xe0, 0x9e)
+	c.input.keys[0xf] = true
+
+	assert.NoError(t, c.Cycle())
+	assert.NoError(t, c.Cycle())
+	assert.Equal(t, uint16(0x206), c.PC())
+}
+
+func TestSkipNotPressedKeyOutOfRange(t *testing.T) {
+	// LOAD V0,0xff; SKNP V0
+	c, _ := newTestChip8(t, 0x60, 0xff, 0xe0, 0xa1)
+
+	assert.NoError(t, c.Cycle())
+	assert.NoError(t, c.Cycle())
+	assert.Equal(t, uint16(0x206), c.PC())
+}
+
+func TestLoadDigitIndexOutOfRange(t *testing.T) {
+	// LOAD V0,0xff; LOAD I,V0
+	c, _ := newTestChip8(t, 0x60, 0xff, 0xf0, 0x29)
+
+	assert.NoError(t, c.Cycle())
+	assert.NoError(t, c.Cycle())
+	assert.Equal(t, uint16(fontStartMemoryAddress+0xf*digitBytes), c.index)
+}
+
+func TestCounterOutOfBounds(t *testing.T) {
+	// JUMP 0x0ffe
+	c, _ := newTestChip8(t, 0x1f, 0xfe)
+	c.memory[0xffe] = 0x60
+
+	assert.NoError(t, c.Cycle())
+	assert.NoError(t, c.Cycle())
+	assert.ErrorIs(t, c.Cycle(), ErrMemoryOutOfBounds)
+}
+
+func TestLoadROMTooBig(t *testing.T) {
+	c, _ := newTestChip8(t)
+
+	assert.ErrorIs(t, c.LoadROM(bytesOf(memoryLocations)), ErrMemoryOutOfBounds)
+}
@@ -0,0 +1,127 @@
+package chip8
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnknownImageFormat is returned when exporting the screen to a file that isn't .png, .pbm or .txt
+var ErrUnknownImageFormat = errors.New("unknown image format")
+
+// Palette is indexed by the planes a pixel is on in: off, plane 1, plane 2, both
+type Palette [1 << planeCount]color.RGBA
+
+// DefaultPalette draws plane 1 white on black and plane 2 in greys
+var DefaultPalette = Palette{
+	{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+	{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	{R: 0xaa, G: 0xaa, B: 0xaa, A: 0xff},
+	{R: 0x55, G: 0x55, B: 0x55, A: 0xff},
+}
+
+// Image draws the screen with a palette, each pixel as a square of scale by scale
+func (s *Screen) Image(palette Palette, scale int) *image.Paletted {
+	scale = max(scale, 1)
+	colours := make(color.Palette, len(palette))
+	for j, c := range palette {
+		colours[j] = c
+	}
+
+	w, h := s.Layout()
+	img := image.NewPaletted(image.Rect(0, 0, w*scale, h*scale), colours)
+	for y := 0; y < h*scale; y++ {
+		for x := 0; x < w*scale; x++ {
+			img.Pix[y*img.Stride+x] = s.Pixel(x/scale, y/scale)
+		}
+	}
+
+	return img
+}
+
+// WritePNG encodes the screen as PNG, see Image
+func (s *Screen) WritePNG(w io.Writer, palette Palette, scale int) error {
+	return png.Encode(w, s.Image(palette, scale))
+}
+
+// WritePBM encodes the screen as a binary PBM, pixels on in any plane being black
+func (s *Screen) WritePBM(w io.Writer) error {
+	width, height := s.Layout()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P4\n%d %d\n", width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x += bytePixels {
+			var b byte
+			for j := 0; j < bytePixels; j++ {
+				if s.Get(x+j, y) {
+					b |= 1 << (bytePixels - 1 - j)
+				}
+			}
+			_ = bw.WriteByte(b)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Text draws the screen a line per row, # for pixels on in any plane and . for pixels off
+// It is meant to be compared in tests, where a failing assertion shows the picture
+func (s *Screen) Text() string {
+	w, h := s.Layout()
+
+	var sb strings.Builder
+	sb.Grow((w + 1) * h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if s.Get(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// Export writes the screen to a file as PNG, PBM or text, as its extension tells: .png, .pbm or .txt
+// Palette and scale only apply to PNG
+func (s *Screen) Export(path string, palette Palette, scale int) error {
+	var write func(w io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		write = func(w io.Writer) error {
+			return s.WritePNG(w, palette, scale)
+		}
+	case ".pbm":
+		write = s.WritePBM
+	case ".txt":
+		write = func(w io.Writer) error {
+			_, err := io.WriteString(w, s.Text())
+			return err
+		}
+	default:
+		return fmt.Errorf("%w %q, expected .png, .pbm or .txt", ErrUnknownImageFormat, filepath.Ext(path))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
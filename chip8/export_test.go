@@ -0,0 +1,81 @@
+package chip8
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newExportScreen() *Screen {
+	s := NewScreen()
+	s.Set(0, 0, true)
+	s.Set(9, 1, true)
+	s.SelectPlanes(0x2)
+	s.Set(63, 31, true)
+
+	return s
+}
+
+func TestScreenText(t *testing.T) {
+	rows := strings.Split(newExportScreen().Text(), "\n")
+
+	assert.Len(t, rows, screenHeight+1)
+	assert.Equal(t, "#"+strings.Repeat(".", 63), rows[0])
+	assert.Equal(t, ".........#"+strings.Repeat(".", 54), rows[1])
+	assert.Equal(t, strings.Repeat(".", 63)+"#", rows[31])
+	assert.Empty(t, rows[32])
+}
+
+func TestScreenPBM(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, newExportScreen().WritePBM(&buf))
+
+	header := "P4\n64 32\n"
+	assert.Equal(t, header, buf.String()[:len(header)])
+
+	data := buf.Bytes()[len(header):]
+	assert.Len(t, data, 8*32)
+	assert.Equal(t, byte(0x80), data[0])
+	assert.Equal(t, byte(0x40), data[8+1])
+	assert.Equal(t, byte(0x01), data[len(data)-1])
+}
+
+func TestScreenPNG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, newExportScreen().WritePNG(&buf, DefaultPalette, 3))
+
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 64*3, img.Bounds().Dx())
+	assert.Equal(t, 32*3, img.Bounds().Dy())
+
+	assert.Equal(t, DefaultPalette[1], rgba(img.At(2, 2)))
+	assert.Equal(t, DefaultPalette[0], rgba(img.At(3, 0)))
+	assert.Equal(t, DefaultPalette[2], rgba(img.At(63*3+2, 31*3)))
+}
+
+func TestScreenExport(t *testing.T) {
+	dir := t.TempDir()
+	s := newExportScreen()
+
+	for _, name := range []string{"shot.png", "shot.pbm", "shot.txt"} {
+		assert.NoError(t, s.Export(filepath.Join(dir, name), DefaultPalette, 1), name)
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, "shot.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, s.Text(), string(text))
+
+	assert.ErrorIs(t, s.Export(filepath.Join(dir, "shot.bmp"), DefaultPalette, 1), ErrUnknownImageFormat)
+}
+
+func rgba(c color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+}
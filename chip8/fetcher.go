@@ -1,6 +1,9 @@
 package chip8
 
-const instructionBytes = 2
+const (
+	instructionBytes = 2
+	longIndexOpcode  = 0xF000
+)
 
 type Fetcher struct {
 	counter uint16
@@ -12,19 +15,27 @@ func NewFetcher(counter uint16) *Fetcher {
 	}
 }
 
-func (f *Fetcher) Fetch(c *Chip8) uint16 {
-	instruction := c.memory.ReadWord(f.counter)
+// Fetch returns the instruction at the counter and moves past it
+// Running off the end of memory is ErrMemoryOutOfBounds
+func (f *Fetcher) Fetch(c *Chip8) (uint16, error) {
+	instruction, err := c.memory.ReadWord(f.counter)
+	if err != nil {
+		return 0, err
+	}
+
 	f.incrementCounter()
-	return instruction
+	return instruction, nil
 }
 
-func (f *Fetcher) Skip() {
+// Skip jumps over the next instruction, including the 4-byte XO-CHIP F000 NNNN
+func (f *Fetcher) Skip(c *Chip8) {
+	if next, err := c.memory.ReadWord(f.counter); err == nil && next == longIndexOpcode {
+		f.incrementCounter()
+	}
 	f.incrementCounter()
 }
 
 func (f *Fetcher) incrementCounter() {
-	// TODO: handle PC > 4096 / 0x1000 (12 bits). 2 options: PC overflow (error) or wrap (modulo)
-	// TODO: also check PC < 521 / 0x200 (program start)
 	f.counter += instructionBytes
 }
 
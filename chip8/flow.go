@@ -0,0 +1,63 @@
+package chip8
+
+// FlowKind is how an instruction moves the program counter
+type FlowKind uint8
+
+const (
+	// FlowNext continues with the next instruction
+	FlowNext FlowKind = iota
+	// FlowSkip continues with the next instruction or the one after it
+	FlowSkip
+	// FlowJump continues at Target
+	FlowJump
+	// FlowCall runs the subroutine at Target, then continues with the next instruction
+	FlowCall
+	// FlowReturn continues at the address on top of the stack
+	FlowReturn
+	// FlowIndirect continues at an address only known at runtime
+	FlowIndirect
+	// FlowExit stops the interpreter
+	FlowExit
+)
+
+// Flow describes what an instruction does to the program counter and I, for static analysis like disassembly
+type Flow struct {
+	Kind   FlowKind
+	Target uint16 // address for FlowJump and FlowCall
+	Size   int    // instruction bytes, 4 for F000 NNNN whose operand follows the opcode
+
+	LoadsIndex  bool   // I is set to Index
+	Index       uint16 // address loaded by ANNN
+	SpriteBytes int    // bytes read from I by DXYN, 0 for any other instruction
+}
+
+// FlowOf returns the control flow of an instruction returned by Decode
+func FlowOf(instruction Instruction) Flow {
+	flow := Flow{Kind: FlowNext, Size: instructionBytes}
+
+	switch i := instruction.(type) {
+	case *jump:
+		flow.Kind, flow.Target = FlowJump, i.nnn
+	case *call:
+		flow.Kind, flow.Target = FlowCall, i.nnn
+	case *returnFromSubroutine:
+		flow.Kind = FlowReturn
+	case *jumpRegister0:
+		flow.Kind = FlowIndirect
+	case *exit:
+		flow.Kind = FlowExit
+	case *skipEqual, *skipNotEqual, *skipEqualRegister, *skipNotEqualRegister, *skipPressed, *skipNotPressed:
+		flow.Kind = FlowSkip
+	case *loadIndex:
+		flow.LoadsIndex, flow.Index = true, i.nnn
+	case *loadLongIndex:
+		flow.Size = 2 * instructionBytes
+	case *drawSprite:
+		flow.SpriteBytes = int(i.n)
+		if i.n == 0 {
+			flow.SpriteBytes = bigSpriteBytes
+		}
+	}
+
+	return flow
+}
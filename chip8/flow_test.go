@@ -0,0 +1,30 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlowOf(t *testing.T) {
+	tests := map[uint16]Flow{
+		0x00E0: {Kind: FlowNext, Size: 2},
+		0x00EE: {Kind: FlowReturn, Size: 2},
+		0x00FD: {Kind: FlowExit, Size: 2},
+		0x1234: {Kind: FlowJump, Target: 0x234, Size: 2},
+		0x2456: {Kind: FlowCall, Target: 0x456, Size: 2},
+		0x3100: {Kind: FlowSkip, Size: 2},
+		0xE19E: {Kind: FlowSkip, Size: 2},
+		0xA300: {Kind: FlowNext, Size: 2, LoadsIndex: true, Index: 0x300},
+		0xB300: {Kind: FlowIndirect, Size: 2},
+		0xD125: {Kind: FlowNext, Size: 2, SpriteBytes: 5},
+		0xD120: {Kind: FlowNext, Size: 2, SpriteBytes: 32},
+		0xF000: {Kind: FlowNext, Size: 4},
+	}
+
+	for opcode, flow := range tests {
+		instruction, ok := Decode(opcode)
+		assert.True(t, ok)
+		assert.Equal(t, flow, FlowOf(instruction), "%04x", opcode)
+	}
+}
@@ -0,0 +1,35 @@
+package chip8
+
+// Display presents the framebuffer to the user
+type Display interface {
+	// Refresh is called at the end of every Update that changed the framebuffer
+	Refresh(s *Screen)
+}
+
+// Audio plays the buzzer while the sound timer is active
+type Audio interface {
+	// SetBeep is called whenever the buzzer turns on or off
+	SetBeep(on bool)
+	// SetPattern is called whenever an XO-CHIP program loads a new audio pattern or changes the pitch
+	// The buzzer then plays the pattern bits as 1-bit samples at PatternRate(pitch) instead of its default tone
+	SetPattern(pattern [AudioPatternBytes]uint8, pitch uint8)
+}
+
+// Keypad reports the state of the hex keypad
+type Keypad interface {
+	// Pressed returns the keys currently held down, indexed by key value
+	Pressed() [KeyCount]bool
+}
+
+// headless is the default frontend: nothing is shown, nothing is heard and no key is ever pressed
+type headless struct{}
+
+func (h headless) Refresh(_ *Screen) {}
+
+func (h headless) SetBeep(_ bool) {}
+
+func (h headless) SetPattern(_ [AudioPatternBytes]uint8, _ uint8) {}
+
+func (h headless) Pressed() [KeyCount]bool {
+	return [KeyCount]bool{}
+}
@@ -2,80 +2,62 @@ package chip8
 
 import (
 	"fmt"
-	"slices"
 	"strings"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-const keyCount = 16
-
-// 1 2 3 C     |\   1 2 3 4
-// 4 5 6 D  ---- \  Q W E R
-// 7 8 9 E  ---- /  A S D F
-// A 0 B F     |/   Z X C V
-var keyMap = [keyCount]ebiten.Key{
-	ebiten.KeyX,
-	ebiten.Key1,
-	ebiten.Key2,
-	ebiten.Key3,
-	ebiten.KeyQ,
-	ebiten.KeyW,
-	ebiten.KeyE,
-	ebiten.KeyA,
-	ebiten.KeyS,
-	ebiten.KeyD,
-	ebiten.KeyZ,
-	ebiten.KeyC,
-	ebiten.Key4,
-	ebiten.KeyR,
-	ebiten.KeyF,
-	ebiten.KeyV,
-}
+const KeyCount = 16
+
+// KeyboardLayout holds, for each keypad key, the QWERTY keyboard key playing it, frontends mapping the 4x4 block
+// under 1234 onto the keypad
+//
+//	1 2 3 C     |\   1 2 3 4
+//	4 5 6 D  ---- \  Q W E R
+//	7 8 9 E  ---- /  A S D F
+//	A 0 B F     |/   Z X C V
+const KeyboardLayout = "x123qweasdzc4rfv"
 
 type Input struct {
-	keys         [keyCount]bool // true if pressed
-	waitCallback func(uint8)
+	keys     [KeyCount]bool // true if pressed
+	waiting  bool
+	register uint8 // register receiving the key once waiting ends
 }
 
 func NewInput() *Input {
 	return &Input{
-		keys:         [keyCount]bool{},
-		waitCallback: nil,
+		keys:    [KeyCount]bool{},
+		waiting: false,
 	}
 }
 
 // Detect return true waiting for key press + release, false otherwise
-func (i *Input) Detect() bool {
-	buf := make([]ebiten.Key, 0, keyCount)
+// The released key is stored in the register given to Wait
+func (i *Input) Detect(keypad Keypad, registers *Registers) bool {
+	pressed := keypad.Pressed()
+	defer func() { i.keys = pressed }()
+
 	if i.isWaiting() {
-		detected := inpututil.AppendJustReleasedKeys(buf)
-		if len(detected) == 0 {
-			return true
+		for key, down := range pressed {
+			if i.keys[key] && !down {
+				registers[i.register] = uint8(key)
+				i.waiting = false
+				return false
+			}
 		}
 
-		index := slices.Index(keyMap[:], detected[0]) // TODO: use map instead
-		i.waitCallback(uint8(index))
-		i.waitCallback = nil
-
-		return false
-	}
-
-	detected := inpututil.AppendPressedKeys(buf)
-	for j, key := range keyMap {
-		i.keys[j] = slices.Contains(detected, key) // TODO: use map instead
+		return true
 	}
 
 	return false
 }
 
-func (i *Input) Wait(callback func(uint8)) {
-	i.waitCallback = callback
+// Wait makes Detect wait for a key press + release, storing the key in register VX
+func (i *Input) Wait(x uint8) {
+	i.waiting = true
+	i.register = x
 }
 
 func (i *Input) isWaiting() bool {
-	return i.waitCallback != nil
+	return i.waiting
 }
 
 func (i *Input) String() string {
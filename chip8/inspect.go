@@ -0,0 +1,72 @@
+package chip8
+
+// PC returns the address of the next instruction
+func (c *Chip8) PC() uint16 {
+	return c.fetcher.GetCounter()
+}
+
+func (c *Chip8) SetPC(pc uint16) {
+	c.fetcher.SetCounter(pc)
+}
+
+func (c *Chip8) Index() uint16 {
+	return c.index
+}
+
+func (c *Chip8) SetIndex(index uint16) {
+	c.index = index
+}
+
+func (c *Chip8) Registers() Registers {
+	return c.registers
+}
+
+func (c *Chip8) SetRegister(x, value uint8) {
+	c.registers[x&0xF] = value
+}
+
+// Stack returns a copy of the return addresses, the innermost call last
+func (c *Chip8) Stack() []uint16 {
+	return append([]uint16(nil), c.stack.data[:c.stack.pointer]...)
+}
+
+// Memory returns the machine memory itself, writes to it change the running program
+func (c *Chip8) Memory() Memory {
+	return c.memory
+}
+
+func (c *Chip8) DelayTimer() uint8 {
+	return c.delayTimer.GetValue()
+}
+
+func (c *Chip8) SoundTimer() uint8 {
+	return c.soundTimer.GetValue()
+}
+
+// WaitingKey returns true if FX0A is waiting for a key press and release, false otherwise
+func (c *Chip8) WaitingKey() bool {
+	return c.input.isWaiting()
+}
+
+// StackPointer returns the number of return addresses on the stack
+func (c *Chip8) StackPointer() uint8 {
+	return c.stack.pointer
+}
+
+// SetStackPointer changes the stack depth, keeping the addresses below it
+func (c *Chip8) SetStackPointer(sp uint8) error {
+	if sp > stackLevels {
+		return ErrStackOverflow
+	}
+
+	c.stack.pointer = sp
+	return nil
+}
+
+func (c *Chip8) SetDelayTimer(value uint8) {
+	c.delayTimer.SetValue(value)
+}
+
+func (c *Chip8) SetSoundTimer(value uint8) {
+	c.soundTimer.SetValue(value)
+}
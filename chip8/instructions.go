@@ -1,13 +1,12 @@
 package chip8
 
 import (
-	"crypto/rand"
 	"fmt"
 )
 
 type Instruction interface {
 	fmt.Stringer
-	Execute(c *Chip8)
+	Execute(c *Chip8) error
 }
 
 // NoOperation do nothing
@@ -21,8 +20,8 @@ func (i noOperation) String() string {
 	return "NOP"
 }
 
-func (i noOperation) Execute(_ *Chip8) {
-
+func (i noOperation) Execute(_ *Chip8) error {
+	return nil
 }
 
 // ClearScreen 00E0: Clear the screen
@@ -36,8 +35,10 @@ func (i clearScreen) String() string {
 	return "CLR"
 }
 
-func (i clearScreen) Execute(c *Chip8) {
+func (i clearScreen) Execute(c *Chip8) error {
 	c.screen.Clear()
+
+	return nil
 }
 
 // Return 00EE: Return from a subroutine
@@ -51,8 +52,14 @@ func (i returnFromSubroutine) String() string {
 	return "RTS"
 }
 
-func (i returnFromSubroutine) Execute(c *Chip8) {
-	c.fetcher.SetCounter(c.stack.Pop())
+func (i returnFromSubroutine) Execute(c *Chip8) error {
+	address, err := c.stack.Pop()
+	if err != nil {
+		return err
+	}
+
+	c.fetcher.SetCounter(address)
+	return nil
 }
 
 // Jump 1NNN: Jump to address NNN
@@ -68,8 +75,10 @@ func (i jump) String() string {
 	return fmt.Sprintf("JUMP 0x%04x", i.nnn)
 }
 
-func (i jump) Execute(c *Chip8) {
+func (i jump) Execute(c *Chip8) error {
 	c.fetcher.SetCounter(i.nnn)
+
+	return nil
 }
 
 // Call 2NNN: Execute subroutine starting at address NNN
@@ -87,9 +96,13 @@ func (i call) String() string {
 	return fmt.Sprintf("CALL 0x%04x", i.nnn)
 }
 
-func (i call) Execute(c *Chip8) {
-	c.stack.Push(c.fetcher.GetCounter())
+func (i call) Execute(c *Chip8) error {
+	if err := c.stack.Push(c.fetcher.GetCounter()); err != nil {
+		return err
+	}
+
 	c.fetcher.SetCounter(i.nnn)
+	return nil
 }
 
 // SkipEqual 3XNN: Skip the following instruction if the value of register VX equals NN
@@ -105,10 +118,12 @@ func (i skipEqual) String() string {
 	return fmt.Sprintf("SKE V%x,%x", i.x, i.nn)
 }
 
-func (i skipEqual) Execute(c *Chip8) {
+func (i skipEqual) Execute(c *Chip8) error {
 	if c.registers[i.x] == i.nn {
-		c.fetcher.Skip()
+		c.fetcher.Skip(c)
 	}
+
+	return nil
 }
 
 // SkipNotEqual 4XNN: Skip the following instruction if the value of register VX is not equal to NN
@@ -124,10 +139,12 @@ func (i skipNotEqual) String() string {
 	return fmt.Sprintf("SKNE V%x,%x", i.x, i.nn)
 }
 
-func (i skipNotEqual) Execute(c *Chip8) {
+func (i skipNotEqual) Execute(c *Chip8) error {
 	if c.registers[i.x] != i.nn {
-		c.fetcher.Skip()
+		c.fetcher.Skip(c)
 	}
+
+	return nil
 }
 
 // SkipEqualRegister 5XY0: Skip the following instruction
@@ -144,10 +161,12 @@ func (i skipEqualRegister) String() string {
 	return fmt.Sprintf("SKE V%x,V%x", i.x, i.y)
 }
 
-func (i skipEqualRegister) Execute(c *Chip8) {
+func (i skipEqualRegister) Execute(c *Chip8) error {
 	if c.registers[i.x] == c.registers[i.y] {
-		c.fetcher.Skip()
+		c.fetcher.Skip(c)
 	}
+
+	return nil
 }
 
 // Load 6XNN: Store number NN in register VX
@@ -163,8 +182,10 @@ func (i load) String() string {
 	return fmt.Sprintf("LOAD V%x,%x", i.x, i.nn)
 }
 
-func (i load) Execute(c *Chip8) {
+func (i load) Execute(c *Chip8) error {
 	c.registers[i.x] = i.nn
+
+	return nil
 }
 
 // Add 7XNN: Add the value NN to register VX
@@ -180,8 +201,10 @@ func (i add) String() string {
 	return fmt.Sprintf("ADD V%x,%x", i.x, i.nn)
 }
 
-func (i add) Execute(c *Chip8) {
+func (i add) Execute(c *Chip8) error {
 	c.registers[i.x] += i.nn
+
+	return nil
 }
 
 // LoadRegister 8XY0: Store the value of register VY in register VX
@@ -197,11 +220,14 @@ func (i loadRegister) String() string {
 	return fmt.Sprintf("LOAD V%x,V%x", i.x, i.y)
 }
 
-func (i loadRegister) Execute(c *Chip8) {
+func (i loadRegister) Execute(c *Chip8) error {
 	c.registers[i.x] = c.registers[i.y]
+
+	return nil
 }
 
 // Or 8XY1: Set VX to VX OR VY
+// Set VF to 00 with the VF reset quirk
 func Or(x, y uint8) Instruction {
 	return &or{x: x, y: y}
 }
@@ -214,11 +240,17 @@ func (i or) String() string {
 	return fmt.Sprintf("OR V%x,V%x", i.x, i.y)
 }
 
-func (i or) Execute(c *Chip8) {
+func (i or) Execute(c *Chip8) error {
 	c.registers[i.x] |= c.registers[i.y]
+	if c.quirks.VFReset {
+		c.registers[flagRegister] = 0
+	}
+
+	return nil
 }
 
 // And 8XY2: Set VX to VX AND VY
+// Set VF to 00 with the VF reset quirk
 func And(x, y uint8) Instruction {
 	return &and{x: x, y: y}
 }
@@ -231,11 +263,17 @@ func (i and) String() string {
 	return fmt.Sprintf("AND V%x,V%x", i.x, i.y)
 }
 
-func (i and) Execute(c *Chip8) {
+func (i and) Execute(c *Chip8) error {
 	c.registers[i.x] &= c.registers[i.y]
+	if c.quirks.VFReset {
+		c.registers[flagRegister] = 0
+	}
+
+	return nil
 }
 
 // Xor 8XY3: Set VX to VX XOR VY
+// Set VF to 00 with the VF reset quirk
 func Xor(x, y uint8) Instruction {
 	return &xor{x: x, y: y}
 }
@@ -248,8 +286,13 @@ func (i xor) String() string {
 	return fmt.Sprintf("XOR V%x,V%x", i.x, i.y)
 }
 
-func (i xor) Execute(c *Chip8) {
+func (i xor) Execute(c *Chip8) error {
 	c.registers[i.x] ^= c.registers[i.y]
+	if c.quirks.VFReset {
+		c.registers[flagRegister] = 0
+	}
+
+	return nil
 }
 
 // AddRegister 8XY4: Add the value of register VY to register VX
@@ -267,13 +310,15 @@ func (i addRegister) String() string {
 	return fmt.Sprintf("ADD V%x,V%x", i.x, i.y)
 }
 
-func (i addRegister) Execute(c *Chip8) {
+func (i addRegister) Execute(c *Chip8) error {
 	c.registers[i.x] += c.registers[i.y]
 	if c.registers[i.x] < c.registers[i.y] {
 		c.registers[flagRegister] = 1
 	} else {
 		c.registers[flagRegister] = 0
 	}
+
+	return nil
 }
 
 // SubRegister 8XY5: Subtract the value of register VY from register VX
@@ -291,7 +336,7 @@ func (i subRegister) String() string {
 	return fmt.Sprintf("SUB V%x,V%x", i.x, i.y)
 }
 
-func (i subRegister) Execute(c *Chip8) {
+func (i subRegister) Execute(c *Chip8) error {
 	borrow := c.registers[i.y] > c.registers[i.x]
 	c.registers[i.x] -= c.registers[i.y]
 	if borrow {
@@ -299,11 +344,13 @@ func (i subRegister) Execute(c *Chip8) {
 	} else {
 		c.registers[flagRegister] = 1
 	}
+
+	return nil
 }
 
 // ShiftRight 8XY6: Store the value of register VY shifted right one bit in register VX
 // Set register VF to the least significant bit prior to the shift
-// VY is unchanged
+// VY is unchanged. With the shift quirk VX is shifted in place instead
 func ShiftRight(x, y uint8) Instruction {
 	return &shiftRight{x: x, y: y}
 }
@@ -316,10 +363,15 @@ func (i shiftRight) String() string {
 	return fmt.Sprintf("SHR V%x,V%x", i.x, i.y)
 }
 
-func (i shiftRight) Execute(c *Chip8) {
-	out := c.registers[i.y] & 1
-	c.registers[i.x] = c.registers[i.y] >> 1
-	c.registers[flagRegister] = out
+func (i shiftRight) Execute(c *Chip8) error {
+	v := c.registers[i.y]
+	if c.quirks.ShiftVX {
+		v = c.registers[i.x]
+	}
+	c.registers[i.x] = v >> 1
+	c.registers[flagRegister] = v & 1
+
+	return nil
 }
 
 // ReverseSubRegister 8XY7: Set register VX to the value of VY minus VX
@@ -337,7 +389,7 @@ func (i reverseSubRegister) String() string {
 	return fmt.Sprintf("RSB V%x,V%x", i.x, i.y)
 }
 
-func (i reverseSubRegister) Execute(c *Chip8) {
+func (i reverseSubRegister) Execute(c *Chip8) error {
 	borrow := c.registers[i.x] > c.registers[i.y]
 	c.registers[i.x] = c.registers[i.y] - c.registers[i.x]
 	if borrow {
@@ -345,11 +397,13 @@ func (i reverseSubRegister) Execute(c *Chip8) {
 	} else {
 		c.registers[flagRegister] = 1
 	}
+
+	return nil
 }
 
 // ShiftLeft 8XYE: Store the value of register VY shifted left one bit in register VX
 // Set register VF to the most significant bit prior to the shift
-// VY is unchanged
+// VY is unchanged. With the shift quirk VX is shifted in place instead
 func ShiftLeft(x, y uint8) Instruction {
 	return &shiftLeft{x: x, y: y}
 }
@@ -362,10 +416,15 @@ func (i shiftLeft) String() string {
 	return fmt.Sprintf("SHL V%x,V%x", i.x, i.y)
 }
 
-func (i shiftLeft) Execute(c *Chip8) {
-	out := c.registers[i.y] >> 7
-	c.registers[i.x] = c.registers[i.y] << 1
-	c.registers[flagRegister] = out
+func (i shiftLeft) Execute(c *Chip8) error {
+	v := c.registers[i.y]
+	if c.quirks.ShiftVX {
+		v = c.registers[i.x]
+	}
+	c.registers[i.x] = v << 1
+	c.registers[flagRegister] = v >> 7
+
+	return nil
 }
 
 // SkipNotEqualRegister 9XY0: Skip the following instruction
@@ -382,10 +441,12 @@ func (i skipNotEqualRegister) String() string {
 	return fmt.Sprintf("SKNE V%x,V%x", i.x, i.y)
 }
 
-func (i skipNotEqualRegister) Execute(c *Chip8) {
+func (i skipNotEqualRegister) Execute(c *Chip8) error {
 	if c.registers[i.x] != c.registers[i.y] {
-		c.fetcher.Skip()
+		c.fetcher.Skip(c)
 	}
+
+	return nil
 }
 
 // LoadIndex ANNN: Store memory address NNN in register I
@@ -401,11 +462,14 @@ func (i loadIndex) String() string {
 	return fmt.Sprintf("LOAD I,0x%04x", i.nnn)
 }
 
-func (i loadIndex) Execute(c *Chip8) {
+func (i loadIndex) Execute(c *Chip8) error {
 	c.index = i.nnn
+
+	return nil
 }
 
 // JumpRegister0 BNNN: Jump to address NNN + V0
+// With the jump quirk it is read as BXNN: Jump to address XNN + VX
 func JumpRegister0(nnn uint16) Instruction {
 	return &jumpRegister0{nnn: nnn}
 }
@@ -418,8 +482,14 @@ func (i jumpRegister0) String() string {
 	return fmt.Sprintf("JUMP 0x%04x+V0", i.nnn)
 }
 
-func (i jumpRegister0) Execute(c *Chip8) {
-	c.fetcher.SetCounter(i.nnn + uint16(c.registers[0]))
+func (i jumpRegister0) Execute(c *Chip8) error {
+	x := uint8(0)
+	if c.quirks.JumpVX {
+		x = uint8(i.nnn>>8) & 0xF
+	}
+	c.fetcher.SetCounter(i.nnn + uint16(c.registers[x]))
+
+	return nil
 }
 
 // Random CXNN: Set VX to a random number with a mask of NN
@@ -435,14 +505,17 @@ func (i random) String() string {
 	return fmt.Sprintf("RAND V%x,0x%04x", i.x, i.nn)
 }
 
-func (i random) Execute(c *Chip8) {
-	b := make([]byte, 1)
-	rand.Read(b)
-	c.registers[i.x] = b[0] & i.nn
+func (i random) Execute(c *Chip8) error {
+	c.registers[i.x] = c.random.Byte() & i.nn
+
+	return nil
 }
 
 // DrawSprite DXYN: Draw a sprite at position VX, VY with N bytes of sprite data starting at the address stored in I
+// DXY0 draws a 16x16 sprite with 32 bytes of sprite data, two bytes per row
+// With both XO-CHIP planes selected the sprite data for plane 2 follows the data for plane 1
 // Set VF to 01 if any set pixels are changed to unset, and 00 otherwise
+// Sprites are clipped at the screen edges, or wrap around with the wrap quirk
 func DrawSprite(x, y, n uint8) Instruction {
 	return &drawSprite{x: x, y: y, n: n}
 }
@@ -455,41 +528,64 @@ func (i drawSprite) String() string {
 	return fmt.Sprintf("DRAW V%x,V%x,%x", i.x, i.y, i.n)
 }
 
-func (i drawSprite) Execute(c *Chip8) {
+func (i drawSprite) Execute(c *Chip8) error {
 	width, height := c.screen.Layout()
 
 	// always start drawing in boundary
 	vx := int(c.registers[i.x]) % width
 	vy := int(c.registers[i.y]) % height
 
-	sprite := c.memory[c.index : c.index+uint16(i.n)]
+	rows, rowBytes := int(i.n), 1
+	if i.n == 0 {
+		rows, rowBytes = bigSpriteBytes/2, 2
+	}
+
+	spriteBytes := uint16(rows * rowBytes)
+	start := c.index
 	vf := uint8(0)
-	for i, b := range sprite {
-		for j := 0; j < bytePixels; j++ {
-			pixelX := vx + j
-			pixelY := vy + i
+	for plane := 0; plane < planeCount; plane++ {
+		if c.screen.SelectedPlanes()&(1<<plane) == 0 {
+			continue
+		}
 
-			// check clipping
-			if pixelX >= width || pixelY >= height {
-				continue
+		sprite, err := c.memory.Slice(start, int(spriteBytes))
+		if err != nil {
+			return err
+		}
+		start += spriteBytes
+
+		for i := 0; i < rows; i++ {
+			pixelY := vy + i
+			if c.quirks.Wrap {
+				pixelY %= height
+			} else if pixelY >= height {
+				break
 			}
 
-			screenPixelIsSet := c.screen.Get(pixelX, pixelY)
-			spritePixelIsSet := (b>>(bytePixels-1-j))&1 == 1
-			if screenPixelIsSet && spritePixelIsSet {
-				vf = uint8(1) // collision detected
+			var line uint64
+			for _, b := range sprite[i*rowBytes : (i+1)*rowBytes] {
+				line = line<<bytePixels | uint64(b)
 			}
 
-			// draw using XOR, boolean != should be equivalent
-			c.screen.Set(pixelX, pixelY, screenPixelIsSet != spritePixelIsSet)
+			// draw using XOR, any pixel turned off is a collision
+			mask := spriteRow(line, rowBytes*bytePixels, vx, width, c.quirks.Wrap)
+			if c.screen.xorRow(plane, pixelY, mask) {
+				vf = uint8(1)
+			}
 		}
 	}
 
 	c.registers[flagRegister] = vf
+
+	if c.quirks.DisplayWait {
+		c.vblankWait = true
+	}
+
+	return nil
 }
 
 // SkipPressed EX9E: Skip the following instruction if the key corresponding to the hex value
-// currently stored in register VX is pressed
+// currently stored in register VX is pressed. Only the low nibble of VX is used, like on the COSMAC VIP
 func SkipPressed(x uint8) Instruction {
 	return &skipPressed{x: x}
 }
@@ -502,15 +598,17 @@ func (i skipPressed) String() string {
 	return fmt.Sprintf("SKP V%x", i.x)
 }
 
-func (i skipPressed) Execute(c *Chip8) {
-	v := c.registers[i.x]
+func (i skipPressed) Execute(c *Chip8) error {
+	v := c.registers[i.x] & 0xF
 	if c.input.keys[v] {
-		c.fetcher.Skip()
+		c.fetcher.Skip(c)
 	}
+
+	return nil
 }
 
 // SkipNotPressed EXA1: Skip the following instruction if the key corresponding to the hex value
-// currently stored in register VX is not pressed
+// currently stored in register VX is not pressed. Only the low nibble of VX is used, like on the COSMAC VIP
 func SkipNotPressed(x uint8) Instruction {
 	return &skipNotPressed{x: x}
 }
@@ -523,11 +621,13 @@ func (i skipNotPressed) String() string {
 	return fmt.Sprintf("SKNP V%x", i.x)
 }
 
-func (i skipNotPressed) Execute(c *Chip8) {
-	v := c.registers[i.x]
+func (i skipNotPressed) Execute(c *Chip8) error {
+	v := c.registers[i.x] & 0xF
 	if !c.input.keys[v] {
-		c.fetcher.Skip()
+		c.fetcher.Skip(c)
 	}
+
+	return nil
 }
 
 // LoadRegisterDelayTimer FX07: Store the current value of the delay timer in register VX
@@ -543,8 +643,10 @@ func (i loadRegisterDelayTimer) String() string {
 	return fmt.Sprintf("LOAD V%x,DT", i.x)
 }
 
-func (i loadRegisterDelayTimer) Execute(c *Chip8) {
+func (i loadRegisterDelayTimer) Execute(c *Chip8) error {
 	c.registers[i.x] = c.delayTimer.GetValue()
+
+	return nil
 }
 
 // WaitKey FX0A: Wait for a keypress and store the result in register VX
@@ -560,10 +662,10 @@ func (i waitKey) String() string {
 	return fmt.Sprintf("LOAD V%x,K", i.x)
 }
 
-func (i waitKey) Execute(c *Chip8) {
-	c.input.Wait(func(key uint8) {
-		c.registers[i.x] = key
-	})
+func (i waitKey) Execute(c *Chip8) error {
+	c.input.Wait(i.x)
+
+	return nil
 }
 
 // LoadDelayTimerRegister FX15: Set the delay timer to the value of register VX
@@ -579,8 +681,10 @@ func (i loadDelayTimerRegister) String() string {
 	return fmt.Sprintf("LOAD DT,V%x", i.x)
 }
 
-func (i loadDelayTimerRegister) Execute(c *Chip8) {
+func (i loadDelayTimerRegister) Execute(c *Chip8) error {
 	c.delayTimer.SetValue(c.registers[i.x])
+
+	return nil
 }
 
 // LoadSoundTimerRegister FX15: Set the delay timer to the value of register VX
@@ -596,8 +700,10 @@ func (i loadSoundTimerRegister) String() string {
 	return fmt.Sprintf("LOAD ST,V%x", i.x)
 }
 
-func (i loadSoundTimerRegister) Execute(c *Chip8) {
-	c.sound.SetTimerValue(c.registers[i.x])
+func (i loadSoundTimerRegister) Execute(c *Chip8) error {
+	c.soundTimer.SetValue(c.registers[i.x])
+
+	return nil
 }
 
 // AddIndex FX1E: Add the value stored in register VX to register I
@@ -613,8 +719,10 @@ func (i addIndex) String() string {
 	return fmt.Sprintf("ADD I,V%x", i.x)
 }
 
-func (i addIndex) Execute(c *Chip8) {
+func (i addIndex) Execute(c *Chip8) error {
 	c.index += uint16(c.registers[i.x])
+
+	return nil
 }
 
 // LoadDigitIndex FX29: Set I to the memory address of the sprite data
@@ -631,8 +739,10 @@ func (i loadDigitIndex) String() string {
 	return fmt.Sprintf("LOAD I,V%x", i.x)
 }
 
-func (i loadDigitIndex) Execute(c *Chip8) {
-	c.index = fontStartMemoryAddress + uint16(c.registers[i.x]*digitBytes)
+func (i loadDigitIndex) Execute(c *Chip8) error {
+	c.index = fontStartMemoryAddress + uint16(c.registers[i.x]&0xF)*digitBytes
+
+	return nil
 }
 
 // BCD FX33: Store the binary-coded decimal equivalent of the value stored in register VX
@@ -649,15 +759,22 @@ func (i bcd) String() string {
 	return fmt.Sprintf("BCD V%x", i.x)
 }
 
-func (i bcd) Execute(c *Chip8) {
+func (i bcd) Execute(c *Chip8) error {
 	v := c.registers[i.x]
-	c.memory[c.index] = v / 100
-	c.memory[c.index+1] = v % 100 / 10
-	c.memory[c.index+2] = v % 10
+	digits, err := c.memory.Slice(c.index, 3)
+	if err != nil {
+		return err
+	}
+
+	digits[0] = v / 100
+	digits[1] = v % 100 / 10
+	digits[2] = v % 10
+
+	return nil
 }
 
 // Write FX55: Store the values of registers V0 to VX inclusive in memory starting at address I
-// I is set to I + X + 1 after operation
+// I is set to I + X + 1 after operation, or as selected by the load/store quirk
 func Write(x uint8) Instruction {
 	return &write{x: x}
 }
@@ -670,14 +787,21 @@ func (i write) String() string {
 	return fmt.Sprintf("WRITE V0-V%x", i.x)
 }
 
-func (i write) Execute(c *Chip8) {
+func (i write) Execute(c *Chip8) error {
 	high := uint16(i.x + 1)
-	copy(c.memory[c.index:c.index+high], c.registers[:high])
-	c.index += high
+	dst, err := c.memory.Slice(c.index, int(high))
+	if err != nil {
+		return err
+	}
+
+	copy(dst, c.registers[:high])
+	c.index = loadStoreIndex(c, i.x)
+
+	return nil
 }
 
 // Read FX65: Fill registers V0 to VX inclusive with the values stored in memory starting at address I
-// I is set to I + X + 1 after operation
+// I is set to I + X + 1 after operation, or as selected by the load/store quirk
 func Read(x uint8) Instruction {
 	return &read{x: x}
 }
@@ -690,8 +814,27 @@ func (i read) String() string {
 	return fmt.Sprintf("READ V0-V%x", i.x)
 }
 
-func (i read) Execute(c *Chip8) {
+func (i read) Execute(c *Chip8) error {
 	high := uint16(i.x + 1)
-	copy(c.registers[:high], c.memory[c.index:c.index+high])
-	c.index += high
+	src, err := c.memory.Slice(c.index, int(high))
+	if err != nil {
+		return err
+	}
+
+	copy(c.registers[:high], src)
+	c.index = loadStoreIndex(c, i.x)
+
+	return nil
+}
+
+// loadStoreIndex returns I after FX55 or FX65 with registers V0 to VX
+func loadStoreIndex(c *Chip8, x uint8) uint16 {
+	switch c.quirks.LoadStore {
+	case LoadStoreIncrementX:
+		return c.index + uint16(x)
+	case LoadStoreUnchanged:
+		return c.index
+	default:
+		return c.index + uint16(x) + 1
+	}
 }
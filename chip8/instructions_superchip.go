@@ -0,0 +1,169 @@
+package chip8
+
+import "fmt"
+
+const (
+	scrollPixels   = 4
+	bigSpriteBytes = 32
+)
+
+// ScrollDown 00CN: Scroll the display down by N pixels
+func ScrollDown(n uint8) Instruction {
+	return &scrollDown{n: n}
+}
+
+type scrollDown struct {
+	n uint8
+}
+
+func (i scrollDown) String() string {
+	return fmt.Sprintf("SCD %x", i.n)
+}
+
+func (i scrollDown) Execute(c *Chip8) error {
+	c.screen.ScrollDown(int(i.n))
+
+	return nil
+}
+
+// ScrollRight 00FB: Scroll the display right by 4 pixels
+func ScrollRight() Instruction {
+	return &scrollRight{}
+}
+
+type scrollRight struct{}
+
+func (i scrollRight) String() string {
+	return "SCR"
+}
+
+func (i scrollRight) Execute(c *Chip8) error {
+	c.screen.ScrollRight(scrollPixels)
+
+	return nil
+}
+
+// ScrollLeft 00FC: Scroll the display left by 4 pixels
+func ScrollLeft() Instruction {
+	return &scrollLeft{}
+}
+
+type scrollLeft struct{}
+
+func (i scrollLeft) String() string {
+	return "SCL"
+}
+
+func (i scrollLeft) Execute(c *Chip8) error {
+	c.screen.ScrollLeft(scrollPixels)
+
+	return nil
+}
+
+// Exit 00FD: Exit the interpreter
+func Exit() Instruction {
+	return &exit{}
+}
+
+type exit struct{}
+
+func (i exit) String() string {
+	return "EXIT"
+}
+
+func (i exit) Execute(c *Chip8) error {
+	c.exited = true
+	return ErrExit
+}
+
+// LowResolution 00FE: Switch to 64x32 low resolution mode and clear the screen
+func LowResolution() Instruction {
+	return &lowResolution{}
+}
+
+type lowResolution struct{}
+
+func (i lowResolution) String() string {
+	return "LOW"
+}
+
+func (i lowResolution) Execute(c *Chip8) error {
+	c.screen.SetHighResolution(false)
+
+	return nil
+}
+
+// HighResolution 00FF: Switch to 128x64 high resolution mode and clear the screen
+func HighResolution() Instruction {
+	return &highResolution{}
+}
+
+type highResolution struct{}
+
+func (i highResolution) String() string {
+	return "HIGH"
+}
+
+func (i highResolution) Execute(c *Chip8) error {
+	c.screen.SetHighResolution(true)
+
+	return nil
+}
+
+// LoadBigDigitIndex FX30: Set I to the memory address of the 8x10 sprite data
+// corresponding to the hexadecimal digit stored in register VX
+func LoadBigDigitIndex(x uint8) Instruction {
+	return &loadBigDigitIndex{x: x}
+}
+
+type loadBigDigitIndex struct {
+	x uint8
+}
+
+func (i loadBigDigitIndex) String() string {
+	return fmt.Sprintf("LOAD HI,V%x", i.x)
+}
+
+func (i loadBigDigitIndex) Execute(c *Chip8) error {
+	c.index = bigFontStartMemoryAddress + uint16(c.registers[i.x]&0xF)*bigDigitBytes
+
+	return nil
+}
+
+// WriteUserFlags FX75: Store the values of registers V0 to VX inclusive in the RPL user flags
+func WriteUserFlags(x uint8) Instruction {
+	return &writeUserFlags{x: x}
+}
+
+type writeUserFlags struct {
+	x uint8
+}
+
+func (i writeUserFlags) String() string {
+	return fmt.Sprintf("WRITE R,V0-V%x", i.x)
+}
+
+func (i writeUserFlags) Execute(c *Chip8) error {
+	copy(c.userFlags[:i.x+1], c.registers[:i.x+1])
+
+	return nil
+}
+
+// ReadUserFlags FX85: Fill registers V0 to VX inclusive with the values stored in the RPL user flags
+func ReadUserFlags(x uint8) Instruction {
+	return &readUserFlags{x: x}
+}
+
+type readUserFlags struct {
+	x uint8
+}
+
+func (i readUserFlags) String() string {
+	return fmt.Sprintf("READ R,V0-V%x", i.x)
+}
+
+func (i readUserFlags) Execute(c *Chip8) error {
+	copy(c.registers[:i.x+1], c.userFlags[:i.x+1])
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package chip8
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighResolution(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.screen.Set(1, 1, true)
+
+	i := highResolution{}
+	i.Execute(c)
+
+	w, h := c.screen.Layout()
+	assert.Equal(t, 128, w)
+	assert.Equal(t, 64, h)
+	assert.False(t, c.screen.Get(1, 1))
+}
+
+func TestScrollDown(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.screen.Set(5, 0, true)
+	c.screen.Set(5, 31, true)
+
+	i := scrollDown{n: 3}
+	i.Execute(c)
+
+	assert.False(t, c.screen.Get(5, 0))
+	assert.True(t, c.screen.Get(5, 3))
+	assert.False(t, c.screen.Get(5, 31))
+}
+
+func TestScrollRightLeft(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.screen.Set(0, 2, true)
+
+	right := scrollRight{}
+	right.Execute(c)
+	assert.False(t, c.screen.Get(0, 2))
+	assert.True(t, c.screen.Get(4, 2))
+
+	left := scrollLeft{}
+	left.Execute(c)
+	left.Execute(c)
+	assert.False(t, c.screen.Get(0, 2))
+	assert.False(t, c.screen.Get(4, 2))
+}
+
+func TestDrawBigSprite(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.screen.SetHighResolution(true)
+	c.index = 0x300
+	for j := uint16(0); j < bigSpriteBytes; j++ {
+		c.memory[0x300+j] = 0xFF
+	}
+	c.registers[0] = 100
+	c.registers[1] = 50
+
+	i := drawSprite{x: 0, y: 1, n: 0}
+	i.Execute(c)
+
+	assert.True(t, c.screen.Get(100, 50))
+	assert.True(t, c.screen.Get(115, 63))
+	assert.False(t, c.screen.Get(116, 50))
+	assert.Equal(t, byte(0x00), c.registers[0xF])
+
+	i.Execute(c)
+	assert.False(t, c.screen.Get(115, 63))
+	assert.Equal(t, byte(0x01), c.registers[0xF])
+}
+
+func TestLoadBigDigitIndex(t *testing.T) {
+	c := NewChip8(slog.Default())
+	assert.NoError(t, c.LoadFont())
+	c.registers[2] = 0x9
+
+	i := loadBigDigitIndex{x: 2}
+	i.Execute(c)
+
+	assert.Equal(t, uint16(0xa0+9*10), c.index)
+	assert.Equal(t, Memory(bigFont[90:100]), c.memory[c.index:c.index+10])
+}
+
+func TestUserFlags(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.registers[0] = 0x01
+	c.registers[7] = 0x07
+
+	w := writeUserFlags{x: 7}
+	w.Execute(c)
+	c.registers = Registers{}
+
+	r := readUserFlags{x: 7}
+	r.Execute(c)
+
+	assert.Equal(t, byte(0x01), c.registers[0])
+	assert.Equal(t, byte(0x07), c.registers[7])
+}
+
+func TestExit(t *testing.T) {
+	// EXIT
+	c, _ := newTestChip8(t, 0x00, 0xfd)
+
+	assert.ErrorIs(t, c.Update(), ErrExit)
+	assert.ErrorIs(t, c.Update(), ErrExit)
+}
+
+func TestDecodeSuperChip(t *testing.T) {
+	tests := map[uint16]string{
+		0x00C4: "SCD 4",
+		0x00FB: "SCR",
+		0x00FC: "SCL",
+		0x00FD: "EXIT",
+		0x00FE: "LOW",
+		0x00FF: "HIGH",
+		0xD120: "DRAW V1,V2,0",
+		0xF330: "LOAD HI,V3",
+		0xF575: "WRITE R,V0-V5",
+		0xF585: "READ R,V0-V5",
+	}
+
+	for opcode, mnemonic := range tests {
+		instruction, ok := Decode(opcode)
+		assert.True(t, ok)
+		assert.Equal(t, mnemonic, instruction.String())
+	}
+}
@@ -129,3 +129,61 @@ func TestReverseSubRegisterVfAsY(t *testing.T) {
 	assert.Equal(t, byte(0x04), c.registers[0])
 	assert.Equal(t, byte(0x01), c.registers[0xF])
 }
+
+func TestRandomSeeded(t *testing.T) {
+	a := NewChip8(slog.Default())
+	a.SetRandom(NewRandom(42))
+	b := NewChip8(slog.Default())
+	b.SetRandom(NewRandom(42))
+
+	i := random{x: 0, nn: 0xFF}
+	for j := 0; j < 16; j++ {
+		i.Execute(a)
+		i.Execute(b)
+		assert.Equal(t, a.registers[0], b.registers[0])
+	}
+}
+
+func TestRandomMask(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.SetRandom(NewRandom(7))
+
+	i := random{x: 3, nn: 0x0F}
+	for j := 0; j < 16; j++ {
+		i.Execute(c)
+		assert.LessOrEqual(t, c.registers[3], byte(0x0F))
+	}
+}
+
+func TestVIPRandom(t *testing.T) {
+	interpreter := make([]byte, vipInterpreterBytes)
+	interpreter[0x101] = 0x80
+	interpreter[0x102] = 0xFF
+
+	r, err := NewVIPRandom(interpreter, 0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, byte(0xC0), r.Byte()) // 0x00 + 0x80, plus itself rotated right
+	assert.Equal(t, byte(0x9E), r.Byte()) // 0xC0 + 0xFF carries into the rotation
+	r.(frameRandom).Frame()
+	assert.Equal(t, byte(0xED), r.Byte())
+
+	_, err = NewVIPRandom(interpreter[:0x1FF], 0)
+	assert.ErrorIs(t, err, ErrInvalidInterpreter)
+}
+
+func TestVIPRandomMovesEveryFrame(t *testing.T) {
+	interpreter := make([]byte, vipInterpreterBytes)
+	for j := range interpreter {
+		interpreter[j] = byte(j * 7)
+	}
+
+	// JUMP 0x0200
+	c, _ := newTestChip8(t, 0x12, 0x00)
+	r, _ := NewVIPRandom(interpreter, 0x1234)
+	c.SetRandom(r)
+	assert.NoError(t, c.Update())
+
+	expected, _ := NewVIPRandom(interpreter, 0x1235)
+	assert.Equal(t, expected.Byte(), r.Byte())
+}
@@ -0,0 +1,181 @@
+package chip8
+
+import "fmt"
+
+// ScrollUp 00DN: Scroll the selected planes up by N pixels
+func ScrollUp(n uint8) Instruction {
+	return &scrollUp{n: n}
+}
+
+type scrollUp struct {
+	n uint8
+}
+
+func (i scrollUp) String() string {
+	return fmt.Sprintf("SCU %x", i.n)
+}
+
+func (i scrollUp) Execute(c *Chip8) error {
+	c.screen.ScrollUp(int(i.n))
+
+	return nil
+}
+
+// WriteRange 5XY2: Store the values of registers VX to VY inclusive in memory starting at address I
+// Registers are stored in reverse order if X > Y. I is unchanged
+func WriteRange(x, y uint8) Instruction {
+	return &writeRange{x: x, y: y}
+}
+
+type writeRange struct {
+	x, y uint8
+}
+
+func (i writeRange) String() string {
+	return fmt.Sprintf("WRITE V%x..V%x", i.x, i.y)
+}
+
+func (i writeRange) Execute(c *Chip8) error {
+	registers, n := registerRange(i.x, i.y)
+	dst, err := c.memory.Slice(c.index, n)
+	if err != nil {
+		return err
+	}
+
+	for j, r := range registers[:n] {
+		dst[j] = c.registers[r]
+	}
+
+	return nil
+}
+
+// ReadRange 5XY3: Fill registers VX to VY inclusive with the values stored in memory starting at address I
+// Registers are filled in reverse order if X > Y. I is unchanged
+func ReadRange(x, y uint8) Instruction {
+	return &readRange{x: x, y: y}
+}
+
+type readRange struct {
+	x, y uint8
+}
+
+func (i readRange) String() string {
+	return fmt.Sprintf("READ V%x..V%x", i.x, i.y)
+}
+
+func (i readRange) Execute(c *Chip8) error {
+	registers, n := registerRange(i.x, i.y)
+	src, err := c.memory.Slice(c.index, n)
+	if err != nil {
+		return err
+	}
+
+	for j, r := range registers[:n] {
+		c.registers[r] = src[j]
+	}
+
+	return nil
+}
+
+// registerRange returns the registers from X to Y inclusive, counting down if X > Y, and how many there are
+// An array rather than a slice keeps it off the heap
+func registerRange(x, y uint8) (registers [registerCount]uint8, n int) {
+	step := 1
+	if x > y {
+		step = -1
+	}
+
+	for r := int(x); ; r += step {
+		registers[n] = uint8(r)
+		n++
+		if r == int(y) {
+			return registers, n
+		}
+	}
+}
+
+// LoadLongIndex F000 NNNN: Store the 16-bit memory address NNNN in register I
+// NNNN is the word following the instruction, which is skipped
+func LoadLongIndex() Instruction {
+	return &loadLongIndex{}
+}
+
+type loadLongIndex struct{}
+
+func (i loadLongIndex) String() string {
+	return "LOAD I,LONG"
+}
+
+func (i loadLongIndex) Execute(c *Chip8) error {
+	address, err := c.memory.ReadWord(c.fetcher.GetCounter())
+	if err != nil {
+		return err
+	}
+
+	c.index = address
+	c.fetcher.incrementCounter()
+
+	return nil
+}
+
+// SelectPlanes FN01: Select the bitplanes drawing, clearing and scrolling act on, with N as a bitmask
+func SelectPlanes(n uint8) Instruction {
+	return &selectPlanes{n: n}
+}
+
+type selectPlanes struct {
+	n uint8
+}
+
+func (i selectPlanes) String() string {
+	return fmt.Sprintf("PLANE %x", i.n)
+}
+
+func (i selectPlanes) Execute(c *Chip8) error {
+	c.screen.SelectPlanes(i.n)
+
+	return nil
+}
+
+// LoadAudioPattern F002: Store 16 bytes starting at address I in the audio pattern buffer
+func LoadAudioPattern() Instruction {
+	return &loadAudioPattern{}
+}
+
+type loadAudioPattern struct{}
+
+func (i loadAudioPattern) String() string {
+	return "AUDIO"
+}
+
+func (i loadAudioPattern) Execute(c *Chip8) error {
+	src, err := c.memory.Slice(c.index, AudioPatternBytes)
+	if err != nil {
+		return err
+	}
+
+	copy(c.pattern[:], src)
+	c.audio.SetPattern(c.pattern, c.pitch)
+
+	return nil
+}
+
+// LoadPitch FX3A: Set the audio pattern playback pitch to the value of register VX
+func LoadPitch(x uint8) Instruction {
+	return &loadPitch{x: x}
+}
+
+type loadPitch struct {
+	x uint8
+}
+
+func (i loadPitch) String() string {
+	return fmt.Sprintf("PITCH V%x", i.x)
+}
+
+func (i loadPitch) Execute(c *Chip8) error {
+	c.pitch = c.registers[i.x]
+	c.audio.SetPattern(c.pattern, c.pitch)
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package chip8
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newXOChip() *Chip8 {
+	c := NewChip8(slog.Default())
+	c.SetPlatform(PlatformXOCHIP)
+	return c
+}
+
+func TestXOChipMemory(t *testing.T) {
+	c := newXOChip()
+
+	assert.Len(t, c.memory, 0x10000)
+}
+
+func TestLoadLongIndex(t *testing.T) {
+	// LOAD I,LONG 0xabcd; LOAD V0,1
+	c := newXOChip()
+	_, _ = c.memory.Write(programStartMemoryAddress, bytes.NewReader([]byte{0xf0, 0x00, 0xab, 0xcd, 0x60, 0x01}))
+
+	assert.NoError(t, c.Cycle())
+	assert.Equal(t, uint16(0xabcd), c.index)
+	assert.Equal(t, uint16(0x204), c.fetcher.GetCounter())
+}
+
+func TestSkipLongIndex(t *testing.T) {
+	// SKE V0,0; LOAD I,LONG 0xabcd; LOAD V1,1
+	c := newXOChip()
+	_, _ = c.memory.Write(programStartMemoryAddress, bytes.NewReader([]byte{0x30, 0x00, 0xf0, 0x00, 0xab, 0xcd, 0x61, 0x01}))
+
+	assert.NoError(t, c.Cycle())
+	assert.Equal(t, uint16(0x206), c.fetcher.GetCounter())
+}
+
+func TestWriteReadRange(t *testing.T) {
+	c := newXOChip()
+	c.index = 0x300
+	c.registers[2] = 0x02
+	c.registers[3] = 0x03
+	c.registers[4] = 0x04
+
+	w := writeRange{x: 4, y: 2}
+	w.Execute(c)
+
+	assert.Equal(t, Memory{0x04, 0x03, 0x02}, c.memory[0x300:0x303])
+	assert.Equal(t, uint16(0x300), c.index)
+
+	r := readRange{x: 5, y: 7}
+	r.Execute(c)
+
+	assert.Equal(t, Registers{0, 0, 2, 3, 4, 4, 3, 2}, c.registers)
+}
+
+func TestDrawSpriteBothPlanes(t *testing.T) {
+	c := newXOChip()
+	c.index = 0x300
+	c.memory[0x300] = 0x80
+	c.memory[0x301] = 0xC0
+
+	p := selectPlanes{n: 3}
+	p.Execute(c)
+
+	i := drawSprite{x: 0, y: 0, n: 1}
+	i.Execute(c)
+
+	assert.Equal(t, uint8(3), c.screen.Pixel(0, 0))
+	assert.Equal(t, uint8(2), c.screen.Pixel(1, 0))
+	assert.Equal(t, byte(0x00), c.registers[0xF])
+
+	p = selectPlanes{n: 2}
+	p.Execute(c)
+	cls := clearScreen{}
+	cls.Execute(c)
+
+	assert.Equal(t, uint8(1), c.screen.Pixel(0, 0))
+	assert.Equal(t, uint8(0), c.screen.Pixel(1, 0))
+}
+
+func TestScrollUpSelectedPlane(t *testing.T) {
+	c := newXOChip()
+	c.screen.SelectPlanes(3)
+	c.screen.Set(0, 5, true)
+	c.screen.SelectPlanes(1)
+
+	i := scrollUp{n: 2}
+	i.Execute(c)
+
+	assert.Equal(t, uint8(1), c.screen.Pixel(0, 3))
+	assert.Equal(t, uint8(2), c.screen.Pixel(0, 5))
+}
+
+func TestAudioPattern(t *testing.T) {
+	c, f := newTestChip8(t)
+	c.index = 0x300
+	c.memory[0x300] = 0xF0
+	c.registers[1] = 112
+
+	a := loadAudioPattern{}
+	a.Execute(c)
+	assert.Equal(t, uint8(0xF0), f.pattern[0])
+	assert.Equal(t, uint8(64), f.pitch)
+
+	p := loadPitch{x: 1}
+	p.Execute(c)
+	assert.Equal(t, uint8(112), f.pitch)
+	assert.InDelta(t, 8000, PatternRate(f.pitch), 0.001)
+}
+
+func TestDecodeXOChip(t *testing.T) {
+	tests := map[uint16]string{
+		0x00D4: "SCU 4",
+		0x5122: "WRITE V1..V2",
+		0x5123: "READ V1..V2",
+		0xF000: "LOAD I,LONG",
+		0xF201: "PLANE 2",
+		0xF002: "AUDIO",
+		0xF43A: "PITCH V4",
+	}
+
+	for opcode, mnemonic := range tests {
+		instruction, ok := Decode(opcode)
+		assert.True(t, ok)
+		assert.Equal(t, mnemonic, instruction.String())
+	}
+}
@@ -5,34 +5,61 @@ import (
 	"strings"
 )
 
-const memoryLocations = 0x1000
+const (
+	memoryLocations       = 0x1000
+	xoChipMemoryLocations = 0x10000
+)
+
+type Memory []uint8
 
-type Memory [memoryLocations]uint8
+// NewMemory returns zeroed memory with a given number of locations
+func NewMemory(locations int) Memory {
+	return make(Memory, locations)
+}
 
 // ReadWord returns 2 big-endian bytes
-func (m *Memory) ReadWord(address uint16) uint16 {
-	msb := m.ReadByte(address)
-	lsb := m.ReadByte(address + 1)
-	return uint16(msb)<<8 | uint16(lsb)
+func (m Memory) ReadWord(address uint16) (uint16, error) {
+	word, err := m.Slice(address, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint16(word[0])<<8 | uint16(word[1]), nil
+}
+
+func (m Memory) ReadByteAt(address uint16) (uint8, error) {
+	if int(address) >= len(m) {
+		return 0, outOfBounds(address, 1)
+	}
+
+	return m[address], nil
 }
 
-func (m *Memory) ReadByte(address uint16) uint8 {
-	return m[address]
+// Slice returns the n locations starting at address, writing to it writes to memory
+func (m Memory) Slice(address uint16, n int) ([]uint8, error) {
+	if int(address)+n > len(m) {
+		return nil, outOfBounds(address, n)
+	}
+
+	return m[address : int(address)+n], nil
 }
 
-func (m *Memory) Write(address uint16, r io.Reader) (int, error) {
+func (m Memory) Write(address uint16, r io.Reader) (int, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return 0, err
 	}
 
-	// TODO: ensure data copied in bounds -> check data len
-	copy(m[address:], data)
+	dst, err := m.Slice(address, len(data))
+	if err != nil {
+		return 0, err
+	}
+	copy(dst, data)
 
 	return len(data), nil
 }
 
-func (m *Memory) String() string {
+func (m Memory) String() string {
 	const bytesPerRow = 16
 	var sb strings.Builder
 
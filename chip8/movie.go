@@ -0,0 +1,224 @@
+package chip8
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	movieMagic   = "CH8M"
+	movieVersion = 3
+)
+
+// ErrInvalidMovie is returned when a movie file can't be read
+var ErrInvalidMovie = errors.New("invalid movie")
+
+// Movie is a recording of every keypad state change, frame by frame, of a session started from power on
+// Replaying it on a machine set up with the same ROM, platform, quirks, IPF, random source and seed reproduces the
+// session exactly
+type Movie struct {
+	Seed     uint64
+	IPF      uint32
+	Platform string
+	Quirks   string // quirks profile overriding the platform ones, if any
+	Random   string // CXNN random source, if not the default one
+	Length   uint64 // frames recorded
+	events   []movieEvent
+}
+
+type movieHeader struct {
+	Version uint16
+	Seed    uint64
+	IPF     uint32
+	Length  uint64
+}
+
+// movieEvent is the keypad state from a given frame on
+type movieEvent struct {
+	frame uint64
+	keys  uint16 // bit N set if key N is pressed
+}
+
+// NewMovieRecorder returns a keypad that records every state change of another keypad in movie
+// It must be polled once per frame, which Chip8.Update does
+func NewMovieRecorder(keypad Keypad, movie *Movie) *MovieRecorder {
+	return &MovieRecorder{
+		keypad: keypad,
+		movie:  movie,
+	}
+}
+
+type MovieRecorder struct {
+	keypad Keypad
+	movie  *Movie
+	keys   uint16
+}
+
+// Pressed implements Keypad
+func (r *MovieRecorder) Pressed() [KeyCount]bool {
+	pressed := r.keypad.Pressed()
+
+	keys := packKeys(pressed)
+	if keys != r.keys {
+		r.movie.events = append(r.movie.events, movieEvent{frame: r.movie.Length, keys: keys})
+		r.keys = keys
+	}
+	r.movie.Length++
+
+	return pressed
+}
+
+// NewMoviePlayer returns a keypad that replays a movie, needing no keyboard
+func NewMoviePlayer(movie *Movie) *MoviePlayer {
+	return &MoviePlayer{
+		movie: movie,
+	}
+}
+
+type MoviePlayer struct {
+	movie *Movie
+	frame uint64
+	next  int // index of the next event
+	keys  uint16
+}
+
+// Pressed implements Keypad
+func (p *MoviePlayer) Pressed() [KeyCount]bool {
+	for p.next < len(p.movie.events) && p.movie.events[p.next].frame <= p.frame {
+		p.keys = p.movie.events[p.next].keys
+		p.next++
+	}
+	p.frame++
+
+	return unpackKeys(p.keys)
+}
+
+// Done return true once every recorded frame has been replayed, false otherwise
+func (p *MoviePlayer) Done() bool {
+	return p.frame >= p.movie.Length
+}
+
+// WriteTo encodes the movie
+func (m *Movie) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	_, _ = bw.WriteString(movieMagic)
+	_ = binary.Write(bw, binary.BigEndian, movieHeader{movieVersion, m.Seed, m.IPF, m.Length})
+	writeShortString(bw, m.Platform)
+	writeShortString(bw, m.Quirks)
+	writeShortString(bw, m.Random)
+	_ = binary.Write(bw, binary.BigEndian, uint32(len(m.events)))
+
+	previous := uint64(0)
+	for _, e := range m.events {
+		_, _ = bw.Write(binary.AppendUvarint(nil, e.frame-previous))
+		_ = binary.Write(bw, binary.BigEndian, e.keys)
+		previous = e.frame
+	}
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ReadMovie decodes a movie written by Movie.WriteTo, validating its header
+func ReadMovie(r io.Reader) (*Movie, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(movieMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != movieMagic {
+		return nil, fmt.Errorf("%w: not a movie", ErrInvalidMovie)
+	}
+
+	var header movieHeader
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+	}
+	if header.Version != movieVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidMovie, header.Version)
+	}
+
+	platform, err := readShortString(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+	}
+	quirks, err := readShortString(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+	}
+	random, err := readShortString(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+	}
+
+	var count uint32
+	if err := binary.Read(br, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+	}
+
+	m := &Movie{
+		Seed:     header.Seed,
+		IPF:      header.IPF,
+		Platform: platform,
+		Quirks:   quirks,
+		Random:   random,
+		Length:   header.Length,
+		events:   make([]movieEvent, 0, min(count, 1<<16)),
+	}
+
+	frame := uint64(0)
+	for j := uint32(0); j < count; j++ {
+		delta, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+		}
+		frame += delta
+
+		var keys uint16
+		if err := binary.Read(br, binary.BigEndian, &keys); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMovie, err)
+		}
+
+		m.events = append(m.events, movieEvent{frame: frame, keys: keys})
+	}
+
+	return m, nil
+}
+
+// writeShortString writes up to 255 bytes of s prefixed by their length
+func writeShortString(w io.Writer, s string) {
+	s = s[:min(len(s), 0xFF)]
+	_, _ = w.Write(append([]byte{uint8(len(s))}, s...))
+}
+
+func readShortString(r io.Reader) (string, error) {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return "", err
+	}
+
+	s := make([]byte, n[0])
+	_, err := io.ReadFull(r, s)
+	return string(s), err
+}
+
+func packKeys(pressed [KeyCount]bool) uint16 {
+	keys := uint16(0)
+	for key, down := range pressed {
+		if down {
+			keys |= 1 << key
+		}
+	}
+	return keys
+}
+
+func unpackKeys(keys uint16) [KeyCount]bool {
+	var pressed [KeyCount]bool
+	for key := range pressed {
+		pressed[key] = keys&(1<<key) != 0
+	}
+	return pressed
+}
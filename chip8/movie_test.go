@@ -0,0 +1,65 @@
+package chip8
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// LOAD V3,K; RAND V4,0xff; ADD V5,V3; JUMP 0x0200
+var movieROM = []byte{0xf3, 0x0a, 0xc4, 0xff, 0x85, 0x34, 0x12, 0x00}
+
+func TestMovieRecordAndReplay(t *testing.T) {
+	c, f := newTestChip8(t, movieROM...)
+	c.SetRandom(NewRandom(99))
+
+	movie := &Movie{Seed: 99, IPF: 1, Platform: "chip8", Quirks: "chip48", Random: "pcg"}
+	c.SetKeypad(NewMovieRecorder(f, movie))
+
+	presses := map[int][KeyCount]bool{
+		2:  {0x1: true},
+		4:  {},
+		10: {0xa: true},
+		14: {},
+		20: {0xf: true},
+		21: {},
+	}
+	for tick := 0; tick < 30; tick++ {
+		if keys, ok := presses[tick]; ok {
+			f.keys = keys
+		}
+		assert.NoError(t, c.Update())
+	}
+
+	var buf bytes.Buffer
+	_, err := movie.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	replayed, err := ReadMovie(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, movie, replayed)
+
+	r := NewChip8(slog.Default())
+	assert.NoError(t, r.LoadROM(bytes.NewReader(movieROM)))
+	r.SetRandom(NewRandom(replayed.Seed))
+	r.SetIPF(uint(replayed.IPF))
+	player := NewMoviePlayer(replayed)
+	r.SetKeypad(player)
+
+	for !player.Done() {
+		assert.NoError(t, r.Update())
+	}
+
+	assert.Equal(t, c.registers, r.registers)
+	assert.Equal(t, c.fetcher.GetCounter(), r.fetcher.GetCounter())
+}
+
+func TestReadMovieValidatesHeader(t *testing.T) {
+	_, err := ReadMovie(bytes.NewReader([]byte("CH8S")))
+	assert.ErrorIs(t, err, ErrInvalidMovie)
+
+	_, err = ReadMovie(bytes.NewReader([]byte("CH8M\x00\x02")))
+	assert.ErrorIs(t, err, ErrInvalidMovie)
+}
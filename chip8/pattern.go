@@ -0,0 +1,44 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidOpcodePattern = errors.New("invalid opcode pattern")
+
+// opcodePattern matches a class of opcodes, like DXYN for every draw
+type opcodePattern struct {
+	text  string
+	mask  uint16
+	value uint16
+}
+
+// parseOpcodePattern parses 4 hex digits where X, Y and N match any digit
+func parseOpcodePattern(pattern string) (opcodePattern, error) {
+	upper := strings.ToUpper(pattern)
+	if len(upper) != 4 {
+		return opcodePattern{}, fmt.Errorf("%w: %q, expected 4 hex digits or X, Y, N", ErrInvalidOpcodePattern, pattern)
+	}
+
+	p := opcodePattern{text: upper}
+	for _, r := range upper {
+		p.mask, p.value = p.mask<<4, p.value<<4
+		switch {
+		case r == 'X' || r == 'Y' || r == 'N':
+		case r >= '0' && r <= '9':
+			p.mask, p.value = p.mask|0xF, p.value|uint16(r-'0')
+		case r >= 'A' && r <= 'F':
+			p.mask, p.value = p.mask|0xF, p.value|uint16(r-'A'+10)
+		default:
+			return opcodePattern{}, fmt.Errorf("%w: %q, expected 4 hex digits or X, Y, N", ErrInvalidOpcodePattern, pattern)
+		}
+	}
+
+	return p, nil
+}
+
+func (p opcodePattern) matches(opcode uint16) bool {
+	return opcode&p.mask == p.value
+}
@@ -0,0 +1,47 @@
+package chip8
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Platform is a CHIP-8 variant: its quirks and how much memory it addresses
+type Platform struct {
+	Quirks     Quirks
+	MemorySize int
+}
+
+var (
+	// PlatformCHIP8 is the original CHIP-8 on the RCA COSMAC VIP
+	PlatformCHIP8 = Platform{Quirks: QuirksCOSMACVIP, MemorySize: memoryLocations}
+	// PlatformCHIP48 is CHIP-48 on the HP-48 calculators
+	PlatformCHIP48 = Platform{Quirks: QuirksCHIP48, MemorySize: memoryLocations}
+	// PlatformSuperCHIP is SUPER-CHIP 1.1 on the HP-48 calculators
+	PlatformSuperCHIP = Platform{Quirks: QuirksSuperCHIP, MemorySize: memoryLocations}
+	// PlatformXOCHIP is XO-CHIP as implemented by Octo, with 64 KiB of memory
+	PlatformXOCHIP = Platform{Quirks: QuirksXOCHIP, MemorySize: xoChipMemoryLocations}
+)
+
+var platforms = map[string]Platform{
+	"chip8":  PlatformCHIP8,
+	"chip48": PlatformCHIP48,
+	"schip":  PlatformSuperCHIP,
+	"xochip": PlatformXOCHIP,
+}
+
+// PlatformByName returns the platform named chip8, chip48, schip or xochip
+func PlatformByName(name string) (Platform, error) {
+	platform, ok := platforms[name]
+	if !ok {
+		return Platform{}, fmt.Errorf("unknown platform %q, expected one of: %s", name, strings.Join(PlatformNames(), ", "))
+	}
+
+	return platform, nil
+}
+
+// PlatformNames returns the platform names in alphabetical order
+func PlatformNames() []string {
+	return slices.Sorted(maps.Keys(platforms))
+}
@@ -0,0 +1,85 @@
+package chip8
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// LoadStoreQuirk is how FX55 and FX65 leave register I
+type LoadStoreQuirk uint8
+
+const (
+	// LoadStoreIncrement sets I to I + X + 1
+	LoadStoreIncrement LoadStoreQuirk = iota
+	// LoadStoreIncrementX sets I to I + X
+	LoadStoreIncrementX
+	// LoadStoreUnchanged leaves I untouched
+	LoadStoreUnchanged
+)
+
+// Quirks are the behaviours that differ between CHIP-8 interpreters
+// See https://github.com/Timendus/chip8-test-suite#quirks-test
+type Quirks struct {
+	// ShiftVX makes 8XY6 and 8XYE shift VX in place, ignoring VY
+	ShiftVX bool
+	// LoadStore selects how FX55 and FX65 update I
+	LoadStore LoadStoreQuirk
+	// JumpVX makes BXNN jump to XNN + VX instead of NNN + V0
+	JumpVX bool
+	// VFReset makes 8XY1, 8XY2 and 8XY3 set VF to 0
+	VFReset bool
+	// Wrap makes sprites wrap around the screen edges instead of being clipped
+	Wrap bool
+	// DisplayWait makes DXYN wait for the next 60 Hz vertical blank
+	DisplayWait bool
+}
+
+var (
+	// QuirksCOSMACVIP is the original CHIP-8 interpreter on the RCA COSMAC VIP
+	QuirksCOSMACVIP = Quirks{
+		LoadStore:   LoadStoreIncrement,
+		VFReset:     true,
+		DisplayWait: true,
+	}
+	// QuirksCHIP48 is CHIP-48 on the HP-48 calculators
+	QuirksCHIP48 = Quirks{
+		ShiftVX:   true,
+		LoadStore: LoadStoreIncrementX,
+		JumpVX:    true,
+	}
+	// QuirksSuperCHIP is SUPER-CHIP 1.1 on the HP-48 calculators
+	QuirksSuperCHIP = Quirks{
+		ShiftVX:   true,
+		LoadStore: LoadStoreUnchanged,
+		JumpVX:    true,
+	}
+	// QuirksXOCHIP is XO-CHIP as implemented by Octo
+	QuirksXOCHIP = Quirks{
+		LoadStore: LoadStoreIncrement,
+		Wrap:      true,
+	}
+)
+
+var quirkProfiles = map[string]Quirks{
+	"chip8":  QuirksCOSMACVIP,
+	"chip48": QuirksCHIP48,
+	"schip":  QuirksSuperCHIP,
+	"xochip": QuirksXOCHIP,
+}
+
+// QuirksByName returns the preset named chip8, chip48, schip or xochip
+func QuirksByName(name string) (Quirks, error) {
+	quirks, ok := quirkProfiles[name]
+	if !ok {
+		return Quirks{}, fmt.Errorf("unknown quirks profile %q, expected one of: %s", name, strings.Join(QuirkProfileNames(), ", "))
+	}
+
+	return quirks, nil
+}
+
+// QuirkProfileNames returns the preset names in alphabetical order
+func QuirkProfileNames() []string {
+	return slices.Sorted(maps.Keys(quirkProfiles))
+}
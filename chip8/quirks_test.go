@@ -0,0 +1,136 @@
+package chip8
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShiftRightQuirk(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.registers[0] = 0x04
+	c.registers[1] = 0x03
+
+	i := shiftRight{x: 0, y: 1}
+	i.Execute(c)
+
+	assert.Equal(t, byte(0x01), c.registers[0])
+	assert.Equal(t, byte(0x01), c.registers[0xF])
+
+	c.SetQuirks(QuirksCHIP48)
+	c.registers[0] = 0x04
+	i.Execute(c)
+
+	assert.Equal(t, byte(0x02), c.registers[0])
+	assert.Equal(t, byte(0x00), c.registers[0xF])
+}
+
+func TestShiftLeftQuirk(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.SetQuirks(QuirksSuperCHIP)
+	c.registers[0] = 0x81
+	c.registers[1] = 0x01
+
+	i := shiftLeft{x: 0, y: 1}
+	i.Execute(c)
+
+	assert.Equal(t, byte(0x02), c.registers[0])
+	assert.Equal(t, byte(0x01), c.registers[0xF])
+}
+
+func TestVFResetQuirk(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.registers[0xF] = 0x05
+
+	i := or{x: 0, y: 1}
+	i.Execute(c)
+	assert.Equal(t, byte(0x00), c.registers[0xF])
+
+	c.SetQuirks(QuirksXOCHIP)
+	c.registers[0xF] = 0x05
+	i.Execute(c)
+	assert.Equal(t, byte(0x05), c.registers[0xF])
+}
+
+func TestLoadStoreQuirk(t *testing.T) {
+	tests := []struct {
+		quirks Quirks
+		index  uint16
+	}{
+		{QuirksCOSMACVIP, 0x304},
+		{QuirksCHIP48, 0x303},
+		{QuirksSuperCHIP, 0x300},
+	}
+
+	for _, tt := range tests {
+		c := NewChip8(slog.Default())
+		c.SetQuirks(tt.quirks)
+		c.index = 0x300
+
+		i := write{x: 3}
+		i.Execute(c)
+
+		assert.Equal(t, tt.index, c.index)
+	}
+}
+
+func TestJumpQuirk(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.registers[0] = 0x01
+	c.registers[2] = 0x02
+
+	i := jumpRegister0{nnn: 0x234}
+	i.Execute(c)
+	assert.Equal(t, uint16(0x235), c.fetcher.GetCounter())
+
+	c.SetQuirks(QuirksCHIP48)
+	i.Execute(c)
+	assert.Equal(t, uint16(0x236), c.fetcher.GetCounter())
+}
+
+func TestDrawSpriteWrapQuirk(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.index = 0x300
+	c.memory[0x300] = 0xFF
+	c.registers[0] = 60
+	c.registers[1] = 31
+
+	i := drawSprite{x: 0, y: 1, n: 1}
+	i.Execute(c)
+	assert.True(t, c.screen.Get(63, 31))
+	assert.False(t, c.screen.Get(0, 31))
+
+	c.SetQuirks(QuirksXOCHIP)
+	c.screen.Clear()
+	i.Execute(c)
+	assert.True(t, c.screen.Get(63, 31))
+	assert.True(t, c.screen.Get(3, 31))
+}
+
+func TestDisplayWaitQuirk(t *testing.T) {
+	// LOAD I,0x0050; DRAW V0,V0,5; ADD V1,1; JUMP 0x0206
+	c, _ := newTestChip8(t, 0xa0, 0x50, 0xd0, 0x05, 0x71, 0x01, 0x12, 0x06)
+	c.SetIPF(10)
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, uint16(0x204), c.fetcher.GetCounter())
+	assert.Equal(t, byte(0x00), c.registers[1])
+
+	assert.NoError(t, c.Update())
+	assert.Equal(t, byte(0x01), c.registers[1])
+
+	c.SetQuirks(QuirksSuperCHIP)
+	c.fetcher.SetCounter(0x200)
+	assert.NoError(t, c.Update())
+	assert.Equal(t, byte(0x02), c.registers[1])
+}
+
+func TestQuirksByName(t *testing.T) {
+	quirks, err := QuirksByName("schip")
+	assert.NoError(t, err)
+	assert.Equal(t, QuirksSuperCHIP, quirks)
+
+	_, err = QuirksByName("nope")
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package chip8
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+const (
+	vipInterpreterBytes = 0x200
+	vipRandomPage       = 0x100 // the random table is the interpreter's second page, where the CXNN routine runs
+	vipRandomTag        = "vip:"
+)
+
+// ErrInvalidInterpreter is returned when a COSMAC VIP interpreter image is too short to hold its second page
+var ErrInvalidInterpreter = errors.New("invalid COSMAC VIP interpreter")
+
+// RandomSource produces the random bytes CXNN masks
+type RandomSource interface {
+	Byte() uint8
+}
+
+// frameRandom is a random source that also moves on once per 60 Hz frame, whatever the program does
+type frameRandom interface {
+	Frame()
+}
+
+// NewRandom returns a deterministic source: the same seed always yields the same bytes
+func NewRandom(seed uint64) RandomSource {
+	pcg := rand.NewPCG(seed, seed)
+	return &seededRandom{
+		pcg: pcg,
+		rng: rand.New(pcg),
+	}
+}
+
+type seededRandom struct {
+	pcg *rand.PCG
+	rng *rand.Rand
+}
+
+func (r *seededRandom) Byte() uint8 {
+	return uint8(r.rng.Uint32())
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, so snapshots carry on with the same bytes
+func (r *seededRandom) MarshalBinary() ([]byte, error) {
+	return r.pcg.MarshalBinary()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (r *seededRandom) UnmarshalBinary(data []byte) error {
+	return r.pcg.UnmarshalBinary(data)
+}
+
+// NewVIPRandom returns the COSMAC VIP interpreter's own CXNN algorithm, so programs relying on its patterns behave
+// like on the original machine. It needs the 512 bytes of the interpreter, whose second page is the table it reads
+// The seed is the starting value of the VIP's R9 register, which the interrupt routine also increments every frame
+func NewVIPRandom(interpreter []byte, seed uint16) (RandomSource, error) {
+	if len(interpreter) < vipInterpreterBytes {
+		return nil, fmt.Errorf("%w: %d bytes, expected %d", ErrInvalidInterpreter, len(interpreter), vipInterpreterBytes)
+	}
+
+	r := &vipRandom{r9: seed}
+	copy(r.page[:], interpreter[vipRandomPage:vipInterpreterBytes])
+	return r, nil
+}
+
+type vipRandom struct {
+	page [0x100]uint8
+	r9   uint16 // R9.0 points into the page, R9.1 holds the last random byte
+}
+
+// Byte adds the table byte R9.0 points at to R9.1, then adds that sum, rotated right through the carry, to itself
+func (r *vipRandom) Byte() uint8 {
+	r.r9++
+
+	sum := uint16(r.r9>>8) + uint16(r.page[uint8(r.r9)])
+	v := uint8(sum)
+	rotated := v>>1 | uint8(sum>>8)<<7
+	v += rotated
+
+	r.r9 = uint16(v)<<8 | r.r9&0xFF
+	return v
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, the table being part of the interpreter rather than the state
+func (r *vipRandom) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint16([]byte(vipRandomTag), r.r9), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (r *vipRandom) UnmarshalBinary(data []byte) error {
+	if len(data) != len(vipRandomTag)+2 || string(data[:len(vipRandomTag)]) != vipRandomTag {
+		return errors.New("invalid VIP random state")
+	}
+
+	r.r9 = binary.BigEndian.Uint16(data[len(vipRandomTag):])
+	return nil
+}
+
+// Frame implements frameRandom, the VIP interrupt routine incrementing R9 every frame
+func (r *vipRandom) Frame() {
+	r.r9++
+}
+
+// RandomSeed returns a seed from the operating system, to be logged so a run can be reproduced
+func RandomSeed() uint64 {
+	b := make([]byte, 8)
+	_, _ = crand.Read(b)
+	return binary.BigEndian.Uint64(b)
+}
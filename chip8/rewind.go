@@ -0,0 +1,148 @@
+package chip8
+
+import (
+	"bytes"
+)
+
+// patchGap is how many equal bytes may sit between two differences before they are stored as separate patches
+const patchGap = 8
+
+// Rewind is a ring buffer of recent snapshots, taken every few frames, to step back in time
+// Only the newest snapshot is kept whole. Older ones are stored as the bytes that differ from the next one,
+// so a long history costs little more than the memory and pixels a program actually changes
+type Rewind struct {
+	interval uint64
+	entries  []rewindEntry
+	start    int
+	count    int
+	last     uint64 // frame the newest snapshot was taken at
+	latest   []byte // newest snapshot, encoded
+}
+
+type rewindEntry struct {
+	undo []patch // turns the next snapshot into this one, nil for the newest
+}
+
+// patch replaces the bytes at offset with data
+type patch struct {
+	offset int
+	data   []byte
+}
+
+// NewRewind creates a rewind buffer keeping up to capacity snapshots taken every interval frames
+func NewRewind(interval, capacity int) *Rewind {
+	return &Rewind{
+		interval: uint64(max(interval, 1)),
+		entries:  make([]rewindEntry, max(capacity, 1)),
+	}
+}
+
+// Record takes a snapshot if interval frames have elapsed since the last one
+// The oldest snapshot is dropped once the buffer is full
+func (r *Rewind) Record(c *Chip8) {
+	frames := c.Frames()
+	if r.count > 0 && frames-r.last < r.interval {
+		return
+	}
+	r.last = frames
+
+	var buf bytes.Buffer
+	_, _ = c.Snapshot().WriteTo(&buf)
+	encoded := buf.Bytes()
+
+	if r.count > 0 {
+		if len(encoded) != len(r.latest) {
+			r.Reset()
+		} else {
+			r.at(r.count - 1).undo = diff(encoded, r.latest)
+		}
+	}
+	r.latest = encoded
+
+	if r.count == len(r.entries) {
+		r.entries[r.start] = rewindEntry{}
+		r.start = (r.start + 1) % len(r.entries)
+		r.count--
+	}
+	r.count++
+	*r.at(r.count - 1) = rewindEntry{}
+}
+
+// Step restores the newest snapshot and forgets it, so the next Step goes further back
+// Return false if there is nothing left to rewind
+func (r *Rewind) Step(c *Chip8) bool {
+	if r.count == 0 {
+		return false
+	}
+
+	snapshot, err := ReadSnapshot(bytes.NewReader(r.latest))
+	if err != nil || c.Restore(snapshot) != nil {
+		r.Reset()
+		return false
+	}
+
+	r.count--
+	if r.count > 0 {
+		previous := r.at(r.count - 1)
+		for _, p := range previous.undo {
+			copy(r.latest[p.offset:], p.data)
+		}
+		previous.undo = nil
+	} else {
+		r.latest = nil
+	}
+	r.last = c.Frames()
+
+	return true
+}
+
+// Reset drops every snapshot
+func (r *Rewind) Reset() {
+	clear(r.entries)
+	r.start = 0
+	r.count = 0
+	r.latest = nil
+}
+
+// Len returns how many snapshots can be stepped back
+func (r *Rewind) Len() int {
+	return r.count
+}
+
+// Size returns approximately how many bytes the snapshots take
+func (r *Rewind) Size() int {
+	size := len(r.latest)
+	for j := 0; j < r.count; j++ {
+		for _, p := range r.at(j).undo {
+			size += len(p.data) + 8
+		}
+	}
+	return size
+}
+
+func (r *Rewind) at(j int) *rewindEntry {
+	return &r.entries[(r.start+j)%len(r.entries)]
+}
+
+// diff returns the patches that turn from into to, both of the same length
+func diff(from, to []byte) []patch {
+	var patches []patch
+
+	for i := 0; i < len(from); i++ {
+		if from[i] == to[i] {
+			continue
+		}
+
+		end := i + 1
+		for j := end; j < len(from) && j < end+patchGap; j++ {
+			if from[j] != to[j] {
+				end = j + 1
+			}
+		}
+
+		patches = append(patches, patch{offset: i, data: bytes.Clone(to[i:end])})
+		i = end - 1
+	}
+
+	return patches
+}
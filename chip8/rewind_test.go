@@ -0,0 +1,49 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewindSteps(t *testing.T) {
+	// ADD V0,1; LOAD I,0x0300; WRITE V0-V0; JUMP 0x0200
+	c, _ := newTestChip8(t, 0x70, 0x01, 0xa3, 0x00, 0xf0, 0x55, 0x12, 0x00)
+	r := NewRewind(4, 10)
+
+	values := []byte{}
+	for j := 0; j < 20; j++ {
+		r.Record(c)
+		if c.frames%4 == 0 {
+			values = append(values, c.registers[0])
+		}
+		assert.NoError(t, c.Update())
+	}
+	assert.Equal(t, 5, r.Len())
+
+	for j := len(values) - 1; j >= 0; j-- {
+		assert.True(t, r.Step(c))
+		assert.Equal(t, values[j], c.registers[0])
+		assert.Equal(t, values[j], c.memory[0x300])
+	}
+
+	assert.False(t, r.Step(c))
+}
+
+func TestRewindCapacity(t *testing.T) {
+	// ADD V0,1; JUMP 0x0200
+	c, _ := newTestChip8(t, 0x70, 0x01, 0x12, 0x00)
+	r := NewRewind(1, 3)
+
+	for j := 0; j < 10; j++ {
+		r.Record(c)
+		assert.NoError(t, c.Update())
+	}
+
+	assert.Equal(t, 3, r.Len())
+	assert.Less(t, r.Size(), 2*len(c.Snapshot().memory)+10000)
+
+	for r.Step(c) {
+	}
+	assert.Equal(t, byte(0x04), c.registers[0])
+}
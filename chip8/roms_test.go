@@ -0,0 +1,152 @@
+package chip8
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images of the ROM tests with the current output")
+
+const (
+	romsDir   = "../roms"
+	goldenDir = "testdata/golden"
+)
+
+// keyPress holds a key down for frames frames, starting at frame
+type keyPress struct {
+	frame  uint64
+	key    uint8
+	frames uint64
+}
+
+// romTest runs a ROM headless and compares the screen with testdata/golden/<name>.txt
+type romTest struct {
+	name     string
+	rom      string
+	platform string
+	ipf      uint
+	frames   uint64
+	keys     []keyPress
+}
+
+// romTests are the Timendus test suite ROMs in roms/, menus being driven by key presses
+var romTests = []romTest{
+	{name: "1-chip8-logo", rom: "1-chip8-logo.ch8", platform: "chip8", frames: 60},
+	{name: "2-ibm-logo", rom: "2-ibm-logo.ch8", platform: "chip8", frames: 60},
+	{name: "3-corax+", rom: "3-corax+.ch8", platform: "chip8", frames: 120},
+	{name: "4-flags", rom: "4-flags.ch8", platform: "chip8", frames: 120},
+	{name: "4-flags-xochip", rom: "4-flags.ch8", platform: "xochip", ipf: 1000, frames: 60},
+	{name: "5-quirks-chip8", rom: "5-quirks.ch8", platform: "chip8", frames: 600,
+		keys: []keyPress{{frame: 120, key: 0x1, frames: 4}}},
+	{name: "5-quirks-schip", rom: "5-quirks.ch8", platform: "schip", ipf: 30, frames: 600,
+		keys: []keyPress{{frame: 30, key: 0x2, frames: 4}, {frame: 60, key: 0x1, frames: 4}}},
+	{name: "5-quirks-xochip", rom: "5-quirks.ch8", platform: "xochip", ipf: 1000, frames: 300,
+		keys: []keyPress{{frame: 30, key: 0x3, frames: 4}}},
+	{name: "6-keypad-getkey", rom: "6-keypad.ch8", platform: "chip8", frames: 300,
+		keys: []keyPress{{frame: 120, key: 0x3, frames: 4}, {frame: 200, key: 0xA, frames: 4}}},
+	{name: "7-beep", rom: "7-beep.ch8", platform: "chip8", frames: 110,
+		keys: []keyPress{{frame: 100, key: 0xB, frames: 30}}},
+}
+
+func TestROMs(t *testing.T) {
+	for _, test := range romTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := test.run(t)
+
+			actual := c.Screen().Text()
+			path := filepath.Join(goldenDir, test.name+".txt")
+			if *update {
+				assert.NoError(t, os.MkdirAll(goldenDir, 0o755))
+				assert.NoError(t, os.WriteFile(path, []byte(actual), 0o644))
+				return
+			}
+
+			golden, err := os.ReadFile(path)
+			if !assert.NoError(t, err, "run go test ./chip8 -run TestROMs -update to create it") {
+				return
+			}
+			if actual != string(golden) {
+				t.Errorf("screen after %d frames differs from %s, + is on but should be off, - is off but should be on:\n%s",
+					test.frames, path, bitmapDiff(string(golden), actual))
+			}
+		})
+	}
+}
+
+// run loads the ROM on a seeded machine and runs it for the test frames, pressing the scripted keys
+func (test romTest) run(t *testing.T) *Chip8 {
+	rom, err := os.ReadFile(filepath.Join(romsDir, test.rom))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	platform, err := PlatformByName(test.platform)
+	assert.NoError(t, err)
+
+	c := NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.SetPlatform(platform)
+	c.SetRandom(NewRandom(1))
+	if test.ipf != 0 {
+		c.SetIPF(test.ipf)
+	}
+	assert.NoError(t, c.LoadFont())
+	assert.NoError(t, c.LoadROM(bytes.NewReader(rom)))
+
+	movie := &Movie{Length: test.frames}
+	for _, press := range test.keys {
+		movie.events = append(movie.events,
+			movieEvent{frame: press.frame, keys: 1 << press.key},
+			movieEvent{frame: press.frame + press.frames})
+	}
+	c.SetKeypad(NewMoviePlayer(movie))
+
+	for frame := uint64(0); frame < test.frames; frame++ {
+		if err := c.Update(); err != nil {
+			assert.ErrorIs(t, err, ErrExit, "frame %d", frame)
+			break
+		}
+	}
+
+	return c
+}
+
+// bitmapDiff marks the pixels that differ between two bitmaps with + and -, or explains why they can't be compared
+func bitmapDiff(expected, actual string) string {
+	expectedRows := strings.Split(strings.TrimSuffix(expected, "\n"), "\n")
+	actualRows := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+	if len(expectedRows) != len(actualRows) || len(expectedRows[0]) != len(actualRows[0]) {
+		return fmt.Sprintf("expected %dx%d, got %dx%d:\n%s",
+			len(expectedRows[0]), len(expectedRows), len(actualRows[0]), len(actualRows), actual)
+	}
+
+	var sb strings.Builder
+	for y := range actualRows {
+		for x := range actualRows[y] {
+			e := byte('.')
+			if x < len(expectedRows[y]) {
+				e = expectedRows[y][x]
+			}
+
+			switch a := actualRows[y][x]; {
+			case e == a:
+				sb.WriteByte(a)
+			case a == '#':
+				sb.WriteByte('+')
+			default:
+				sb.WriteByte('-')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
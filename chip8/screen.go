@@ -1,64 +1,241 @@
 package chip8
 
-import (
-	"image/color"
-
-	"github.com/hajimehoshi/ebiten/v2"
-)
-
 const (
-	screenWidth      = 64
-	screenHeight     = 32
-	screenMultiplier = 16
-	bytePixels       = 8
+	screenWidth       = 64
+	screenHeight      = 32
+	hiresScreenWidth  = 128
+	hiresScreenHeight = 64
+	bytePixels        = 8
+	planeCount        = 2
+	defaultPlanes     = 0x1
+	wordPixels        = 64
+	rowWords          = hiresScreenWidth / wordPixels
 )
 
-var (
-	pixelColorOn  = color.White
-	pixelColorOff = color.Black
-)
+// row holds a line of one plane, a bit per pixel, the leftmost pixel being the top bit of the first word
+type row [rowWords]uint64
 
+// Screen is the framebuffer. It is 64x32 in low resolution and 128x64 in SUPER-CHIP high resolution mode
+// Each pixel holds one bit per XO-CHIP bitplane, so there are four colours: off, plane 1, plane 2 and both
+// Planes are packed a bit per pixel, so sprites are drawn a row at a time
 type Screen struct {
-	buffer *ebiten.Image
+	bits   [planeCount][hiresScreenHeight]row
+	hires  bool
+	planes uint8 // bitmask of the planes drawing, clearing and scrolling act on
+	dirty  bool
 }
 
 func NewScreen() *Screen {
 	return &Screen{
-		buffer: ebiten.NewImage(screenWidth, screenHeight),
+		planes: defaultPlanes,
 	}
 }
 
 func (s *Screen) Layout() (w, h int) {
+	if s.hires {
+		return hiresScreenWidth, hiresScreenHeight
+	}
 	return screenWidth, screenHeight
 }
 
+// SetHighResolution switches between 64x32 and 128x64, clearing every plane
+func (s *Screen) SetHighResolution(hires bool) {
+	s.hires = hires
+	s.bits = [planeCount][hiresScreenHeight]row{}
+	s.dirty = true
+}
+
+func (s *Screen) IsHighResolution() bool {
+	return s.hires
+}
+
+// SelectPlanes sets the bitmask of planes following operations act on
+func (s *Screen) SelectPlanes(planes uint8) {
+	s.planes = planes & (1<<planeCount - 1)
+}
+
+func (s *Screen) SelectedPlanes() uint8 {
+	return s.planes
+}
+
+// Clear turns off every pixel in the selected planes
 func (s *Screen) Clear() {
-	s.buffer.Fill(pixelColorOff)
+	for plane := range s.bits {
+		if s.planes&(1<<plane) != 0 {
+			s.bits[plane] = [hiresScreenHeight]row{}
+		}
+	}
+	s.dirty = true
 }
 
+// Get returns true if the pixel is on in any plane
 func (s *Screen) Get(x, y int) bool {
-	// TODO: reduce calls https://ebitengine.org/en/documents/performancetips.html#Don't_call_(*Image).At_too_much
-	return pixelOn(s.buffer.At(x, y))
+	return s.Pixel(x, y) != 0
 }
 
+// Pixel returns the colour of a pixel, a bitmask of the planes it is on in
+func (s *Screen) Pixel(x, y int) uint8 {
+	var colour uint8
+	for plane := range s.bits {
+		if s.bits[plane][y].get(x) {
+			colour |= 1 << plane
+		}
+	}
+
+	return colour
+}
+
+// Set turns a pixel on or off in the selected planes
 func (s *Screen) Set(x, y int, on bool) {
-	s.buffer.Set(x, y, pixelColor(on))
+	for plane := range s.bits {
+		if s.planes&(1<<plane) != 0 {
+			s.bits[plane][y].set(x, on)
+		}
+	}
+	s.dirty = true
+}
+
+// xorRow flips the pixels of a plane's line set in mask, returning true if any of them was on
+func (s *Screen) xorRow(plane, y int, mask row) bool {
+	r := &s.bits[plane][y]
+	collision := r[0]&mask[0] != 0 || r[1]&mask[1] != 0
+
+	r[0] ^= mask[0]
+	r[1] ^= mask[1]
+	s.dirty = true
+
+	return collision
 }
 
-func (s *Screen) Draw(image *ebiten.Image) {
-	// TODO: Use BlendXor, if we still can detect collisions https://ebitengine.org/en/examples/blend.html
-	image.DrawImage(s.buffer, nil)
+// ScrollDown moves every row n pixels down, blank rows appear at the top
+func (s *Screen) ScrollDown(n int) {
+	s.scroll(0, n)
 }
 
-func pixelColor(on bool) color.Color {
+// ScrollUp moves every row n pixels up, blank rows appear at the bottom
+func (s *Screen) ScrollUp(n int) {
+	s.scroll(0, -n)
+}
+
+// ScrollRight moves every column n pixels right, blank columns appear on the left
+func (s *Screen) ScrollRight(n int) {
+	s.scroll(n, 0)
+}
+
+// ScrollLeft moves every column n pixels left, blank columns appear on the right
+func (s *Screen) ScrollLeft(n int) {
+	s.scroll(-n, 0)
+}
+
+// scroll moves the selected planes, leaving the others in place
+func (s *Screen) scroll(dx, dy int) {
+	w, h := s.Layout()
+	visible := widthMask(w)
+
+	for plane := range s.bits {
+		if s.planes&(1<<plane) == 0 {
+			continue
+		}
+
+		var scrolled [hiresScreenHeight]row
+		for y := 0; y < h; y++ {
+			fromY := y - dy
+			if fromY < 0 || fromY >= h {
+				continue
+			}
+
+			r := s.bits[plane][fromY]
+			if dx >= 0 {
+				r = r.shiftRight(dx)
+			} else {
+				r = r.shiftLeft(-dx)
+			}
+			scrolled[y] = r.and(visible)
+		}
+		s.bits[plane] = scrolled
+	}
+	s.dirty = true
+}
+
+// pixels returns the colour of every pixel, row by row at the current width, as stored in snapshots
+func (s *Screen) pixels() [hiresScreenWidth * hiresScreenHeight]uint8 {
+	var pixels [hiresScreenWidth * hiresScreenHeight]uint8
+
+	w, h := s.Layout()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pixels[y*w+x] = s.Pixel(x, y)
+		}
+	}
+
+	return pixels
+}
+
+// setPixels replaces the framebuffer with the colours returned by pixels, for the current resolution
+func (s *Screen) setPixels(pixels [hiresScreenWidth * hiresScreenHeight]uint8) {
+	s.bits = [planeCount][hiresScreenHeight]row{}
+
+	w, h := s.Layout()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for plane := range s.bits {
+				s.bits[plane][y].set(x, pixels[y*w+x]&(1<<plane) != 0)
+			}
+		}
+	}
+}
+
+// spriteRow places count sprite pixels, the top bits of line, at column x of a screen w pixels wide
+// Pixels past the right edge wrap around to the left if wrap is true and are clipped otherwise
+func spriteRow(line uint64, count, x, w int, wrap bool) row {
+	sprite := row{line << (wordPixels - count)}
+
+	mask := sprite.shiftRight(x)
+	if wrap {
+		mask = mask.or(sprite.shiftLeft(w - x))
+	}
+
+	return mask.and(widthMask(w))
+}
+
+// widthMask has the first w pixels of a row on
+func widthMask(w int) row {
+	return row{^uint64(0), ^uint64(0)}.shiftLeft(hiresScreenWidth - w)
+}
+
+func (r row) get(x int) bool {
+	return r[x/wordPixels]>>(wordPixels-1-x%wordPixels)&1 == 1
+}
+
+func (r *row) set(x int, on bool) {
+	bit := uint64(1) << (wordPixels - 1 - x%wordPixels)
 	if on {
-		return pixelColorOn
+		r[x/wordPixels] |= bit
+	} else {
+		r[x/wordPixels] &^= bit
 	}
-	return pixelColorOff
 }
 
-func pixelOn(c color.Color) bool {
-	r1, g1, b1, a1 := c.RGBA()
-	r2, g2, b2, a2 := pixelColorOn.RGBA()
-	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+// shiftRight moves the pixels n columns right, 0 <= n <= 128
+func (r row) shiftRight(n int) row {
+	if n >= wordPixels {
+		return row{0, r[0] >> (n - wordPixels)}
+	}
+	return row{r[0] >> n, r[1]>>n | r[0]<<(wordPixels-n)}
+}
+
+// shiftLeft moves the pixels n columns left, 0 <= n <= 128
+func (r row) shiftLeft(n int) row {
+	if n >= wordPixels {
+		return row{r[1] << (n - wordPixels), 0}
+	}
+	return row{r[0]<<n | r[1]>>(wordPixels-n), r[1] << n}
+}
+
+func (r row) and(o row) row {
+	return row{r[0] & o[0], r[1] & o[1]}
+}
+
+func (r row) or(o row) row {
+	return row{r[0] | o[0], r[1] | o[1]}
 }
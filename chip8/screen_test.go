@@ -0,0 +1,89 @@
+package chip8
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrawSpriteAcrossWords(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.screen.SetHighResolution(true)
+	c.index = 0x300
+	c.memory[0x300] = 0xA5
+	c.registers[0] = 60
+	c.registers[1] = 7
+
+	i := drawSprite{x: 0, y: 1, n: 1}
+	i.Execute(c)
+
+	for x, on := range []bool{true, false, true, false, false, true, false, true} {
+		assert.Equal(t, on, c.screen.Get(60+x, 7), "x %d", 60+x)
+	}
+	assert.False(t, c.screen.Get(59, 7))
+	assert.False(t, c.screen.Get(68, 7))
+	assert.Equal(t, byte(0x00), c.registers[0xF])
+
+	c.memory[0x300] = 0x01
+	i.Execute(c)
+	assert.False(t, c.screen.Get(67, 7))
+	assert.True(t, c.screen.Get(60, 7))
+	assert.Equal(t, byte(0x01), c.registers[0xF])
+}
+
+func TestDrawSpriteWrapsHighResolution(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.SetQuirks(QuirksXOCHIP)
+	c.screen.SetHighResolution(true)
+	c.index = 0x300
+	c.memory[0x300] = 0xFF
+	c.registers[0] = 124
+
+	i := drawSprite{x: 0, y: 1, n: 1}
+	i.Execute(c)
+
+	assert.True(t, c.screen.Get(127, 0))
+	assert.True(t, c.screen.Get(3, 0))
+	assert.False(t, c.screen.Get(4, 0))
+	assert.False(t, c.screen.Get(123, 0))
+}
+
+func TestScrollAcrossWords(t *testing.T) {
+	s := NewScreen()
+	s.SetHighResolution(true)
+	s.Set(62, 10, true)
+
+	s.ScrollRight(4)
+	assert.False(t, s.Get(62, 10))
+	assert.True(t, s.Get(66, 10))
+
+	s.ScrollLeft(4)
+	s.ScrollLeft(4)
+	assert.True(t, s.Get(58, 10))
+
+	s.SetHighResolution(false)
+	s.Set(62, 3, true)
+	s.ScrollRight(4)
+	assert.False(t, s.Get(62, 3))
+	s.ScrollLeft(4)
+	assert.False(t, s.Get(62, 3), "pixels scrolled off the low resolution screen are lost")
+}
+
+func TestScreenPixelsRoundTrip(t *testing.T) {
+	s := NewScreen()
+	s.SetHighResolution(true)
+	s.Set(0, 0, true)
+	s.SelectPlanes(0x3)
+	s.Set(127, 63, true)
+	s.SelectPlanes(0x2)
+	s.Set(64, 32, true)
+
+	restored := NewScreen()
+	restored.SetHighResolution(true)
+	restored.setPixels(s.pixels())
+
+	assert.Equal(t, s.bits, restored.bits)
+	assert.Equal(t, uint8(0x3), restored.Pixel(127, 63))
+	assert.Equal(t, uint8(0x2), restored.Pixel(64, 32))
+}
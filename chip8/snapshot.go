@@ -0,0 +1,301 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+const (
+	snapshotMagic   = "CH8S"
+	snapshotVersion = 4
+)
+
+// Save states are a header followed by sections, each a 4 byte tag, a 4 byte length and its data
+// Readers skip the sections they don't know and leave the machine defaults for the ones missing. Sections holding
+// a struct only ever get fields appended: older readers ignore the extra bytes, newer ones read missing fields as 0
+const (
+	sectionMemory = "MEM "
+	sectionQuirks = "QRKS"
+	sectionCPU    = "CPU "
+	sectionScreen = "SCRN"
+	sectionInput  = "KEYS"
+	sectionAudio  = "AUDI"
+	sectionRandom = "RAND"
+)
+
+// ErrInvalidSnapshot is returned when a save state can't be read or restored
+var ErrInvalidSnapshot = errors.New("invalid save state")
+
+// Snapshot is a copy of the complete machine state, see Chip8.Snapshot and Chip8.Restore
+type Snapshot struct {
+	memory Memory
+	quirks *Quirks // nil if the save state doesn't tell
+	cpu    cpuState
+	screen screenState
+	input  inputState
+	audio  audioState
+	random []byte // state of the random source, if it can be saved
+}
+
+// cpuState is the registers, stack, timers and counters
+type cpuState struct {
+	Registers    Registers
+	UserFlags    Registers
+	Index        uint16
+	StackData    [stackLevels]uint16
+	StackPointer uint8
+	Counter      uint16
+	DelayValue   uint8
+	SoundValue   uint8
+	VBlankWait   bool
+	Exited       bool
+	Frames       uint64
+	Cycles       uint64
+	FrameCycles  uint32
+}
+
+type screenState struct {
+	Pixels [hiresScreenWidth * hiresScreenHeight]uint8
+	Hires  bool
+	Planes uint8
+}
+
+type inputState struct {
+	Keys         [KeyCount]bool
+	Waiting      bool
+	WaitRegister uint8
+}
+
+type audioState struct {
+	Pattern [AudioPatternBytes]uint8
+	Pitch   uint8
+}
+
+// Snapshot copies the machine state, including the random source's if it implements encoding.BinaryMarshaler
+func (c *Chip8) Snapshot() *Snapshot {
+	var random []byte
+	if m, ok := c.random.(encoding.BinaryMarshaler); ok {
+		random, _ = m.MarshalBinary()
+	}
+
+	quirks := c.quirks
+	return &Snapshot{
+		memory: slices.Clone(c.memory),
+		quirks: &quirks,
+		cpu: cpuState{
+			Registers:    c.registers,
+			UserFlags:    c.userFlags,
+			Index:        c.index,
+			StackData:    c.stack.data,
+			StackPointer: c.stack.pointer,
+			Counter:      c.fetcher.counter,
+			DelayValue:   c.delayTimer.value,
+			SoundValue:   c.soundTimer.value,
+			VBlankWait:   c.vblankWait,
+			Exited:       c.exited,
+			Frames:       c.frames,
+			Cycles:       c.cycles,
+			FrameCycles:  uint32(c.frameCycles),
+		},
+		screen: screenState{
+			Pixels: c.screen.pixels(),
+			Hires:  c.screen.hires,
+			Planes: c.screen.planes,
+		},
+		input: inputState{
+			Keys:         c.input.keys,
+			Waiting:      c.input.waiting,
+			WaitRegister: c.input.register,
+		},
+		audio: audioState{
+			Pattern: c.pattern,
+			Pitch:   c.pitch,
+		},
+		random: random,
+	}
+}
+
+// Restore replaces the machine state with a snapshot taken on the same platform, with the same quirks and the same
+// kind of random source
+func (c *Chip8) Restore(s *Snapshot) error {
+	if len(s.memory) != len(c.memory) {
+		return fmt.Errorf("%w: %d bytes of memory, platform has %d", ErrInvalidSnapshot, len(s.memory), len(c.memory))
+	}
+	if s.quirks != nil && *s.quirks != c.quirks {
+		return fmt.Errorf("%w: saved with quirks %+v, machine has %+v", ErrInvalidSnapshot, *s.quirks, c.quirks)
+	}
+	if len(s.random) > 0 {
+		u, ok := c.random.(encoding.BinaryUnmarshaler)
+		if !ok {
+			return fmt.Errorf("%w: the random source state can't be restored", ErrInvalidSnapshot)
+		}
+		if err := u.UnmarshalBinary(s.random); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+	}
+
+	copy(c.memory, s.memory)
+	c.registers = s.cpu.Registers
+	c.userFlags = s.cpu.UserFlags
+	c.index = s.cpu.Index
+	c.stack.data = s.cpu.StackData
+	c.stack.pointer = s.cpu.StackPointer
+	c.fetcher.counter = s.cpu.Counter
+	c.delayTimer.value = s.cpu.DelayValue
+	c.soundTimer.value = s.cpu.SoundValue
+	c.vblankWait = s.cpu.VBlankWait
+	c.exited = s.cpu.Exited
+	c.frames = s.cpu.Frames
+	c.cycles = s.cpu.Cycles
+	c.frameCycles = uint(s.cpu.FrameCycles)
+	c.screen.hires = s.screen.Hires
+	c.screen.planes = s.screen.Planes
+	c.screen.setPixels(s.screen.Pixels)
+	c.input.keys = s.input.Keys
+	c.input.waiting = s.input.Waiting
+	c.input.register = s.input.WaitRegister
+	c.pattern = s.audio.Pattern
+	c.pitch = s.audio.Pitch
+
+	c.audio.SetPattern(c.pattern, c.pitch)
+	c.screen.dirty = false
+	c.display.Refresh(c.screen)
+
+	return nil
+}
+
+// WriteTo encodes the snapshot as a versioned save state
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(snapshotVersion))
+
+	writeSection(&buf, sectionMemory, s.memory)
+	if s.quirks != nil {
+		writeSection(&buf, sectionQuirks, structBytes(s.quirks))
+	}
+	writeSection(&buf, sectionCPU, structBytes(&s.cpu))
+	writeSection(&buf, sectionScreen, structBytes(&s.screen))
+	writeSection(&buf, sectionInput, structBytes(&s.input))
+	writeSection(&buf, sectionAudio, structBytes(&s.audio))
+	if len(s.random) > 0 {
+		writeSection(&buf, sectionRandom, s.random)
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadSnapshot decodes a save state written by Snapshot.WriteTo, validating its header
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	header := make([]byte, len(snapshotMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("%w: not a save state", ErrInvalidSnapshot)
+	}
+	if version := binary.BigEndian.Uint16(header[len(snapshotMagic):]); version != snapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, version)
+	}
+
+	s := &Snapshot{audio: audioState{Pitch: defaultPitch}}
+	var hasCPU bool
+	for {
+		tag, data, err := readSection(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+
+		switch tag {
+		case sectionMemory:
+			if len(data) != memoryLocations && len(data) != xoChipMemoryLocations {
+				return nil, fmt.Errorf("%w: unexpected memory size %d", ErrInvalidSnapshot, len(data))
+			}
+			s.memory = data
+		case sectionQuirks:
+			s.quirks = &Quirks{}
+			readStruct(data, s.quirks)
+		case sectionCPU:
+			readStruct(data, &s.cpu)
+			hasCPU = true
+		case sectionScreen:
+			readStruct(data, &s.screen)
+		case sectionInput:
+			readStruct(data, &s.input)
+		case sectionAudio:
+			readStruct(data, &s.audio)
+		case sectionRandom:
+			s.random = data
+		}
+	}
+
+	if s.memory == nil || !hasCPU {
+		return nil, fmt.Errorf("%w: missing memory or CPU state", ErrInvalidSnapshot)
+	}
+	if s.cpu.StackPointer > stackLevels || s.input.WaitRegister >= registerCount || s.screen.Planes >= 1<<planeCount {
+		return nil, fmt.Errorf("%w: corrupted machine state", ErrInvalidSnapshot)
+	}
+
+	return s, nil
+}
+
+func writeSection(buf *bytes.Buffer, tag string, data []byte) {
+	buf.WriteString(tag)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+}
+
+// readSection returns the next section, io.EOF once there are none left
+func readSection(r io.Reader) (string, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[4:])
+	if size > xoChipMemoryLocations {
+		return "", nil, fmt.Errorf("section %q of %d bytes", header[:4], size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", nil, fmt.Errorf("section %q: %w", header[:4], err)
+	}
+
+	return string(header[:4]), data, nil
+}
+
+// structBytes encodes a struct of fixed size fields
+func structBytes(v any) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, v)
+	return buf.Bytes()
+}
+
+// readStruct decodes a section written by structBytes, ignoring fields it doesn't know and zeroing those it lacks
+func readStruct(data []byte, v any) {
+	size := binary.Size(v)
+	if len(data) < size {
+		data = append(data, make([]byte, size-len(data))...)
+	}
+	_, _ = binary.Decode(data[:size], binary.BigEndian, v)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,198 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	// LOAD V3,K; JUMP 0x0202
+	c, f := newTestChip8(t, 0xf3, 0x0a, 0x12, 0x02)
+	c.registers[5] = 0x55
+	c.index = 0x123
+	assert.NoError(t, c.stack.Push(0x246))
+	c.delayTimer.SetValue(30)
+	c.screen.Set(3, 4, true)
+	assert.NoError(t, c.Update())
+
+	s := c.Snapshot()
+
+	c.registers[5] = 0
+	c.index = 0
+	_, _ = c.stack.Pop()
+	c.delayTimer.SetValue(0)
+	c.screen.Clear()
+	c.memory[0x200] = 0
+	c.input.waiting = false
+
+	assert.NoError(t, c.Restore(s))
+
+	assert.Equal(t, byte(0x55), c.registers[5])
+	assert.Equal(t, uint16(0x123), c.index)
+	address, err := c.stack.Pop()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x246), address)
+	assert.Equal(t, uint8(29), c.delayTimer.GetValue())
+	assert.True(t, c.screen.Get(3, 4))
+	assert.Equal(t, byte(0xf3), c.memory[0x200])
+
+	// pending FX0A wait survives
+	f.keys[0x7] = true
+	assert.NoError(t, c.Update())
+	f.keys[0x7] = false
+	assert.NoError(t, c.Update())
+	assert.Equal(t, byte(0x7), c.registers[3])
+}
+
+func TestSnapshotEncoding(t *testing.T) {
+	c, _ := newTestChip8(t, 0x60, 0x2a)
+	assert.NoError(t, c.Update())
+	c.delayTimer.SetValue(9)
+
+	var buf bytes.Buffer
+	_, err := c.Snapshot().WriteTo(&buf)
+	assert.NoError(t, err)
+
+	s, err := ReadSnapshot(&buf)
+	assert.NoError(t, err)
+
+	restored := NewChip8(slog.Default())
+	assert.NoError(t, restored.Restore(s))
+	assert.Equal(t, byte(0x2a), restored.registers[0])
+	assert.Equal(t, uint16(0x202), restored.fetcher.GetCounter())
+	assert.Equal(t, uint8(9), restored.delayTimer.GetValue())
+	assert.Equal(t, c.memory, restored.memory)
+}
+
+func TestReadSnapshotValidatesHeader(t *testing.T) {
+	_, err := ReadSnapshot(bytes.NewReader([]byte("NOPE\x00\x01")))
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	_, err = ReadSnapshot(bytes.NewReader([]byte("CH8S\x00\x63")))
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	assert.ErrorContains(t, err, "unsupported version 99")
+}
+
+func TestRestoreOtherPlatform(t *testing.T) {
+	c := NewChip8(slog.Default())
+	s := c.Snapshot()
+
+	c.SetPlatform(PlatformXOCHIP)
+	assert.ErrorIs(t, c.Restore(s), ErrInvalidSnapshot)
+}
+
+func TestSnapshotRestoresRandom(t *testing.T) {
+	// RND V0,0xff; JUMP 0x0200
+	rom := []byte{0xc0, 0xff, 0x12, 0x00}
+
+	interpreter := make([]byte, vipInterpreterBytes)
+	for j := range interpreter {
+		interpreter[j] = byte(j * 13)
+	}
+	vip, err := NewVIPRandom(interpreter, 0x4321)
+	assert.NoError(t, err)
+
+	for name, random := range map[string]RandomSource{"pcg": NewRandom(5), "vip": vip} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := newTestChip8(t, rom...)
+			c.SetRandom(random)
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, c.Update())
+			}
+
+			var buf bytes.Buffer
+			_, err := c.Snapshot().WriteTo(&buf)
+			assert.NoError(t, err)
+
+			var expected []byte
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, c.Update())
+				expected = append(expected, c.registers[0])
+			}
+
+			s, err := ReadSnapshot(&buf)
+			assert.NoError(t, err)
+			assert.NoError(t, c.Restore(s))
+			assert.Equal(t, uint64(10), c.Frames())
+			assert.Equal(t, uint64(10), c.cycles)
+
+			var actual []byte
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, c.Update())
+				actual = append(actual, c.registers[0])
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestRestoreOtherRandomSource(t *testing.T) {
+	c := NewChip8(slog.Default())
+	s := c.Snapshot()
+
+	vip, err := NewVIPRandom(make([]byte, vipInterpreterBytes), 0)
+	assert.NoError(t, err)
+	c.SetRandom(vip)
+	assert.ErrorIs(t, c.Restore(s), ErrInvalidSnapshot)
+}
+
+func TestReadSnapshotSections(t *testing.T) {
+	c, _ := newTestChip8(t, 0x60, 0x2a)
+	assert.NoError(t, c.Update())
+	s := c.Snapshot()
+
+	// a future version appending a CPU field and a section, without the audio one
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	buf.Write([]byte{0, snapshotVersion})
+	writeSection(&buf, sectionMemory, s.memory)
+	writeSection(&buf, "NEW!", []byte{1, 2, 3})
+	writeSection(&buf, sectionCPU, append(structBytes(&s.cpu), 0xff, 0xff))
+	writeSection(&buf, sectionScreen, structBytes(&s.screen))
+
+	read, err := ReadSnapshot(&buf)
+	assert.NoError(t, err)
+	assert.Nil(t, read.quirks)
+	assert.Equal(t, s.cpu, read.cpu)
+	assert.Equal(t, uint8(defaultPitch), read.audio.Pitch)
+
+	restored := NewChip8(slog.Default())
+	restored.SetQuirks(QuirksSuperCHIP)
+	assert.NoError(t, restored.Restore(read))
+	assert.Equal(t, byte(0x2a), restored.registers[0])
+
+	// an older version without the counters at the end of the CPU section
+	buf.Reset()
+	buf.WriteString(snapshotMagic)
+	buf.Write([]byte{0, snapshotVersion})
+	writeSection(&buf, sectionMemory, s.memory)
+	writeSection(&buf, sectionCPU, structBytes(&s.cpu)[:binary.Size(s.cpu)-20])
+
+	read, err = ReadSnapshot(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x202), read.cpu.Counter)
+	assert.Zero(t, read.cpu.Frames)
+
+	buf.Reset()
+	buf.WriteString(snapshotMagic)
+	buf.Write([]byte{0, snapshotVersion})
+	writeSection(&buf, sectionCPU, structBytes(&s.cpu))
+	_, err = ReadSnapshot(&buf)
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+}
+
+func TestRestoreOtherQuirks(t *testing.T) {
+	c := NewChip8(slog.Default())
+	c.SetPlatform(PlatformSuperCHIP)
+	s := c.Snapshot()
+
+	c.SetPlatform(PlatformCHIP48)
+	assert.ErrorIs(t, c.Restore(s), ErrInvalidSnapshot)
+
+	c.SetQuirks(QuirksSuperCHIP)
+	assert.NoError(t, c.Restore(s))
+}
@@ -19,16 +19,23 @@ func NewStack() *Stack {
 	}
 }
 
-func (s *Stack) Push(value uint16) {
-	// TODO: check stack overflow
+func (s *Stack) Push(value uint16) error {
+	if s.pointer >= stackLevels {
+		return ErrStackOverflow
+	}
+
 	s.data[s.pointer] = value
 	s.pointer++
+	return nil
 }
 
-func (s *Stack) Pop() uint16 {
-	// TODO: check stack pointer won't be < 0
+func (s *Stack) Pop() (uint16, error) {
+	if s.pointer == 0 {
+		return 0, ErrStackUnderflow
+	}
+
 	s.pointer--
-	return s.data[s.pointer]
+	return s.data[s.pointer], nil
 }
 
 func (s *Stack) String() string {
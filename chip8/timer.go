@@ -4,26 +4,13 @@ import (
 	"fmt"
 )
 
-const timerRateHz = 60
-
+// Timer counts down to 0 at 60 Hz, one step per frame
 type Timer struct {
-	rate      uint
-	tps       uint
-	count     float64
-	increment float64
-	value     uint8
-}
-
-// NewTimer create timer that counts down at a given hertz rate
-func NewTimer(tps uint) *Timer {
-	timer := &Timer{rate: timerRateHz}
-	timer.SetTPS(tps)
-	return timer
+	value uint8
 }
 
-func (t *Timer) SetTPS(tps uint) {
-	t.tps = tps
-	t.increment = float64(t.rate) / float64(t.tps)
+func NewTimer() *Timer {
+	return &Timer{}
 }
 
 func (t *Timer) GetValue() uint8 {
@@ -32,20 +19,13 @@ func (t *Timer) GetValue() uint8 {
 
 func (t *Timer) SetValue(value uint8) {
 	t.value = value
-	t.count = 0
 }
 
+// Update counts down one step, it must be called once per frame
 func (t *Timer) Update() {
-	if t.value == 0 {
-		return
-	}
-
-	for t.count >= 1 && t.value > 0 {
+	if t.value > 0 {
 		t.value--
-		t.count--
 	}
-
-	t.count += t.increment
 }
 
 func (t *Timer) String() string {
@@ -0,0 +1,164 @@
+package chip8
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// TraceFormat selects how a Tracer writes records
+type TraceFormat uint8
+
+const (
+	// TraceText writes one fixed width line per instruction:
+	//
+	//	cycle frame PC opcode mnemonic V:V0...VF I:index SP:depth DT:delay ST:sound
+	TraceText TraceFormat = iota
+	// TraceJSONL writes one JSON object per line, with the TraceRecord fields as numbers
+	TraceJSONL
+)
+
+var traceFormats = map[string]TraceFormat{
+	"text":  TraceText,
+	"jsonl": TraceJSONL,
+}
+
+// TraceFormatByName returns the format named text or jsonl
+func TraceFormatByName(name string) (TraceFormat, error) {
+	format, ok := traceFormats[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown trace format %q, expected one of: %s", name, strings.Join(TraceFormatNames(), ", "))
+	}
+
+	return format, nil
+}
+
+// TraceFormatNames returns the trace format names in alphabetical order
+func TraceFormatNames() []string {
+	return slices.Sorted(maps.Keys(traceFormats))
+}
+
+// TraceRecord is the machine state right before an instruction executes
+type TraceRecord struct {
+	Cycle       uint64
+	Frame       uint64
+	PC          uint16
+	Opcode      uint16
+	Instruction Instruction
+	Registers   Registers
+	Index       uint16
+	SP          uint8
+	DT          uint8
+	ST          uint8
+}
+
+// TraceSink receives a record before each instruction, see SetTrace. The record is only valid during the call
+type TraceSink interface {
+	Trace(r *TraceRecord)
+}
+
+// SetTrace sends a record to sink before each instruction executes, nil stops tracing
+func (c *Chip8) SetTrace(sink TraceSink) {
+	c.trace = sink
+}
+
+// traceJSON is the JSONL form of a TraceRecord
+type traceJSON struct {
+	Cycle    uint64    `json:"cycle"`
+	Frame    uint64    `json:"frame"`
+	PC       uint16    `json:"pc"`
+	Opcode   uint16    `json:"opcode"`
+	Mnemonic string    `json:"mnemonic"`
+	V        Registers `json:"v"`
+	I        uint16    `json:"i"`
+	SP       uint8     `json:"sp"`
+	DT       uint8     `json:"dt"`
+	ST       uint8     `json:"st"`
+}
+
+// Tracer is a TraceSink writing records in a TraceFormat, optionally only those in an address range or matching
+// opcode patterns. Writes are buffered: Flush once done
+type Tracer struct {
+	w        *bufio.Writer
+	format   TraceFormat
+	from, to uint16
+	patterns []opcodePattern
+	err      error
+}
+
+// NewTracer creates a tracer recording every instruction
+func NewTracer(w io.Writer, format TraceFormat) *Tracer {
+	return &Tracer{w: bufio.NewWriter(w), format: format, to: 0xFFFF}
+}
+
+// SetAddressRange records only instructions at addresses from to to, both included
+func (t *Tracer) SetAddressRange(from, to uint16) {
+	t.from, t.to = from, to
+}
+
+// AddOpcodeFilter records only opcodes matching one of the patterns added, see Debugger.SetOpcodeBreakpoint
+func (t *Tracer) AddOpcodeFilter(pattern string) error {
+	p, err := parseOpcodePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	t.patterns = append(t.patterns, p)
+	return nil
+}
+
+func (t *Tracer) Trace(r *TraceRecord) {
+	if t.err != nil || r.PC < t.from || r.PC > t.to || !t.matches(r.Opcode) {
+		return
+	}
+
+	if t.format == TraceJSONL {
+		data, err := json.Marshal(traceJSON{
+			Cycle:    r.Cycle,
+			Frame:    r.Frame,
+			PC:       r.PC,
+			Opcode:   r.Opcode,
+			Mnemonic: r.Instruction.String(),
+			V:        r.Registers,
+			I:        r.Index,
+			SP:       r.SP,
+			DT:       r.DT,
+			ST:       r.ST,
+		})
+		if err == nil {
+			_, err = t.w.Write(append(data, '\n'))
+		}
+		t.err = err
+		return
+	}
+
+	_, t.err = fmt.Fprintf(
+		t.w, "%8d %6d %04x %04x %-24s V:%x I:%04x SP:%x DT:%02x ST:%02x\n",
+		r.Cycle, r.Frame, r.PC, r.Opcode, r.Instruction, r.Registers[:], r.Index, r.SP, r.DT, r.ST,
+	)
+}
+
+func (t *Tracer) matches(opcode uint16) bool {
+	if len(t.patterns) == 0 {
+		return true
+	}
+
+	for _, p := range t.patterns {
+		if p.matches(opcode) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush writes the buffered records, returning the first write error
+func (t *Tracer) Flush() error {
+	if t.err != nil {
+		return t.err
+	}
+	return t.w.Flush()
+}
@@ -0,0 +1,80 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// traceROM sets V0, points I at the font and draws
+//
+//	0200: LOAD V0,2a
+//	0202: LOAD I,0x0050
+//	0204: DRAW V0,V0,5
+//	0206: JUMP 0x0206
+var traceROM = []byte{0x60, 0x2a, 0xa0, 0x50, 0xd0, 0x05, 0x12, 0x06}
+
+func traceLines(t *testing.T, tracer *Tracer, buf *bytes.Buffer, cycles int) []string {
+	t.Helper()
+
+	c, _ := newTestChip8(t, traceROM...)
+	c.SetTrace(tracer)
+	for j := 0; j < cycles; j++ {
+		require.NoError(t, c.Cycle())
+	}
+	require.NoError(t, tracer.Flush())
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestTraceText(t *testing.T) {
+	var buf bytes.Buffer
+	lines := traceLines(t, NewTracer(&buf, TraceText), &buf, 3)
+
+	assert.Equal(t, []string{
+		"       0      0 0200 602a LOAD V0,2a               V:00000000000000000000000000000000 I:0000 SP:0 DT:00 ST:00",
+		"       1      0 0202 a050 LOAD I,0x0050            V:2a000000000000000000000000000000 I:0000 SP:0 DT:00 ST:00",
+		"       2      0 0204 d005 DRAW V0,V0,5             V:2a000000000000000000000000000000 I:0050 SP:0 DT:00 ST:00",
+	}, lines)
+}
+
+func TestTraceJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	lines := traceLines(t, NewTracer(&buf, TraceJSONL), &buf, 2)
+	require.Len(t, lines, 2)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, float64(1), record["cycle"])
+	assert.Equal(t, float64(0x202), record["pc"])
+	assert.Equal(t, float64(0xa050), record["opcode"])
+	assert.Equal(t, "LOAD I,0x0050", record["mnemonic"])
+	assert.Equal(t, float64(0x2a), record["v"].([]any)[0])
+}
+
+func TestTraceFilters(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf, TraceText)
+	tracer.SetAddressRange(0x202, 0x206)
+	require.NoError(t, tracer.AddOpcodeFilter("DXYN"))
+	require.NoError(t, tracer.AddOpcodeFilter("1NNN"))
+	assert.ErrorIs(t, tracer.AddOpcodeFilter("DXYZ"), ErrInvalidOpcodePattern)
+
+	lines := traceLines(t, tracer, &buf, 5)
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "0204 d005 DRAW")
+	assert.Contains(t, lines[1], "0206 1206 JUMP")
+}
+
+func TestTraceFormatByName(t *testing.T) {
+	format, err := TraceFormatByName("jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, TraceJSONL, format)
+
+	_, err = TraceFormatByName("xml")
+	assert.ErrorContains(t, err, "text")
+}
@@ -0,0 +1,184 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidWatchpoint = errors.New("invalid watchpoint")
+
+// Watchpoint pauses the debugger when its condition becomes true or, for "changed" conditions, when the
+// watched value changes. Conditions compare a location with a number or watch it change:
+//
+//	V3 == 0x10
+//	I >= 0x300
+//	mem[I] changed
+//	mem[0x3a0] != 0
+//	mem[I+2] < 8
+//
+// Locations are V0-VF, I, PC, SP, DT, ST and mem[address], the address being a number, I or I+number
+type Watchpoint struct {
+	ID        int
+	Condition string
+}
+
+type locationKind uint8
+
+const (
+	locationRegister locationKind = iota
+	locationIndex
+	locationPC
+	locationSP
+	locationDT
+	locationST
+	locationMemory
+)
+
+type location struct {
+	kind     locationKind
+	register uint8
+	address  uint16
+	indexed  bool // memory at I plus address
+}
+
+// addressOf returns the memory address a memory location refers to right now
+func (l location) addressOf(c *Chip8) uint16 {
+	if l.indexed {
+		return c.index + l.address
+	}
+	return l.address
+}
+
+func (l location) read(c *Chip8, address uint16) int {
+	switch l.kind {
+	case locationRegister:
+		return int(c.registers[l.register])
+	case locationIndex:
+		return int(c.index)
+	case locationPC:
+		return int(c.fetcher.GetCounter())
+	case locationSP:
+		return int(c.stack.pointer)
+	case locationDT:
+		return int(c.delayTimer.GetValue())
+	case locationST:
+		return int(c.soundTimer.GetValue())
+	}
+
+	value, _ := c.memory.ReadByteAt(address)
+	return int(value)
+}
+
+var comparisons = map[string]func(a, b int) bool{
+	"==": func(a, b int) bool { return a == b },
+	"!=": func(a, b int) bool { return a != b },
+	"<":  func(a, b int) bool { return a < b },
+	"<=": func(a, b int) bool { return a <= b },
+	">":  func(a, b int) bool { return a > b },
+	">=": func(a, b int) bool { return a >= b },
+}
+
+type watchpoint struct {
+	Watchpoint
+	location location
+	compare  func(a, b int) bool // nil for changed
+	value    int
+
+	// state before the instruction being executed
+	address uint16
+	before  int
+	held    bool
+}
+
+func parseWatchpoint(id int, condition string) (*watchpoint, error) {
+	fields := strings.Fields(condition)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("%w: %q, expected <location> changed or <location> <comparison> <value>", ErrInvalidWatchpoint, condition)
+	}
+
+	l, err := parseLocation(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watchpoint{Watchpoint: Watchpoint{ID: id, Condition: condition}, location: l}
+	switch {
+	case len(fields) == 2 && fields[1] == "changed":
+		return w, nil
+	case len(fields) == 3:
+		compare, ok := comparisons[fields[1]]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown comparison %q", ErrInvalidWatchpoint, fields[1])
+		}
+		value, err := strconv.ParseUint(fields[2], 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid value %q", ErrInvalidWatchpoint, fields[2])
+		}
+		w.compare, w.value = compare, int(value)
+		return w, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q, expected <location> changed or <location> <comparison> <value>", ErrInvalidWatchpoint, condition)
+}
+
+func parseLocation(text string) (location, error) {
+	upper := strings.ToUpper(text)
+	switch upper {
+	case "I":
+		return location{kind: locationIndex}, nil
+	case "PC":
+		return location{kind: locationPC}, nil
+	case "SP":
+		return location{kind: locationSP}, nil
+	case "DT":
+		return location{kind: locationDT}, nil
+	case "ST":
+		return location{kind: locationST}, nil
+	}
+
+	if len(upper) == 2 && upper[0] == 'V' {
+		if r, err := strconv.ParseUint(upper[1:], 16, 4); err == nil {
+			return location{kind: locationRegister, register: uint8(r)}, nil
+		}
+	}
+
+	if inner, ok := strings.CutPrefix(upper, "MEM["); ok {
+		if inner, ok = strings.CutSuffix(inner, "]"); ok {
+			l := location{kind: locationMemory}
+			if offset, ok := strings.CutPrefix(inner, "I"); ok {
+				l.indexed, inner = true, strings.TrimPrefix(offset, "+")
+				if inner == "" {
+					return l, nil
+				}
+			}
+			if address, err := strconv.ParseUint(strings.ToLower(inner), 0, 16); err == nil {
+				l.address = uint16(address)
+				return l, nil
+			}
+		}
+	}
+
+	return location{}, fmt.Errorf("%w: unknown location %q", ErrInvalidWatchpoint, text)
+}
+
+// arm records the watched value before an instruction executes
+func (w *watchpoint) arm(c *Chip8) {
+	w.address = w.location.addressOf(c)
+	w.before = w.location.read(c, w.address)
+	if w.compare != nil {
+		w.held = w.compare(w.before, w.value)
+	}
+}
+
+// triggered returns true if the instruction executed since arm changed the watched value or made the condition
+// true, false otherwise. Memory is read at the address the location referred to before the instruction
+func (w *watchpoint) triggered(c *Chip8) bool {
+	if w.compare == nil {
+		return w.location.read(c, w.address) != w.before
+	}
+
+	now := w.compare(w.location.read(c, w.location.addressOf(c)), w.value)
+	return now && !w.held
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/dap"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// dapCommand serves the Debug Adapter Protocol on stdin and stdout, as editors run debug adapters, or on a TCP
+// address. A ROM given on the command line is the machine attach requests debug
+func dapCommand(args []string) int {
+	var (
+		machine machineFlags
+		address string
+	)
+
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	machine.register(flags)
+	flags.StringVar(&address, "listen", "", "TCP address to listen on, like localhost:4711, instead of stdin and stdout")
+	_ = flags.Parse(args)
+
+	// stdout carries the protocol, logs go to stderr
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	launch := func(program *dap.Program, args dap.LaunchArguments) (*chip8.Chip8, error) {
+		m := machine
+		if args.IPF > 0 {
+			m.ipf = args.IPF
+		}
+		if args.Platform != "" {
+			m.platform = args.Platform
+		}
+		if args.Quirks != "" {
+			m.quirks = args.Quirks
+		}
+		if args.Seed != nil {
+			m.seed = seedFlag{value: *args.Seed, set: true}
+		}
+		if args.Random != "" {
+			m.random = args.Random
+		}
+
+		emulator, err := m.machine(log)
+		if err != nil {
+			return nil, err
+		}
+		return emulator, emulator.LoadROM(bytes.NewReader(program.ROM))
+	}
+
+	server := dap.NewServer(launch, log)
+	if flags.NArg() > 0 {
+		program, err := dap.Load(flags.Arg(0))
+		if err != nil {
+			log.Error("Failed to load ROM file", slog.String("error", err.Error()))
+			return 1
+		}
+		emulator, err := launch(program, dap.LaunchArguments{})
+		if err != nil {
+			log.Error(err.Error())
+			return 1
+		}
+		server.SetAttachTarget(program, emulator)
+	}
+
+	var err error
+	if address != "" {
+		err = server.ListenAndServe(address)
+	} else {
+		err = server.Serve(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout})
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,613 @@
+package dap
+
+import (
+	"chip8/chip8"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// variable references of the scopes, the same for every frame since CHIP-8 has no locals
+const (
+	registersReference = iota + 1
+	stackReference
+	memoryReference
+)
+
+const memoryRowBytes = 16
+
+type handler func(s *session, arguments json.RawMessage) (any, error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                (*session).initialize,
+		"launch":                    (*session).launchProgram,
+		"attach":                    (*session).attachProgram,
+		"setBreakpoints":            (*session).setBreakpoints,
+		"setInstructionBreakpoints": (*session).setInstructionBreakpoints,
+		"setExceptionBreakpoints":   (*session).setExceptionBreakpoints,
+		"configurationDone":         (*session).configurationDone,
+		"threads":                   (*session).threads,
+		"stackTrace":                (*session).stackTrace,
+		"scopes":                    (*session).scopes,
+		"variables":                 (*session).variables,
+		"setVariable":               (*session).setVariable,
+		"continue":                  (*session).continueProgram,
+		"next":                      stepHandler((*chip8.Debugger).StepOver),
+		"stepIn":                    stepHandler((*chip8.Debugger).Step),
+		"stepOut":                   stepHandler((*chip8.Debugger).StepOut),
+		"pause":                     (*session).pause,
+		"readMemory":                (*session).readMemory,
+		"writeMemory":               (*session).writeMemory,
+		"disassemble":               (*session).disassemble,
+		"terminate":                 (*session).terminate,
+		"disconnect":                (*session).disconnect,
+	}
+}
+
+// decode unmarshals request arguments, which may be missing
+func decode(arguments json.RawMessage, v any) error {
+	if len(arguments) == 0 {
+		return nil
+	}
+	return json.Unmarshal(arguments, v)
+}
+
+func (s *session) initialize(json.RawMessage) (any, error) {
+	return Capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsInstructionBreakpoints:   true,
+		SupportsReadMemoryRequest:        true,
+		SupportsWriteMemoryRequest:       true,
+		SupportsDisassembleRequest:       true,
+		SupportsSetVariable:              true,
+		SupportsTerminateRequest:         true,
+	}, nil
+}
+
+func (s *session) launchProgram(arguments json.RawMessage) (any, error) {
+	var args LaunchArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Program == "" {
+		return nil, errors.New("missing program in the launch configuration")
+	}
+
+	program, err := Load(args.Program)
+	if err != nil {
+		return nil, err
+	}
+
+	emulator, err := s.launch(program, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.target, s.stopOnEntry = newTarget(program, emulator), args.StopOnEntry
+	s.emit("initialized", nil)
+	return nil, nil
+}
+
+func (s *session) attachProgram(arguments json.RawMessage) (any, error) {
+	var args struct {
+		StopOnEntry bool `json:"stopOnEntry"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if s.attach == nil {
+		return nil, ErrNoAttachTarget
+	}
+
+	s.target, s.stopOnEntry = s.attach, args.StopOnEntry
+	s.emit("initialized", nil)
+	return nil, nil
+}
+
+func (s *session) setBreakpoints(arguments json.RawMessage) (any, error) {
+	var args struct {
+		Source      Source             `json:"source"`
+		Breakpoints []SourceBreakpoint `json:"breakpoints"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if s.target == nil {
+		return nil, ErrNoProgram
+	}
+
+	program := s.target.program
+	ours := program.HasSource() && samePath(args.Source.Path, program.Path)
+
+	breakpoints := make([]Breakpoint, len(args.Breakpoints))
+	addresses := map[uint16]bool{}
+	for j, b := range args.Breakpoints {
+		address, line, ok := program.Address(b.Line)
+		if !ours || !ok {
+			breakpoints[j] = Breakpoint{Line: b.Line, Message: "no code at this line"}
+			continue
+		}
+
+		addresses[address] = true
+		breakpoints[j] = Breakpoint{
+			Verified:             true,
+			Source:               &args.Source,
+			Line:                 line,
+			InstructionReference: reference(address),
+		}
+	}
+
+	if ours {
+		s.target.sourceBreakpoints = addresses
+		s.target.syncBreakpoints()
+	}
+
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+func (s *session) setInstructionBreakpoints(arguments json.RawMessage) (any, error) {
+	var args struct {
+		Breakpoints []InstructionBreakpoint `json:"breakpoints"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if s.target == nil {
+		return nil, ErrNoProgram
+	}
+
+	breakpoints := make([]Breakpoint, len(args.Breakpoints))
+	addresses := map[uint16]bool{}
+	for j, b := range args.Breakpoints {
+		base, err := parseReference(b.InstructionReference)
+		address := base + b.Offset
+		if err != nil || address < 0 || address > 0xFFFF {
+			breakpoints[j] = Breakpoint{Message: fmt.Sprintf("invalid address %q", b.InstructionReference)}
+			continue
+		}
+
+		addresses[uint16(address)] = true
+		breakpoints[j] = Breakpoint{Verified: true, InstructionReference: reference(uint16(address))}
+		if line, ok := s.target.line(uint16(address)); ok {
+			breakpoints[j].Source, breakpoints[j].Line = s.target.source(), line
+		}
+	}
+
+	s.target.instructionBreakpoints = addresses
+	s.target.syncBreakpoints()
+
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+func (s *session) setExceptionBreakpoints(json.RawMessage) (any, error) {
+	return map[string]any{"breakpoints": []Breakpoint{}}, nil
+}
+
+// configurationDone starts the program, unless it must stop on entry
+func (s *session) configurationDone(json.RawMessage) (any, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	if s.stopOnEntry {
+		s.emit("stopped", StoppedEvent{Reason: "entry", ThreadID: threadID, AllThreadsStopped: true})
+	} else {
+		s.target.debugger.Continue()
+		s.running = true
+	}
+
+	return nil, nil
+}
+
+func (s *session) threads(json.RawMessage) (any, error) {
+	return map[string]any{"threads": []Thread{{ID: threadID, Name: "CHIP-8"}}}, nil
+}
+
+// stackTrace returns the frame at PC followed by the CALL of every return address on the stack
+func (s *session) stackTrace(arguments json.RawMessage) (any, error) {
+	var args struct {
+		StartFrame int `json:"startFrame"`
+		Levels     int `json:"levels"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	e := s.target.emulator
+	addresses := []uint16{e.PC()}
+	stack := e.Stack()
+	for j := len(stack) - 1; j >= 0; j-- {
+		addresses = append(addresses, stack[j]-2)
+	}
+
+	frames := []StackFrame{}
+	for j := args.StartFrame; j < len(addresses) && (args.Levels <= 0 || j < args.StartFrame+args.Levels); j++ {
+		address := addresses[j]
+		frame := StackFrame{
+			ID:                          j,
+			Name:                        fmt.Sprintf("%04x %s", address, s.target.mnemonic(address)),
+			InstructionPointerReference: reference(address),
+		}
+		if line, ok := s.target.line(address); ok {
+			frame.Source, frame.Line, frame.Column = s.target.source(), line, 1
+		}
+		frames = append(frames, frame)
+	}
+
+	return map[string]any{"stackFrames": frames, "totalFrames": len(addresses)}, nil
+}
+
+func (s *session) scopes(json.RawMessage) (any, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	rows := (len(s.target.emulator.Memory()) + memoryRowBytes - 1) / memoryRowBytes
+	return map[string]any{"scopes": []Scope{
+		{Name: "Registers", VariablesReference: registersReference, NamedVariables: 21},
+		{Name: "Stack", VariablesReference: stackReference, IndexedVariables: len(s.target.emulator.Stack())},
+		{Name: "Memory", VariablesReference: memoryReference, IndexedVariables: rows, Expensive: true},
+	}}, nil
+}
+
+func (s *session) variables(arguments json.RawMessage) (any, error) {
+	var args struct {
+		VariablesReference int `json:"variablesReference"`
+		Start              int `json:"start"`
+		Count              int `json:"count"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	e := s.target.emulator
+	var variables []Variable
+	switch args.VariablesReference {
+	case registersReference:
+		for j, v := range e.Registers() {
+			variables = append(variables, Variable{Name: fmt.Sprintf("V%X", j), Value: fmt.Sprintf("0x%02x", v), Type: "uint8"})
+		}
+		variables = append(variables,
+			Variable{Name: "I", Value: reference(e.Index()), Type: "uint16", MemoryReference: reference(e.Index())},
+			Variable{Name: "PC", Value: reference(e.PC()), Type: "uint16", MemoryReference: reference(e.PC())},
+			Variable{Name: "SP", Value: fmt.Sprintf("%d", e.StackPointer()), Type: "uint8"},
+			Variable{Name: "DT", Value: fmt.Sprintf("0x%02x", e.DelayTimer()), Type: "uint8"},
+			Variable{Name: "ST", Value: fmt.Sprintf("0x%02x", e.SoundTimer()), Type: "uint8"},
+		)
+	case stackReference:
+		for j, address := range e.Stack() {
+			variables = append(variables, Variable{Name: strconv.Itoa(j), Value: reference(address), Type: "uint16", MemoryReference: reference(address)})
+		}
+	case memoryReference:
+		memory := e.Memory()
+		rows := (len(memory) + memoryRowBytes - 1) / memoryRowBytes
+		end := rows
+		if args.Count > 0 {
+			end = min(args.Start+args.Count, rows)
+		}
+		for row := max(args.Start, 0); row < end; row++ {
+			address := row * memoryRowBytes
+			data := memory[address:min(address+memoryRowBytes, len(memory))]
+			variables = append(variables, Variable{
+				Name:            reference(uint16(address)),
+				Value:           fmt.Sprintf("% x", []byte(data)),
+				MemoryReference: reference(uint16(address)),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+
+	return map[string]any{"variables": variables}, nil
+}
+
+// setVariable writes a register, values are numbers in Go syntax, like 42, 0x2a or 0b101010
+func (s *session) setVariable(arguments json.RawMessage) (any, error) {
+	var args struct {
+		VariablesReference int    `json:"variablesReference"`
+		Name               string `json:"name"`
+		Value              string `json:"value"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	if args.VariablesReference != registersReference {
+		return nil, errors.New("only registers can be set")
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(args.Value), 0, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", args.Value)
+	}
+
+	e := s.target.emulator
+	switch name := strings.ToUpper(args.Name); {
+	case name == "I":
+		e.SetIndex(uint16(value))
+	case name == "PC":
+		e.SetPC(uint16(value))
+	case name == "SP":
+		if value > 0xFF || e.SetStackPointer(uint8(value)) != nil {
+			return nil, fmt.Errorf("invalid stack pointer %q", args.Value)
+		}
+	case name == "DT" || name == "ST" || (len(name) == 2 && name[0] == 'V'):
+		if value > 0xFF {
+			return nil, fmt.Errorf("invalid value %q, registers are 8 bits", args.Value)
+		}
+		switch name {
+		case "DT":
+			e.SetDelayTimer(uint8(value))
+		case "ST":
+			e.SetSoundTimer(uint8(value))
+		default:
+			x, err := strconv.ParseUint(name[1:], 16, 4)
+			if err != nil {
+				return nil, fmt.Errorf("unknown register %q", args.Name)
+			}
+			e.SetRegister(uint8(x), uint8(value))
+		}
+	default:
+		return nil, fmt.Errorf("unknown register %q", args.Name)
+	}
+
+	return map[string]any{"value": args.Value}, nil
+}
+
+func (s *session) continueProgram(json.RawMessage) (any, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	s.target.debugger.Continue()
+	s.running = true
+	return map[string]any{"allThreadsContinued": true}, nil
+}
+
+// stepHandler runs a debugger step, which either stops right away or resumes until the step completes
+func stepHandler(step func(d *chip8.Debugger) error) handler {
+	return func(s *session, _ json.RawMessage) (any, error) {
+		if err := s.ready(); err != nil {
+			return nil, err
+		}
+
+		err := step(s.target.debugger)
+		switch {
+		case errors.Is(err, chip8.ErrExit):
+			s.exited()
+		case s.target.debugger.Paused():
+			s.stopped()
+		default:
+			s.running = true
+		}
+
+		return nil, nil
+	}
+}
+
+func (s *session) pause(json.RawMessage) (any, error) {
+	if s.target == nil {
+		return nil, ErrNoProgram
+	}
+
+	if s.running {
+		s.target.debugger.Pause()
+		s.stopped()
+	}
+	return nil, nil
+}
+
+func (s *session) readMemory(arguments json.RawMessage) (any, error) {
+	var args struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Count           int    `json:"count"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	base, err := parseReference(args.MemoryReference)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := s.target.emulator.Memory()
+	if args.Count < 0 {
+		return nil, fmt.Errorf("invalid count %d", args.Count)
+	}
+	start := base + args.Offset
+	if start < 0 || start >= len(memory) {
+		return nil, fmt.Errorf("address 0x%x outside memory", start)
+	}
+
+	end := start + min(args.Count, len(memory)-start)
+	return map[string]any{
+		"address":         reference(uint16(start)),
+		"data":            base64.StdEncoding.EncodeToString(memory[start:end]),
+		"unreadableBytes": args.Count - (end - start),
+	}, nil
+}
+
+func (s *session) writeMemory(arguments json.RawMessage) (any, error) {
+	var args struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Data            string `json:"data"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	base, err := parseReference(args.MemoryReference)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(args.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	address := base + args.Offset
+	if address < 0 || address > 0xFFFF {
+		return nil, fmt.Errorf("invalid address 0x%x", address)
+	}
+	dst, err := s.target.emulator.Memory().Slice(uint16(address), len(data))
+	if err != nil {
+		return nil, err
+	}
+	copy(dst, data)
+
+	return map[string]any{"bytesWritten": len(data)}, nil
+}
+
+// disassemble decodes instructionCount instructions, addresses outside memory being shown as ?? as long as some of
+// the instructions are in memory
+func (s *session) disassemble(arguments json.RawMessage) (any, error) {
+	var args struct {
+		MemoryReference   string `json:"memoryReference"`
+		Offset            int    `json:"offset"`
+		InstructionOffset int    `json:"instructionOffset"`
+		InstructionCount  int    `json:"instructionCount"`
+	}
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	base, err := parseReference(args.MemoryReference)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := s.target.emulator.Memory()
+	if args.InstructionCount < 0 || args.InstructionCount > len(memory)/2 {
+		return nil, fmt.Errorf("invalid instruction count %d", args.InstructionCount)
+	}
+	if args.Offset < -len(memory) || args.Offset > len(memory) ||
+		args.InstructionOffset < -len(memory) || args.InstructionOffset > len(memory) {
+		return nil, errors.New("offset outside memory")
+	}
+	start := base + args.Offset + 2*args.InstructionOffset
+	if args.InstructionCount > 0 && (start+2*args.InstructionCount <= 0 || start >= len(memory)) {
+		return nil, fmt.Errorf("address 0x%x outside memory", start)
+	}
+
+	instructions := make([]DisassembledInstruction, args.InstructionCount)
+	for j := range instructions {
+		address := start + 2*j
+		if address < 0 || address+1 >= len(memory) {
+			instructions[j] = DisassembledInstruction{Address: fmt.Sprintf("0x%04x", address), Instruction: "??"}
+			continue
+		}
+
+		instructions[j] = DisassembledInstruction{
+			Address:          reference(uint16(address)),
+			InstructionBytes: fmt.Sprintf("%02x %02x", memory[address], memory[address+1]),
+			Instruction:      s.target.mnemonic(uint16(address)),
+		}
+		if line, ok := s.target.line(uint16(address)); ok {
+			instructions[j].Location, instructions[j].Line = s.target.source(), line
+		}
+	}
+
+	return map[string]any{"instructions": instructions}, nil
+}
+
+func (s *session) terminate(json.RawMessage) (any, error) {
+	if s.target == nil {
+		return nil, ErrNoProgram
+	}
+
+	s.running = false
+	s.target.debugger.Pause()
+	s.emit("terminated", nil)
+	return nil, nil
+}
+
+func (s *session) disconnect(json.RawMessage) (any, error) {
+	s.done = true
+	return nil, nil
+}
+
+// syncBreakpoints sets the debugger breakpoints to the source and instruction ones
+func (t *target) syncBreakpoints() {
+	for _, address := range t.debugger.Breakpoints() {
+		t.debugger.ClearBreakpoint(address)
+	}
+	for address := range t.sourceBreakpoints {
+		t.debugger.SetBreakpoint(address)
+	}
+	for address := range t.instructionBreakpoints {
+		t.debugger.SetBreakpoint(address)
+	}
+}
+
+func (t *target) mnemonic(address uint16) string {
+	opcode, err := t.emulator.Memory().ReadWord(address)
+	if err != nil {
+		return "??"
+	}
+
+	instruction, ok := chip8.Decode(opcode)
+	if !ok {
+		return fmt.Sprintf("dw 0x%04x", opcode)
+	}
+	return instruction.String()
+}
+
+func (t *target) line(address uint16) (int, bool) {
+	if !t.program.HasSource() {
+		return 0, false
+	}
+	return t.program.Line(address)
+}
+
+func (t *target) source() *Source {
+	return &Source{Name: filepath.Base(t.program.Path), Path: t.program.Path}
+}
+
+// reference formats an address as the memory and instruction references of the protocol
+func reference(address uint16) string {
+	return fmt.Sprintf("0x%04x", address)
+}
+
+func parseReference(text string) (int, error) {
+	value, err := strconv.ParseUint(text, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory reference %q", text)
+	}
+	return int(value), nil
+}
+
+// samePath returns true if two paths name the same file, false otherwise
+func samePath(a, b string) bool {
+	absolute, err := filepath.Abs(a)
+	if err != nil {
+		return false
+	}
+	return filepath.Clean(absolute) == filepath.Clean(b)
+}
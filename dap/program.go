@@ -0,0 +1,99 @@
+package dap
+
+import (
+	"bytes"
+	"chip8/asm"
+	"chip8/octo"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Program is a ROM and, when it was built from Octo (.8o) or assembler (.asm) source, the addresses of the source
+// lines, so breakpoints can be set on lines and stack frames shown in the source
+type Program struct {
+	Path  string
+	ROM   []byte
+	lines map[int]uint16
+	// addresses are the line addresses in ascending order, to find the line of an address
+	addresses []lineAddress
+}
+
+type lineAddress struct {
+	address uint16
+	line    int
+}
+
+// Load reads a ROM, compiling it first if it's Octo or assembler source
+func Load(path string) (*Program, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(absolute)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Program{Path: absolute}
+	switch filepath.Ext(path) {
+	case ".8o":
+		p.ROM, p.lines, err = octo.CompileWithLines(bytes.NewReader(data))
+	case ".asm":
+		p.ROM, p.lines, err = asm.AssembleWithLines(bytes.NewReader(data))
+	default:
+		p.ROM = data
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for line, address := range p.lines {
+		p.addresses = append(p.addresses, lineAddress{address: address, line: line})
+	}
+	slices.SortFunc(p.addresses, func(a, b lineAddress) int {
+		if a.address != b.address {
+			return int(a.address) - int(b.address)
+		}
+		return a.line - b.line
+	})
+
+	return p, nil
+}
+
+// HasSource returns true if the program was built from source, false for plain ROMs
+func (p *Program) HasSource() bool {
+	return p.lines != nil
+}
+
+// Address returns the address of a source line or, if it emits nothing, of the next line that does, together
+// with that line. It returns false past the last line with code
+func (p *Program) Address(line int) (uint16, int, bool) {
+	best := 0
+	for l := range p.lines {
+		if l >= line && (best == 0 || l < best) {
+			best = l
+		}
+	}
+	if best == 0 {
+		return 0, 0, false
+	}
+
+	return p.lines[best], best, true
+}
+
+// Line returns the source line that emitted an address, false if it's before the first line or there is no source
+func (p *Program) Line(address uint16) (int, bool) {
+	j, found := slices.BinarySearchFunc(p.addresses, address, func(l lineAddress, address uint16) int {
+		return int(l.address) - int(address)
+	})
+	if found {
+		return p.addresses[j].line, true
+	}
+	if j == 0 {
+		return 0, false
+	}
+
+	return p.addresses[j-1].line, true
+}
@@ -0,0 +1,161 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+var ErrMissingLength = errors.New("missing Content-Length header")
+
+// message is the envelope of requests, responses and events
+type message struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+}
+
+type request struct {
+	message
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type response struct {
+	message
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type event struct {
+	message
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// readMessage reads a message framed by a Content-Length header
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, ErrMissingLength
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func writeMessage(w io.Writer, m any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+// Protocol types, only the fields the server uses
+
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsInstructionBreakpoints   bool `json:"supportsInstructionBreakpoints"`
+	SupportsReadMemoryRequest        bool `json:"supportsReadMemoryRequest"`
+	SupportsWriteMemoryRequest       bool `json:"supportsWriteMemoryRequest"`
+	SupportsDisassembleRequest       bool `json:"supportsDisassembleRequest"`
+	SupportsSetVariable              bool `json:"supportsSetVariable"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+// LaunchArguments are the launch configuration, zero values keep the server defaults
+type LaunchArguments struct {
+	Program     string  `json:"program"`
+	Platform    string  `json:"platform"`
+	IPF         int     `json:"ipf"`
+	Quirks      string  `json:"quirks"`
+	Seed        *uint64 `json:"seed"`
+	Random      string  `json:"random"`
+	StopOnEntry bool    `json:"stopOnEntry"`
+}
+
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+type SourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+type InstructionBreakpoint struct {
+	InstructionReference string `json:"instructionReference"`
+	Offset               int    `json:"offset,omitempty"`
+}
+
+type Breakpoint struct {
+	Verified             bool    `json:"verified"`
+	Message              string  `json:"message,omitempty"`
+	Source               *Source `json:"source,omitempty"`
+	Line                 int     `json:"line,omitempty"`
+	InstructionReference string  `json:"instructionReference,omitempty"`
+}
+
+type StoppedEvent struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	Text              string `json:"text,omitempty"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+}
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type StackFrame struct {
+	ID                          int     `json:"id"`
+	Name                        string  `json:"name"`
+	Source                      *Source `json:"source,omitempty"`
+	Line                        int     `json:"line"`
+	Column                      int     `json:"column"`
+	InstructionPointerReference string  `json:"instructionPointerReference,omitempty"`
+}
+
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	NamedVariables     int    `json:"namedVariables,omitempty"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	MemoryReference    string `json:"memoryReference,omitempty"`
+}
+
+type DisassembledInstruction struct {
+	Address          string  `json:"address"`
+	InstructionBytes string  `json:"instructionBytes,omitempty"`
+	Instruction      string  `json:"instruction"`
+	Location         *Source `json:"location,omitempty"`
+	Line             int     `json:"line,omitempty"`
+}
@@ -0,0 +1,291 @@
+// Package dap serves the Debug Adapter Protocol, so editors can launch ROMs and debug them with their own UI
+//
+// Launch configurations take a program, a ROM or an Octo (.8o) or assembler (.asm) source that is compiled
+// first, and optionally platform, ipf, quirks, seed and stopOnEntry. Breakpoints are set on source lines or on
+// instruction addresses. The Registers, Stack and Memory scopes show the machine state as variables
+package dap
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+)
+
+const (
+	framesPerSecond = 60
+	threadID        = 1
+)
+
+var (
+	ErrNoProgram      = errors.New("no program launched")
+	ErrNoAttachTarget = errors.New("no machine to attach to, start the server with a ROM")
+	ErrRunning        = errors.New("the program is running")
+	ErrExited         = errors.New("the program exited")
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+// Launcher builds a machine with a program loaded, zero launch arguments keeping the defaults
+type Launcher func(program *Program, args LaunchArguments) (*chip8.Chip8, error)
+
+// Server debugs machines for one client at a time. Launch requests build a new machine, attach requests debug
+// the one given to SetAttachTarget, which keeps its state between clients
+type Server struct {
+	log    *slog.Logger
+	launch Launcher
+	frame  time.Duration
+	attach *target
+}
+
+// target is a machine being debugged
+type target struct {
+	program                *Program
+	emulator               *chip8.Chip8
+	debugger               *chip8.Debugger
+	sourceBreakpoints      map[uint16]bool
+	instructionBreakpoints map[uint16]bool
+	exited                 bool
+}
+
+func newTarget(program *Program, emulator *chip8.Chip8) *target {
+	t := &target{
+		program:                program,
+		emulator:               emulator,
+		debugger:               chip8.NewDebugger(emulator),
+		sourceBreakpoints:      map[uint16]bool{},
+		instructionBreakpoints: map[uint16]bool{},
+	}
+	t.debugger.Pause()
+
+	return t
+}
+
+func NewServer(launch Launcher, log *slog.Logger) *Server {
+	return &Server{
+		log:    log,
+		launch: launch,
+		frame:  time.Second / framesPerSecond,
+	}
+}
+
+// SetAttachTarget makes a machine available to attach requests, it stays paused until a client continues it
+func (s *Server) SetAttachTarget(program *Program, emulator *chip8.Chip8) {
+	s.attach = newTarget(program, emulator)
+}
+
+// ListenAndServe accepts clients on a TCP address, like localhost:4711, one at a time
+func (s *Server) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	for {
+		s.log.Info("Waiting for an editor", slog.String("address", listener.Addr().String()))
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		s.log.Info("Editor connected", slog.String("client", conn.RemoteAddr().String()))
+		if err := s.Serve(conn); err != nil {
+			s.log.Error("Editor connection failed", slog.String("error", err.Error()))
+		}
+		_ = conn.Close()
+	}
+}
+
+// incoming is a request read from the client, or the error that ended the connection
+type incoming struct {
+	request request
+	err     error
+}
+
+func readRequests(r io.Reader, requests chan<- incoming, done <-chan struct{}) {
+	br := bufio.NewReader(r)
+	for {
+		var in incoming
+		data, err := readMessage(br)
+		if err == nil {
+			err = json.Unmarshal(data, &in.request)
+		}
+		in.err = err
+
+		select {
+		case requests <- in:
+		case <-done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// session is the state of one client connection
+type session struct {
+	*Server
+	w           io.Writer
+	seq         int
+	target      *target
+	running     bool
+	stopOnEntry bool
+	events      []event // sent after the response being prepared
+	done        bool
+}
+
+// Serve talks to a client until it disconnects
+func (s *Server) Serve(conn io.ReadWriter) error {
+	requests := make(chan incoming)
+	done := make(chan struct{})
+	defer close(done)
+	go readRequests(conn, requests, done)
+
+	ticker := time.NewTicker(s.frame)
+	defer ticker.Stop()
+
+	sess := &session{Server: s, w: conn}
+	defer sess.detach()
+
+	for !sess.done {
+		var tick <-chan time.Time
+		if sess.running {
+			tick = ticker.C
+		}
+
+		select {
+		case in := <-requests:
+			if errors.Is(in.err, io.EOF) {
+				return nil
+			}
+			if in.err != nil {
+				return in.err
+			}
+			if err := sess.handle(in.request); err != nil {
+				return err
+			}
+		case <-tick:
+			sess.update()
+			if err := sess.flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// detach leaves the attach target paused for the next client
+func (s *session) detach() {
+	if s.target != nil && !s.target.debugger.Paused() {
+		s.target.debugger.Pause()
+	}
+}
+
+func (s *session) handle(r request) error {
+	s.log.Debug("Editor request", slog.String("command", r.Command))
+
+	res := response{
+		message:    message{Type: "response"},
+		RequestSeq: r.Seq,
+		Command:    r.Command,
+		Success:    true,
+	}
+
+	handler, ok := handlers[r.Command]
+	if !ok {
+		res.Success, res.Message = false, fmt.Sprintf("%s: %s", ErrUnknownCommand, r.Command)
+	} else if body, err := handler(s, r.Arguments); err != nil {
+		res.Success, res.Message = false, err.Error()
+		s.events = nil
+	} else {
+		res.Body = body
+	}
+
+	s.seq++
+	res.Seq = s.seq
+	if err := writeMessage(s.w, res); err != nil {
+		return err
+	}
+
+	return s.flush()
+}
+
+// emit queues an event, sent after the current response
+func (s *session) emit(name string, body any) {
+	s.events = append(s.events, event{message: message{Type: "event"}, Event: name, Body: body})
+}
+
+func (s *session) flush() error {
+	for _, e := range s.events {
+		s.seq++
+		e.Seq = s.seq
+		if err := writeMessage(s.w, e); err != nil {
+			return err
+		}
+	}
+	s.events = s.events[:0]
+
+	return nil
+}
+
+// update runs a frame, reporting the program stopping or exiting
+func (s *session) update() {
+	err := s.target.emulator.Update()
+	switch {
+	case errors.Is(err, chip8.ErrExit):
+		s.exited()
+	case s.target.debugger.Paused():
+		s.stopped()
+	}
+}
+
+// stopped tells the client why the machine paused
+func (s *session) stopped() {
+	s.running = false
+
+	body := StoppedEvent{ThreadID: threadID, AllThreadsStopped: true}
+	stop, _ := s.target.debugger.Stopped()
+	switch stop.Reason {
+	case chip8.StopStep:
+		body.Reason = "step"
+	case chip8.StopBreakpoint:
+		body.Reason = "breakpoint"
+	case chip8.StopWatchpoint:
+		body.Reason = "data breakpoint"
+	case chip8.StopFault:
+		body.Reason, body.Description, body.Text = "exception", "Machine fault", stop.Err.Error()
+	default:
+		body.Reason = "pause"
+	}
+
+	s.emit("stopped", body)
+}
+
+func (s *session) exited() {
+	s.running = false
+	s.target.exited = true
+	s.emit("exited", map[string]int{"exitCode": 0})
+	s.emit("terminated", nil)
+}
+
+// ready returns an error unless the machine is paused and can be inspected or resumed
+func (s *session) ready() error {
+	switch {
+	case s.target == nil:
+		return ErrNoProgram
+	case s.target.exited:
+		return ErrExited
+	case s.running:
+		return ErrRunning
+	}
+
+	return nil
+}
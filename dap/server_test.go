@@ -0,0 +1,412 @@
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"chip8/chip8"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSource counts V0 up in a subroutine
+const testSource = `; counts V0 up
+start:
+    CALL count      ; 0200
+    JUMP start      ; 0202
+count:
+    ADD V0,1        ; 0204
+    RTS             ; 0206
+`
+
+func testLauncher(program *Program, args LaunchArguments) (*chip8.Chip8, error) {
+	c := chip8.NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if args.IPF > 0 {
+		c.SetIPF(uint(args.IPF))
+	}
+	if err := c.LoadFont(); err != nil {
+		return nil, err
+	}
+
+	return c, c.LoadROM(bytes.NewReader(program.ROM))
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// received is any message from the server
+type received struct {
+	Type       string          `json:"type"`
+	Event      string          `json:"event"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client is a minimal protocol client, keeping the events received while waiting for responses
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	r      *bufio.Reader
+	seq    int
+	events []received
+}
+
+func newTestClient(t *testing.T, s *Server) *client {
+	s.frame = time.Millisecond
+
+	server, conn := net.Pipe()
+	go func() {
+		_ = s.Serve(server)
+		_ = server.Close()
+	}()
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *client) receive() received {
+	c.t.Helper()
+
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	data, err := readMessage(c.r)
+	require.NoError(c.t, err)
+
+	var m received
+	require.NoError(c.t, json.Unmarshal(data, &m))
+	return m
+}
+
+// request sends a request and returns its response
+func (c *client) request(command string, arguments any) received {
+	c.t.Helper()
+
+	c.seq++
+	args, err := json.Marshal(arguments)
+	require.NoError(c.t, err)
+	require.NoError(c.t, writeMessage(c.conn, request{
+		message:   message{Seq: c.seq, Type: "request"},
+		Command:   command,
+		Arguments: args,
+	}))
+
+	for {
+		m := c.receive()
+		if m.Type == "event" {
+			c.events = append(c.events, m)
+			continue
+		}
+		require.Equal(c.t, c.seq, m.RequestSeq)
+		return m
+	}
+}
+
+// body sends a request that must succeed and decodes the response body
+func (c *client) body(command string, arguments any, body any) {
+	c.t.Helper()
+
+	res := c.request(command, arguments)
+	require.True(c.t, res.Success, res.Message)
+	if body != nil {
+		require.NoError(c.t, json.Unmarshal(res.Body, body))
+	}
+}
+
+// event returns the next event, which must have a name
+func (c *client) event(name string) received {
+	c.t.Helper()
+
+	var e received
+	if len(c.events) > 0 {
+		e, c.events = c.events[0], c.events[1:]
+	} else {
+		e = c.receive()
+	}
+	require.Equal(c.t, "event", e.Type)
+	require.Equal(c.t, name, e.Event)
+	return e
+}
+
+func (c *client) stopped(reason string) {
+	c.t.Helper()
+
+	var body StoppedEvent
+	require.NoError(c.t, json.Unmarshal(c.event("stopped").Body, &body))
+	assert.Equal(c.t, reason, body.Reason)
+}
+
+func (c *client) registers() map[string]string {
+	c.t.Helper()
+
+	var body struct{ Variables []Variable }
+	c.body("variables", map[string]int{"variablesReference": registersReference}, &body)
+
+	registers := map[string]string{}
+	for _, v := range body.Variables {
+		registers[v.Name] = v.Value
+	}
+	return registers
+}
+
+func (c *client) launch(arguments LaunchArguments) {
+	c.t.Helper()
+
+	var capabilities Capabilities
+	c.body("initialize", map[string]string{"adapterID": "chip8"}, &capabilities)
+	assert.True(c.t, capabilities.SupportsInstructionBreakpoints)
+
+	c.body("launch", arguments, nil)
+	c.event("initialized")
+}
+
+func TestSourceBreakpoints(t *testing.T) {
+	path := writeTestFile(t, "count.asm", testSource)
+	c := newTestClient(t, NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	c.launch(LaunchArguments{Program: path})
+
+	var breakpoints struct{ Breakpoints []Breakpoint }
+	c.body("setBreakpoints", map[string]any{
+		"source":      Source{Path: path},
+		"breakpoints": []SourceBreakpoint{{Line: 5}, {Line: 20}},
+	}, &breakpoints)
+	require.Len(t, breakpoints.Breakpoints, 2)
+	assert.True(t, breakpoints.Breakpoints[0].Verified)
+	assert.Equal(t, 6, breakpoints.Breakpoints[0].Line)
+	assert.Equal(t, "0x0204", breakpoints.Breakpoints[0].InstructionReference)
+	assert.False(t, breakpoints.Breakpoints[1].Verified)
+
+	c.body("configurationDone", nil, nil)
+	c.stopped("breakpoint")
+
+	var trace struct {
+		StackFrames []StackFrame
+		TotalFrames int
+	}
+	c.body("stackTrace", map[string]int{"threadId": threadID}, &trace)
+	require.Len(t, trace.StackFrames, 2)
+	assert.Equal(t, 2, trace.TotalFrames)
+	assert.Equal(t, "0204 ADD V0,1", trace.StackFrames[0].Name)
+	assert.Equal(t, 6, trace.StackFrames[0].Line)
+	assert.Equal(t, path, trace.StackFrames[0].Source.Path)
+	assert.Equal(t, "0x0200", trace.StackFrames[1].InstructionPointerReference)
+	assert.Equal(t, 3, trace.StackFrames[1].Line)
+
+	assert.Equal(t, "0x00", c.registers()["V0"])
+
+	c.body("continue", map[string]int{"threadId": threadID}, nil)
+	c.stopped("breakpoint")
+	assert.Equal(t, "0x01", c.registers()["V0"])
+
+	c.body("setBreakpoints", map[string]any{"source": Source{Path: path}, "breakpoints": []SourceBreakpoint{}}, nil)
+	c.body("disconnect", nil, nil)
+}
+
+func TestStepping(t *testing.T) {
+	path := writeTestFile(t, "count.asm", testSource)
+	c := newTestClient(t, NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	c.launch(LaunchArguments{Program: path, StopOnEntry: true})
+
+	c.body("configurationDone", nil, nil)
+	c.stopped("entry")
+	assert.Equal(t, "0x0200", c.registers()["PC"])
+
+	c.body("stepIn", map[string]int{"threadId": threadID}, nil)
+	c.stopped("step")
+	assert.Equal(t, "0x0204", c.registers()["PC"])
+
+	var scopes struct{ Scopes []Scope }
+	c.body("scopes", map[string]int{"frameId": 0}, &scopes)
+	require.Len(t, scopes.Scopes, 3)
+	assert.Equal(t, 1, scopes.Scopes[1].IndexedVariables)
+
+	var stack struct{ Variables []Variable }
+	c.body("variables", map[string]int{"variablesReference": stackReference}, &stack)
+	assert.Equal(t, []Variable{{Name: "0", Value: "0x0202", Type: "uint16", MemoryReference: "0x0202"}}, stack.Variables)
+
+	c.body("stepOut", map[string]int{"threadId": threadID}, nil)
+	c.stopped("step")
+	assert.Equal(t, "0x0202", c.registers()["PC"])
+
+	c.body("next", map[string]int{"threadId": threadID}, nil)
+	c.stopped("step")
+	c.body("next", map[string]int{"threadId": threadID}, nil)
+	c.stopped("step")
+	assert.Equal(t, "0x0202", c.registers()["PC"])
+	assert.Equal(t, "0x02", c.registers()["V0"])
+
+	c.body("continue", map[string]int{"threadId": threadID}, nil)
+	c.body("pause", map[string]int{"threadId": threadID}, nil)
+	c.stopped("pause")
+}
+
+func TestInstructionBreakpointsAndMemory(t *testing.T) {
+	path := writeTestFile(t, "count.asm", testSource)
+	c := newTestClient(t, NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	c.launch(LaunchArguments{Program: path, StopOnEntry: true})
+
+	var breakpoints struct{ Breakpoints []Breakpoint }
+	c.body("setInstructionBreakpoints", map[string]any{
+		"breakpoints": []InstructionBreakpoint{{InstructionReference: "0x0200", Offset: 6}, {InstructionReference: "zz"}},
+	}, &breakpoints)
+	assert.Equal(t, Breakpoint{Verified: true, InstructionReference: "0x0206", Source: &Source{Name: "count.asm", Path: path}, Line: 7}, breakpoints.Breakpoints[0])
+	assert.False(t, breakpoints.Breakpoints[1].Verified)
+
+	c.body("configurationDone", nil, nil)
+	c.stopped("entry")
+	c.body("continue", nil, nil)
+	c.stopped("breakpoint")
+	assert.Equal(t, "0x0206", c.registers()["PC"])
+
+	var memory struct {
+		Address string
+		Data    string
+	}
+	c.body("readMemory", map[string]any{"memoryReference": "0x0200", "offset": 2, "count": 2}, &memory)
+	assert.Equal(t, "0x0202", memory.Address)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x12, 0x00}), memory.Data)
+
+	c.body("writeMemory", map[string]any{"memoryReference": "0x0300", "data": base64.StdEncoding.EncodeToString([]byte{0xab, 0xcd})}, nil)
+	var rows struct{ Variables []Variable }
+	c.body("variables", map[string]int{"variablesReference": memoryReference, "start": 0x30, "count": 1}, &rows)
+	require.Len(t, rows.Variables, 1)
+	assert.Equal(t, "0x0300", rows.Variables[0].Name)
+	assert.Equal(t, "ab cd 00 00 00 00 00 00 00 00 00 00 00 00 00 00", rows.Variables[0].Value)
+
+	var disassembly struct{ Instructions []DisassembledInstruction }
+	c.body("disassemble", map[string]any{"memoryReference": "0x0204", "instructionOffset": -1, "instructionCount": 3}, &disassembly)
+	require.Len(t, disassembly.Instructions, 3)
+	assert.Equal(t, "JUMP 0x0200", disassembly.Instructions[0].Instruction)
+	assert.Equal(t, 4, disassembly.Instructions[0].Line)
+	assert.Equal(t, "70 01", disassembly.Instructions[1].InstructionBytes)
+	assert.Equal(t, "RTS", disassembly.Instructions[2].Instruction)
+
+	c.body("setVariable", map[string]any{"variablesReference": registersReference, "name": "V3", "value": "0x10"}, nil)
+	c.body("setVariable", map[string]any{"variablesReference": registersReference, "name": "I", "value": "768"}, nil)
+	assert.Equal(t, "0x10", c.registers()["V3"])
+	assert.Equal(t, "0x0300", c.registers()["I"])
+	assert.False(t, c.request("setVariable", map[string]any{"variablesReference": registersReference, "name": "V3", "value": "0x100"}).Success)
+	assert.False(t, c.request("setVariable", map[string]any{"variablesReference": registersReference, "name": "VZ", "value": "1"}).Success)
+}
+
+func TestInvalidMemoryRequests(t *testing.T) {
+	path := writeTestFile(t, "count.asm", testSource)
+	c := newTestClient(t, NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	c.launch(LaunchArguments{Program: path, StopOnEntry: true})
+	c.body("configurationDone", nil, nil)
+	c.stopped("entry")
+
+	for _, args := range []map[string]any{
+		{"memoryReference": "0x0200", "count": -1},
+		{"memoryReference": "0x0200", "offset": -0x201, "count": 2},
+		{"memoryReference": "0x0200", "offset": 0x10000, "count": 2},
+		{"memoryReference": "0x0200", "offset": math.MaxInt, "count": 2},
+	} {
+		assert.False(t, c.request("readMemory", args).Success, "%v", args)
+	}
+
+	var memory struct {
+		Data            string
+		UnreadableBytes int
+	}
+	c.body("readMemory", map[string]any{"memoryReference": "0x0ffe", "count": math.MaxInt}, &memory)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0, 0}), memory.Data)
+	assert.Equal(t, math.MaxInt-2, memory.UnreadableBytes)
+
+	for _, args := range []map[string]any{
+		{"memoryReference": "0x0200", "instructionCount": -1},
+		{"memoryReference": "0x0200", "instructionCount": math.MaxInt},
+		{"memoryReference": "0x0200", "instructionOffset": math.MinInt, "instructionCount": 1},
+		{"memoryReference": "0x0200", "offset": 0x1000, "instructionCount": 1},
+		{"memoryReference": "0x0000", "instructionOffset": -2, "instructionCount": 2},
+	} {
+		assert.False(t, c.request("disassemble", args).Success, "%v", args)
+	}
+
+	var disassembly struct{ Instructions []DisassembledInstruction }
+	c.body("disassemble", map[string]any{"memoryReference": "0x0000", "instructionOffset": -1, "instructionCount": 2}, &disassembly)
+	require.Len(t, disassembly.Instructions, 2)
+	assert.Equal(t, "??", disassembly.Instructions[0].Instruction)
+
+	c.body("threads", nil, nil)
+}
+
+func TestOctoSource(t *testing.T) {
+	path := writeTestFile(t, "count.8o", ": main\n  v0 += 1\n  jump main\n")
+	c := newTestClient(t, NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	c.launch(LaunchArguments{Program: path})
+
+	var breakpoints struct{ Breakpoints []Breakpoint }
+	c.body("setBreakpoints", map[string]any{"source": Source{Path: path}, "breakpoints": []SourceBreakpoint{{Line: 3}}}, &breakpoints)
+	assert.Equal(t, "0x0204", breakpoints.Breakpoints[0].InstructionReference)
+
+	c.body("setBreakpoints", map[string]any{"source": Source{Path: "other.8o"}, "breakpoints": []SourceBreakpoint{{Line: 3}}}, &breakpoints)
+	assert.False(t, breakpoints.Breakpoints[0].Verified)
+
+	c.body("configurationDone", nil, nil)
+	c.stopped("breakpoint")
+	assert.Equal(t, "0x0204", c.registers()["PC"])
+}
+
+func TestExitAndErrors(t *testing.T) {
+	path := writeTestFile(t, "exit.ch8", "\x00\xfd")
+	c := newTestClient(t, NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	assert.False(t, c.request("stackTrace", nil).Success)
+	assert.False(t, c.request("attach", nil).Success)
+	assert.False(t, c.request("launch", LaunchArguments{Program: "missing.ch8"}).Success)
+	res := c.request("evaluate", nil)
+	assert.False(t, res.Success)
+	assert.Contains(t, res.Message, "unknown command")
+
+	c.launch(LaunchArguments{Program: path})
+
+	var breakpoints struct{ Breakpoints []Breakpoint }
+	c.body("setBreakpoints", map[string]any{"source": Source{Path: path}, "breakpoints": []SourceBreakpoint{{Line: 1}}}, &breakpoints)
+	assert.False(t, breakpoints.Breakpoints[0].Verified)
+
+	c.body("configurationDone", nil, nil)
+	c.event("exited")
+	c.event("terminated")
+	assert.False(t, c.request("continue", nil).Success)
+}
+
+func TestAttach(t *testing.T) {
+	program, err := Load(writeTestFile(t, "count.asm", testSource))
+	require.NoError(t, err)
+	emulator, err := testLauncher(program, LaunchArguments{})
+	require.NoError(t, err)
+
+	s := NewServer(testLauncher, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetAttachTarget(program, emulator)
+
+	c := newTestClient(t, s)
+	c.body("initialize", nil, nil)
+	c.body("attach", map[string]bool{"stopOnEntry": true}, nil)
+	c.event("initialized")
+	c.body("configurationDone", nil, nil)
+	c.stopped("entry")
+	c.body("stepIn", nil, nil)
+	c.stopped("step")
+	c.body("disconnect", nil, nil)
+
+	c = newTestClient(t, s)
+	c.body("initialize", nil, nil)
+	c.body("attach", map[string]bool{"stopOnEntry": true}, nil)
+	c.event("initialized")
+	assert.Equal(t, "0x0204", c.registers()["PC"])
+}
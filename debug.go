@@ -0,0 +1,45 @@
+package main
+
+import (
+	"chip8/frontend/tui"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// debugCommand runs a ROM in the terminal debugger. Logs are discarded, they would tear the screen
+func debugCommand(args []string) int {
+	var (
+		machine     machineFlags
+		breakpoints string
+	)
+
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	machine.register(flags)
+	flags.StringVar(&breakpoints, "break", "", "comma separated breakpoint addresses, in hex")
+	machine.parse(flags, args)
+
+	emulator, err := machine.build(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ui := tui.New(emulator)
+	for _, address := range strings.FieldsFunc(breakpoints, func(r rune) bool { return r == ',' }) {
+		if err := ui.Execute("break " + address); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	if err := ui.Run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"chip8/disasm"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// disasmCommand prints the disassembly of a ROM to standard output, or to -o
+func disasmCommand(args []string) int {
+	var output string
+
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	flags.StringVar(&output, "o", "", "output path, standard output by default")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chip8 disasm [-o out.asm] rom.ch8")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	rom, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := os.Stdout
+	if output != "" {
+		if w, err = os.Create(output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	_, err = disasm.Disassemble(rom).WriteTo(w)
+	if output != "" {
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
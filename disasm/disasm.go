@@ -0,0 +1,362 @@
+// Package disasm turns CHIP-8 ROMs back into the mnemonics chip8.Instruction prints
+//
+// Code is told apart from data by following every jump, call and skip from the program start.
+// Anything never reached, like sprites or code only reached through BNNN, is rendered as db bytes
+package disasm
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Origin is the address ROMs are loaded at
+const Origin = 0x200
+
+const (
+	dataBytesPerLine = 8
+	mnemonicWidth    = 24
+)
+
+type byteKind uint8
+
+const (
+	kindData byteKind = iota
+	kindOpcode
+	kindOperand // any byte of an instruction but the first
+)
+
+// labelKind orders labels by priority, an address used in several ways is named after the highest one
+type labelKind uint8
+
+const (
+	labelData labelKind = iota
+	labelSprite
+	labelJump
+	labelCall
+)
+
+var labelPrefixes = map[labelKind]string{
+	labelData:   "data",
+	labelSprite: "sprite",
+	labelJump:   "label",
+	labelCall:   "sub",
+}
+
+// sprite is a data region drawn by DXYN
+type sprite struct {
+	bytes    int
+	rowBytes int
+}
+
+// Program is a disassembled ROM
+type Program struct {
+	rom     []byte
+	kinds   []byteKind
+	labels  map[uint16]labelKind
+	sprites map[uint16]sprite
+}
+
+// path is where the analysis continues, with the value of I if known
+type path struct {
+	address    uint16
+	index      uint16
+	indexKnown bool
+}
+
+// Disassemble analyses a ROM loaded at Origin
+func Disassemble(rom []byte) *Program {
+	p := &Program{
+		rom:     rom,
+		kinds:   make([]byteKind, len(rom)),
+		labels:  map[uint16]labelKind{},
+		sprites: map[uint16]sprite{},
+	}
+
+	queue := []path{{address: Origin}}
+	for len(queue) > 0 {
+		next := queue[len(queue)-1]
+		queue = p.walk(next, queue[:len(queue)-1])
+	}
+
+	// labels can only be written before a line, never inside an instruction
+	for address := range p.labels {
+		if p.kind(address) == kindOperand {
+			delete(p.labels, address)
+		}
+	}
+
+	return p
+}
+
+// walk decodes instructions from a path until the flow stops, returning the queue with any branches taken
+func (p *Program) walk(at path, queue []path) []path {
+	for {
+		offset := int(at.address) - Origin
+		if offset < 0 || offset+1 >= len(p.rom) || p.kinds[offset] != kindData || p.kinds[offset+1] != kindData {
+			return queue
+		}
+
+		instruction, ok := chip8.Decode(p.word(offset))
+		if !ok {
+			return queue
+		}
+
+		flow := chip8.FlowOf(instruction)
+		if offset+flow.Size > len(p.rom) {
+			return queue
+		}
+
+		p.kinds[offset] = kindOpcode
+		for j := 1; j < flow.Size; j++ {
+			p.kinds[offset+j] = kindOperand
+		}
+
+		if flow.LoadsIndex {
+			at.index, at.indexKnown = flow.Index, true
+			p.label(flow.Index, labelData)
+		}
+		if flow.SpriteBytes > 0 && at.indexKnown {
+			p.sprite(at.index, flow.SpriteBytes)
+		}
+
+		next := at.address + uint16(flow.Size)
+		switch flow.Kind {
+		case chip8.FlowNext:
+			at.address = next
+		case chip8.FlowSkip:
+			skipped := at
+			skipped.address = next + uint16(p.sizeAt(next))
+			queue = append(queue, skipped)
+			at.address = next
+		case chip8.FlowJump:
+			p.label(flow.Target, labelJump)
+			at.address = flow.Target
+		case chip8.FlowCall:
+			p.label(flow.Target, labelCall)
+			queue = append(queue, path{address: flow.Target})
+			at.address = next
+		default:
+			return queue
+		}
+	}
+}
+
+// WriteTo writes the program as source the assembler reads back, with addresses and raw bytes in comments
+func (p *Program) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	fmt.Fprintf(bw, "; %d bytes at 0x%04x\n", len(p.rom), Origin)
+
+	for offset := 0; offset < len(p.rom); {
+		address := uint16(offset + Origin)
+		if kind, ok := p.labels[address]; ok {
+			fmt.Fprintf(bw, "\n%s:\n", labelName(address, kind))
+		}
+
+		var n int
+		switch p.kinds[offset] {
+		case kindOpcode:
+			n = p.writeInstruction(bw, offset)
+		case kindOperand:
+			// only reached for the operand of an F000 NNNN, already written
+			n = 1
+		default:
+			n = p.writeData(bw, offset)
+		}
+		offset += n
+	}
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (p *Program) writeInstruction(w io.Writer, offset int) int {
+	opcode := p.word(offset)
+	instruction, _ := chip8.Decode(opcode)
+	flow := chip8.FlowOf(instruction)
+
+	if !Canonical(opcode) {
+		writeLine(w, fmt.Sprintf("dw 0x%04x", opcode), fmt.Sprintf("%04x: %s", offset+Origin, instruction))
+		return 2
+	}
+
+	mnemonic := instruction.String()
+	switch {
+	case flow.Kind == chip8.FlowJump || flow.Kind == chip8.FlowCall:
+		mnemonic = p.withLabel(mnemonic, flow.Target)
+	case flow.LoadsIndex:
+		mnemonic = p.withLabel(mnemonic, flow.Index)
+	}
+	writeLine(w, mnemonic, fmt.Sprintf("%04x: %04x", offset+Origin, opcode))
+
+	if flow.Size > 2 {
+		operand := p.word(offset + 2)
+		writeLine(w, fmt.Sprintf("dw 0x%04x", operand), fmt.Sprintf("%04x: %04x", offset+2+Origin, operand))
+	}
+
+	return flow.Size
+}
+
+// writeData writes a line of db bytes, one sprite row per line inside sprites
+func (p *Program) writeData(w io.Writer, offset int) int {
+	if s, ok := p.spriteAt(offset); ok {
+		row := p.rom[offset : offset+s.rowBytes]
+		writeLine(w, "db "+hexBytes(row), fmt.Sprintf("%04x: %s", offset+Origin, bitmap(row)))
+		return s.rowBytes
+	}
+
+	end := offset + 1
+	for end < len(p.rom) && end-offset < dataBytesPerLine && p.kinds[end] == kindData {
+		if _, ok := p.labels[uint16(end+Origin)]; ok {
+			break
+		}
+		if _, ok := p.spriteAt(end); ok {
+			break
+		}
+		end++
+	}
+
+	writeLine(w, "db "+hexBytes(p.rom[offset:end]), fmt.Sprintf("%04x", offset+Origin))
+	return end - offset
+}
+
+// spriteAt returns the sprite whose row starts at offset, if the whole row is data
+func (p *Program) spriteAt(offset int) (sprite, bool) {
+	for start, s := range p.sprites {
+		row := offset - (int(start) - Origin)
+		if row < 0 || row >= s.bytes || row%s.rowBytes != 0 {
+			continue
+		}
+		if offset+s.rowBytes > len(p.rom) {
+			return sprite{}, false
+		}
+		for j := offset; j < offset+s.rowBytes; j++ {
+			if p.kinds[j] != kindData {
+				return sprite{}, false
+			}
+		}
+		return s, true
+	}
+
+	return sprite{}, false
+}
+
+func (p *Program) label(address uint16, kind labelKind) {
+	if p.inROM(address) {
+		if current, ok := p.labels[address]; !ok || kind > current {
+			p.labels[address] = kind
+		}
+	}
+}
+
+func (p *Program) sprite(address uint16, bytes int) {
+	rowBytes := 1
+	if bytes == 32 {
+		rowBytes = 2
+	}
+
+	if current, ok := p.sprites[address]; !ok || bytes > current.bytes {
+		p.sprites[address] = sprite{bytes: bytes, rowBytes: rowBytes}
+	}
+	p.label(address, labelSprite)
+}
+
+// withLabel replaces the address operand of a mnemonic with its label
+func (p *Program) withLabel(mnemonic string, address uint16) string {
+	kind, ok := p.labels[address]
+	if !ok {
+		return mnemonic
+	}
+
+	return strings.Replace(mnemonic, fmt.Sprintf("0x%04x", address), labelName(address, kind), 1)
+}
+
+func (p *Program) inROM(address uint16) bool {
+	return int(address) >= Origin && int(address) < Origin+len(p.rom)
+}
+
+func (p *Program) kind(address uint16) byteKind {
+	if !p.inROM(address) {
+		return kindData
+	}
+	return p.kinds[int(address)-Origin]
+}
+
+func (p *Program) word(offset int) uint16 {
+	return uint16(p.rom[offset])<<8 | uint16(p.rom[offset+1])
+}
+
+// sizeAt returns the size of the instruction at address, so skips jump over F000 NNNN as a whole
+func (p *Program) sizeAt(address uint16) int {
+	offset := int(address) - Origin
+	if offset >= 0 && offset+1 < len(p.rom) && p.word(offset) == 0xF000 {
+		return 4
+	}
+	return 2
+}
+
+// canonicalOpcodes maps every mnemonic to the lowest opcode printing it, the one the assembler encodes it into
+var canonicalOpcodes = sync.OnceValue(func() map[string]uint16 {
+	opcodes := map[string]uint16{}
+	for opcode := 0xFFFF; opcode >= 0; opcode-- {
+		if instruction, ok := chip8.Decode(uint16(opcode)); ok {
+			opcodes[instruction.String()] = uint16(opcode)
+		}
+	}
+
+	return opcodes
+})
+
+// Canonical returns true if assembling the mnemonic of an opcode gives it back, false if the opcode is invalid or an
+// alternative encoding, like 0NNN opcodes with a non-zero X or 5XYN and 9XYN with an N the interpreter ignores
+func Canonical(opcode uint16) bool {
+	instruction, ok := chip8.Decode(opcode)
+	return ok && canonicalOpcodes()[instruction.String()] == opcode
+}
+
+func labelName(address uint16, kind labelKind) string {
+	return fmt.Sprintf("%s_%04x", labelPrefixes[kind], address)
+}
+
+func writeLine(w io.Writer, text, comment string) {
+	fmt.Fprintf(w, "    %-*s ; %s\n", mnemonicWidth, text, comment)
+}
+
+func hexBytes(data []byte) string {
+	hex := make([]string, len(data))
+	for j, b := range data {
+		hex[j] = fmt.Sprintf("0x%02x", b)
+	}
+	return strings.Join(hex, ", ")
+}
+
+// bitmap draws sprite bytes with # for set pixels and . for unset ones
+func bitmap(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		for j := 7; j >= 0; j-- {
+			if b>>j&1 == 1 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
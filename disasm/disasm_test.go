@@ -0,0 +1,133 @@
+package disasm
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func disassemble(t *testing.T, rom []byte) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	_, err := Disassemble(rom).WriteTo(&out)
+	require.NoError(t, err)
+	return out.String()
+}
+
+func TestDisassembleFollowsFlow(t *testing.T) {
+	rom := []byte{
+		0x22, 0x0a, // 0200: CALL sub_020a
+		0xa2, 0x0e, // 0202: LOAD I,sprite_020e
+		0xd0, 0x12, // 0204: DRAW V0,V1,2
+		0x12, 0x06, // 0206: JUMP label_0206
+		0xff, 0xff, // 0208: unreachable
+		0x00, 0xee, // 020a: RTS
+		0x00, 0x00, // 020c: unreachable
+		0xc3, 0x81, // 020e: sprite
+	}
+
+	out := disassemble(t, rom)
+
+	assert.Contains(t, out, "CALL sub_020a")
+	assert.Contains(t, out, "LOAD I,sprite_020e")
+	assert.Contains(t, out, "\nlabel_0206:\n    JUMP label_0206")
+	assert.Contains(t, out, "\nsub_020a:\n    RTS")
+	assert.Contains(t, out, "db 0xff, 0xff")
+	assert.Contains(t, out, "; 020e: ##....##\n")
+	assert.Contains(t, out, "; 020f: #......#\n")
+	assert.Contains(t, out, "; 0200: 220a\n")
+	assert.NotContains(t, out, "SCD", "data after an unconditional jump must not be decoded")
+}
+
+func TestDisassembleSkipsBothWays(t *testing.T) {
+	rom := []byte{
+		0x30, 0x00, // 0200: SKE V0,0
+		0x12, 0x08, // 0202: JUMP label_0208
+		0x00, 0xe0, // 0204: CLR
+		0x12, 0x04, // 0206: JUMP label_0204
+		0x00, 0xfd, // 0208: EXIT
+	}
+
+	out := disassemble(t, rom)
+
+	assert.Contains(t, out, "\nlabel_0204:\n    CLR")
+	assert.Contains(t, out, "\nlabel_0208:\n    EXIT")
+	assert.NotContains(t, out, "db ")
+}
+
+func TestDisassembleLongIndexOperand(t *testing.T) {
+	rom := []byte{
+		0x40, 0x00, // 0200: SKNE V0,0
+		0xf0, 0x00, // 0202: LOAD I,LONG
+		0x02, 0x0a, // 0204: operand
+		0x00, 0xe0, // 0206: CLR, reached by skipping the whole F000 NNNN
+		0x00, 0xfd, // 0208: EXIT
+		0x00, 0x00, // 020a
+	}
+
+	out := disassemble(t, rom)
+
+	assert.Contains(t, out, "LOAD I,LONG              ; 0202: f000\n    dw 0x020a                ; 0204: 020a\n")
+	assert.Contains(t, out, "CLR                      ; 0206: 00e0")
+}
+
+func TestDisassembleAlternativeEncodings(t *testing.T) {
+	rom := []byte{
+		0x01, 0xe0, // 0200: CLR with a non-zero X
+		0x91, 0x21, // 0202: SKNE V1,V2 with a non-zero N
+		0x51, 0x21, // 0204: SKE V1,V2 with an N other than 2 and 3
+		0x00, 0xfd, // 0206: EXIT
+	}
+
+	out := disassemble(t, rom)
+
+	assert.Contains(t, out, "dw 0x01e0                ; 0200: CLR\n")
+	assert.Contains(t, out, "dw 0x9121                ; 0202: SKNE V1,V2\n")
+	assert.Contains(t, out, "dw 0x5121                ; 0204: SKE V1,V2\n")
+	assert.Contains(t, out, "EXIT                     ; 0206: 00fd")
+}
+
+func TestCanonical(t *testing.T) {
+	assert.True(t, Canonical(0x00e0))
+	assert.True(t, Canonical(0x9120))
+	assert.False(t, Canonical(0x01e0))
+	assert.False(t, Canonical(0x9121))
+	assert.True(t, Canonical(0x5122))
+	assert.False(t, Canonical(0x512f))
+	assert.False(t, Canonical(0xe000))
+}
+
+func TestDisassembleDropsLabelsInsideInstructions(t *testing.T) {
+	rom := []byte{
+		0x12, 0x04, // 0200: JUMP label_0204
+		0x00, 0x00,
+		0xa2, 0x05, // 0204: LOAD I,0x0205, in the middle of an instruction
+		0x00, 0xfd, // 0206: EXIT
+	}
+
+	out := disassemble(t, rom)
+
+	assert.Contains(t, out, "LOAD I,0x0205")
+	assert.NotContains(t, out, "data_0205")
+}
+
+func TestDisassembleROMs(t *testing.T) {
+	entries, err := os.ReadDir("../roms")
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		t.Run(entry.Name(), func(t *testing.T) {
+			rom, err := os.ReadFile("../roms/" + entry.Name())
+			require.NoError(t, err)
+
+			out := disassemble(t, rom)
+			assert.True(t, strings.HasPrefix(out, "; "))
+			assert.Contains(t, out, "; 0200: ")
+		})
+	}
+}
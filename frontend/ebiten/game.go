@@ -0,0 +1,87 @@
+// Package ebiten runs the emulator in a desktop window using Ebitengine
+package ebiten
+
+import (
+	"chip8/chip8"
+	"errors"
+	"log/slog"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	screenMultiplier = 16
+	framesPerSecond  = 60
+)
+
+// Game implements ebiten.Game and acts as display, audio and keypad of the emulator
+type Game struct {
+	log           *slog.Logger
+	emulator      *chip8.Chip8
+	screen        *screen
+	sound         *sound
+	saveStatePath string
+	rewind        *chip8.Rewind
+
+	screenshotPath  string
+	screenshotScale int
+	recordingPath   string
+	recordingScale  int
+	recording       *recording
+}
+
+// New creates the window frontend and attaches it to the emulator
+func New(emulator *chip8.Chip8, log *slog.Logger) *Game {
+	g := &Game{
+		log:      log,
+		emulator: emulator,
+		screen:   newScreen(emulator.Screen()),
+		sound:    newSound(),
+	}
+
+	emulator.SetDisplay(g.screen)
+	emulator.SetAudio(g.sound)
+	emulator.SetKeypad(keypad{})
+
+	return g
+}
+
+// Run opens the window and blocks until it is closed, running one emulator frame per tick at 60 TPS
+func (g *Game) Run() error {
+	w, h := g.emulator.Screen().Layout()
+	ebiten.SetWindowSize(w*screenMultiplier, h*screenMultiplier)
+	ebiten.SetWindowTitle("CHIP-8")
+	ebiten.SetTPS(framesPerSecond)
+
+	err := ebiten.RunGame(g)
+	if g.recording != nil {
+		g.stopRecording()
+	}
+
+	return err
+}
+
+func (g *Game) Update() error {
+	g.handleScreenshot()
+	g.handleRecording()
+	g.handleSaveStates()
+	defer g.capture()
+
+	if g.handleRewind() {
+		return nil
+	}
+
+	err := g.emulator.Update()
+	if errors.Is(err, chip8.ErrExit) {
+		return ebiten.Termination
+	}
+	return err
+}
+
+func (g *Game) Draw(image *ebiten.Image) {
+	g.screen.Draw(image)
+}
+
+func (g *Game) Layout(_, _ int) (w, h int) {
+	return g.emulator.Screen().Layout()
+}
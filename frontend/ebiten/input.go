@@ -0,0 +1,36 @@
+package ebiten
+
+import (
+	"chip8/chip8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// keyMap is chip8.KeyboardLayout as Ebitengine keys
+var keyMap = func() [chip8.KeyCount]ebiten.Key {
+	var keys [chip8.KeyCount]ebiten.Key
+	for j, c := range chip8.KeyboardLayout {
+		if c >= 'a' {
+			keys[j] = ebiten.KeyA + ebiten.Key(c-'a')
+		} else {
+			keys[j] = ebiten.KeyDigit0 + ebiten.Key(c-'0')
+		}
+	}
+	return keys
+}()
+
+type keypad struct{}
+
+// Pressed implements chip8.Keypad
+func (k keypad) Pressed() [chip8.KeyCount]bool {
+	var keys [chip8.KeyCount]bool
+	for i, key := range keyMap {
+		keys[i] = ebiten.IsKeyPressed(key)
+	}
+	return keys
+}
+
+// Keypad returns the keyboard keypad the window reads, to wrap it in a movie recorder
+func (g *Game) Keypad() chip8.Keypad {
+	return keypad{}
+}
@@ -0,0 +1,72 @@
+package ebiten
+
+import (
+	"chip8/recorder"
+	"log/slog"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// recordKey starts recording the screen, pressing it again saves the recording
+const recordKey = ebiten.KeyF10
+
+// recording is the screen being recorded, saved to path after frames frames if not 0
+type recording struct {
+	recorder *recorder.Recorder
+	path     string
+	frames   int
+}
+
+// SetRecordingPath enables the recording hotkey, saving <path>.recordingN.gif with the first unused N and each
+// pixel scaled to scale by scale
+func (g *Game) SetRecordingPath(path string, scale int) {
+	g.recordingPath = path
+	g.recordingScale = scale
+}
+
+// Record records the screen from the first frame, saving it to path after frames frames, or when the window is
+// closed if frames is 0
+func (g *Game) Record(path string, frames, scale int) {
+	g.recording = &recording{
+		recorder: recorder.New(g.screen.palette, scale),
+		path:     path,
+		frames:   frames,
+	}
+}
+
+func (g *Game) handleRecording() {
+	if g.recordingPath == "" || !inpututil.IsKeyJustPressed(recordKey) {
+		return
+	}
+
+	if g.recording != nil {
+		g.stopRecording()
+		return
+	}
+
+	g.Record(nextPath(g.recordingPath, "recording", ".gif"), 0, g.recordingScale)
+	g.log.Info("Recording started", slog.String("path", g.recording.path))
+}
+
+// capture adds the screen to the recording, if any, stopping it once it has all its frames
+func (g *Game) capture() {
+	if g.recording == nil {
+		return
+	}
+
+	g.recording.recorder.Capture(g.emulator.Screen())
+	if g.recording.frames > 0 && g.recording.recorder.Ticks() >= g.recording.frames {
+		g.stopRecording()
+	}
+}
+
+func (g *Game) stopRecording() {
+	path := g.recording.path
+	if err := g.recording.recorder.Save(path); err != nil {
+		g.log.Error("Failed to save recording", slog.String("path", path), slog.String("error", err.Error()))
+	} else {
+		g.log.Info("Recording saved", slog.String("path", path), slog.Int("frames", g.recording.recorder.Ticks()))
+	}
+	g.recording = nil
+}
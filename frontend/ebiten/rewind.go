@@ -0,0 +1,32 @@
+package ebiten
+
+import (
+	"chip8/chip8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// rewindKey steps back in time while held
+const rewindKey = ebiten.KeyBackspace
+
+// SetRewind enables the rewind hotkey, recording snapshots in the given buffer
+func (g *Game) SetRewind(rewind *chip8.Rewind) {
+	g.rewind = rewind
+}
+
+// handleRewind return true while rewinding, false otherwise
+// It steps back one snapshot per frame
+func (g *Game) handleRewind() bool {
+	if g.rewind == nil {
+		return false
+	}
+
+	if !ebiten.IsKeyPressed(rewindKey) {
+		g.rewind.Record(g.emulator)
+		return false
+	}
+
+	g.rewind.Step(g.emulator)
+
+	return true
+}
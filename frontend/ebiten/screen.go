@@ -0,0 +1,47 @@
+package ebiten
+
+import (
+	"chip8/chip8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const bytesPerPixel = 4
+
+type screen struct {
+	buffer  *ebiten.Image
+	pixels  []byte // RGBA, uploaded to buffer in one go
+	palette chip8.Palette
+}
+
+func newScreen(s *chip8.Screen) *screen {
+	w, h := s.Layout()
+	return &screen{
+		buffer:  ebiten.NewImage(w, h),
+		pixels:  make([]byte, w*h*bytesPerPixel),
+		palette: chip8.DefaultPalette,
+	}
+}
+
+// Refresh implements chip8.Display, uploading the whole framebuffer at once
+func (s *screen) Refresh(framebuffer *chip8.Screen) {
+	w, h := framebuffer.Layout()
+	if bounds := s.buffer.Bounds(); bounds.Dx() != w || bounds.Dy() != h {
+		s.buffer.Deallocate()
+		s.buffer = ebiten.NewImage(w, h)
+		s.pixels = make([]byte, w*h*bytesPerPixel)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := s.palette[framebuffer.Pixel(x, y)]
+			offset := (y*w + x) * bytesPerPixel
+			s.pixels[offset], s.pixels[offset+1], s.pixels[offset+2], s.pixels[offset+3] = c.R, c.G, c.B, c.A
+		}
+	}
+	s.buffer.WritePixels(s.pixels)
+}
+
+func (s *screen) Draw(image *ebiten.Image) {
+	image.DrawImage(s.buffer, nil)
+}
@@ -0,0 +1,45 @@
+package ebiten
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// screenshotKey saves the screen as PNG
+const screenshotKey = ebiten.KeyF12
+
+// SetScreenshotPath enables the screenshot hotkey, saving <path>.screenshotN.png with the first unused N and each
+// pixel scaled to scale by scale
+func (g *Game) SetScreenshotPath(path string, scale int) {
+	g.screenshotPath = path
+	g.screenshotScale = scale
+}
+
+func (g *Game) handleScreenshot() {
+	if g.screenshotPath == "" || !inpututil.IsKeyJustPressed(screenshotKey) {
+		return
+	}
+
+	path := nextPath(g.screenshotPath, "screenshot", ".png")
+	if err := g.emulator.Screen().Export(path, g.screen.palette, g.screenshotScale); err != nil {
+		g.log.Error("Failed to save screenshot", slog.String("path", path), slog.String("error", err.Error()))
+		return
+	}
+	g.log.Info("Screenshot saved", slog.String("path", path))
+}
+
+// nextPath returns <base>.<name>N<extension> with the first N no file has
+func nextPath(base, name, extension string) string {
+	for n := 1; ; n++ {
+		path := fmt.Sprintf("%s.%s%d%s", base, name, n, extension)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return path
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ebiten
+
+import (
+	"chip8/chip8"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const (
+	sampleRate     = 48000
+	frequency      = 440
+	patternBits    = chip8.AudioPatternBytes * 8
+	patternVolume  = 0.5
+	bytesPerSample = 8
+)
+
+// from https://ebitengine.org/en/examples/sinewave.html
+
+// stream is an infinite stream of 440 Hz sine wave, or of an XO-CHIP audio pattern once one is loaded.
+type stream struct {
+	pos int64
+
+	mu      sync.Mutex
+	pattern *[chip8.AudioPatternBytes]uint8
+	rate    float64 // pattern bits per sample
+	phase   float64 // pattern bit being played
+}
+
+// Read is io.Reader's Read.
+//
+// Read fills the data with sine wave or pattern samples.
+func (s *stream) Read(buf []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pattern != nil {
+		return s.readPattern(buf), nil
+	}
+
+	n := len(buf) / bytesPerSample * bytesPerSample
+
+	const length = sampleRate / frequency
+	for i := 0; i < n/bytesPerSample; i++ {
+		v := math.Float32bits(float32(math.Sin(2 * math.Pi * float64(s.pos/bytesPerSample+int64(i)) / length)))
+
+		putSample(buf[8*i:], v)
+	}
+
+	s.pos += int64(n)
+	s.pos %= length * bytesPerSample
+
+	return n, nil
+}
+
+// readPattern fills the data with the pattern bits as square wave samples
+func (s *stream) readPattern(buf []byte) int {
+	n := len(buf) / bytesPerSample * bytesPerSample
+
+	for i := 0; i < n/bytesPerSample; i++ {
+		bit := int(s.phase) % patternBits
+		v := float32(-patternVolume)
+		if s.pattern[bit/8]>>(7-bit%8)&1 == 1 {
+			v = patternVolume
+		}
+
+		putSample(buf[8*i:], math.Float32bits(v))
+
+		s.phase = math.Mod(s.phase+s.rate, patternBits)
+	}
+
+	return n
+}
+
+func (s *stream) setPattern(pattern [chip8.AudioPatternBytes]uint8, pitch uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pattern = &pattern
+	s.rate = chip8.PatternRate(pitch) / sampleRate
+}
+
+// putSample writes a 32-bit float sample to both stereo channels
+func putSample(buf []byte, v uint32) {
+	buf[0] = byte(v)
+	buf[1] = byte(v >> 8)
+	buf[2] = byte(v >> 16)
+	buf[3] = byte(v >> 24)
+	buf[4] = byte(v)
+	buf[5] = byte(v >> 8)
+	buf[6] = byte(v >> 16)
+	buf[7] = byte(v >> 24)
+}
+
+// Close is io.Closer's Close.
+func (s *stream) Close() error {
+	return nil
+}
+
+type sound struct {
+	player *audio.Player
+	stream *stream
+}
+
+func newSound() *sound {
+	context := audio.NewContext(sampleRate)
+
+	s := &stream{}
+	player, _ := context.NewPlayerF32(s)
+	player.SetVolume(0)
+	player.Play()
+
+	return &sound{
+		player: player,
+		stream: s,
+	}
+}
+
+// SetBeep implements chip8.Audio
+func (s *sound) SetBeep(on bool) {
+	if on {
+		s.player.SetVolume(1)
+	} else {
+		s.player.SetVolume(0)
+	}
+}
+
+// SetPattern implements chip8.Audio
+func (s *sound) SetPattern(pattern [chip8.AudioPatternBytes]uint8, pitch uint8) {
+	s.stream.setPattern(pattern, pitch)
+}
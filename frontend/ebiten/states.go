@@ -0,0 +1,80 @@
+package ebiten
+
+import (
+	"chip8/chip8"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// F1-F4 load a save state slot, Shift+F1-F4 save it
+var saveStateKeys = [...]ebiten.Key{
+	ebiten.KeyF1,
+	ebiten.KeyF2,
+	ebiten.KeyF3,
+	ebiten.KeyF4,
+}
+
+// SetSaveStatePath enables the save state hotkeys, storing slot N in <path>.stateN
+func (g *Game) SetSaveStatePath(path string) {
+	g.saveStatePath = path
+}
+
+func (g *Game) handleSaveStates() {
+	if g.saveStatePath == "" {
+		return
+	}
+
+	for i, key := range saveStateKeys {
+		if !inpututil.IsKeyJustPressed(key) {
+			continue
+		}
+
+		path := fmt.Sprintf("%s.state%d", g.saveStatePath, i+1)
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if err := saveState(g.emulator, path); err != nil {
+				g.log.Error("Failed to save state", slog.String("path", path), slog.String("error", err.Error()))
+				continue
+			}
+			g.log.Info("State saved", slog.String("path", path))
+		} else {
+			if err := loadState(g.emulator, path); err != nil {
+				g.log.Error("Failed to load state", slog.String("path", path), slog.String("error", err.Error()))
+				continue
+			}
+			g.log.Info("State loaded", slog.String("path", path))
+		}
+	}
+}
+
+func saveState(emulator *chip8.Chip8, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := emulator.Snapshot().WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func loadState(emulator *chip8.Chip8, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	snapshot, err := chip8.ReadSnapshot(f)
+	if err != nil {
+		return err
+	}
+
+	return emulator.Restore(snapshot)
+}
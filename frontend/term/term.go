@@ -0,0 +1,202 @@
+// Package term plays programs in an ANSI terminal, for machines reached over SSH where no window can open
+package term
+
+import (
+	"chip8/chip8"
+	"chip8/terminal"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const framesPerSecond = 60
+
+// Renderer draws a screen as lines of text
+type Renderer func(s *chip8.Screen) []string
+
+var renderers = map[string]Renderer{
+	"halfblock": terminal.HalfBlocks,
+	"braille":   terminal.Braille,
+}
+
+// RendererByName returns the renderer named halfblock, two rows per character, or braille, two columns and four
+// rows per character
+func RendererByName(name string) (Renderer, error) {
+	renderer, ok := renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown renderer %q, expected one of: %s", name, strings.Join(RendererNames(), ", "))
+	}
+
+	return renderer, nil
+}
+
+// RendererNames returns the renderer names in alphabetical order
+func RendererNames() []string {
+	return slices.Sorted(maps.Keys(renderers))
+}
+
+// Sound tells how the buzzer is played in a terminal
+type Sound uint8
+
+const (
+	// SoundBell rings the terminal bell when the buzzer turns on
+	SoundBell Sound = iota
+	// SoundFlash draws the screen in reverse video while the buzzer is on
+	SoundFlash
+	// SoundOff ignores the buzzer
+	SoundOff
+)
+
+var sounds = map[string]Sound{
+	"bell":  SoundBell,
+	"flash": SoundFlash,
+	"off":   SoundOff,
+}
+
+// SoundByName returns the sound named bell, flash or off
+func SoundByName(name string) (Sound, error) {
+	sound, ok := sounds[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown sound %q, expected one of: %s", name, strings.Join(SoundNames(), ", "))
+	}
+
+	return sound, nil
+}
+
+// SoundNames returns the sound names in alphabetical order
+func SoundNames() []string {
+	return slices.Sorted(maps.Keys(sounds))
+}
+
+// Frontend is the display, audio and keypad of a machine running in a terminal. Keys are mapped like in the
+// window, 1234/QWER/ASDF/ZXCV, each press holding the key for a moment as terminals don't report releases
+type Frontend struct {
+	emulator *chip8.Chip8
+	keypad   *terminal.Keypad
+	render   Renderer
+	sound    Sound
+
+	beeping bool
+	ring    bool // the bell is due with the next frame
+	quit    bool
+}
+
+// New attaches a terminal frontend to a machine
+func New(emulator *chip8.Chip8, render Renderer, sound Sound) *Frontend {
+	f := &Frontend{
+		emulator: emulator,
+		keypad:   terminal.NewKeypad(terminal.DefaultHoldFrames),
+		render:   render,
+		sound:    sound,
+	}
+
+	emulator.SetDisplay(f)
+	emulator.SetAudio(f)
+	emulator.SetKeypad(f.keypad)
+
+	return f
+}
+
+// Keypad returns the keypad the terminal presses, to wrap it in a movie recorder
+func (f *Frontend) Keypad() chip8.Keypad {
+	return f.keypad
+}
+
+// Refresh implements chip8.Display, the screen is drawn every frame anyway and only written out if it changed
+func (f *Frontend) Refresh(_ *chip8.Screen) {}
+
+// SetBeep implements chip8.Audio
+func (f *Frontend) SetBeep(on bool) {
+	f.beeping = on
+	if on && f.sound == SoundBell {
+		f.ring = true
+	}
+}
+
+// SetPattern implements chip8.Audio, terminals can only ring their bell
+func (f *Frontend) SetPattern(_ [chip8.AudioPatternBytes]uint8, _ uint8) {}
+
+// Run takes over the terminal of in and out until the program exits or the user quits with Esc or Ctrl+C
+func (f *Frontend) Run(in *os.File, out io.Writer) error {
+	restore, err := terminal.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	fmt.Fprint(out, terminal.AlternateScreen, terminal.HideCursor, terminal.ClearScreen)
+	defer fmt.Fprint(out, terminal.ShowCursor, terminal.MainScreen)
+
+	reader := terminal.NewKeyReader(in)
+	defer reader.Stop()
+
+	ticker := time.NewTicker(time.Second / framesPerSecond)
+	defer ticker.Stop()
+
+	var last string
+	for !f.quit {
+		select {
+		case key := <-reader.Keys():
+			f.HandleKey(key)
+		case <-ticker.C:
+			if err := f.Update(); err != nil {
+				return err
+			}
+		}
+
+		if f.ring {
+			fmt.Fprint(out, terminal.Bell)
+			f.ring = false
+		}
+		if frame := f.Render(); frame != last {
+			fmt.Fprint(out, terminal.Home, frame)
+			last = frame
+		}
+	}
+
+	return nil
+}
+
+// HandleKey presses the keypad key of a key decoded by terminal.DecodeKeys, Esc and Ctrl+C quit
+func (f *Frontend) HandleKey(key string) {
+	switch key {
+	case "esc", "ctrl-c":
+		f.quit = true
+	default:
+		f.keypad.Press(key)
+	}
+}
+
+// Update runs a frame, the user quitting once the program exits
+func (f *Frontend) Update() error {
+	err := f.emulator.Update()
+	if errors.Is(err, chip8.ErrExit) {
+		f.quit = true
+		return nil
+	}
+
+	return err
+}
+
+// Quit returns true once the program exited or the user asked to quit, false otherwise
+func (f *Frontend) Quit() bool {
+	return f.quit
+}
+
+// Render returns the screen and a help line, in reverse video while flashing for the buzzer
+func (f *Frontend) Render() string {
+	lines := f.render(f.emulator.Screen())
+	if f.beeping && f.sound == SoundFlash {
+		for j, line := range lines {
+			lines[j] = terminal.Reverse + line + terminal.Reset
+		}
+	}
+	lines = append(lines, "", "keypad 1234 qwer asdf zxcv  esc quit")
+
+	return strings.Join(lines, terminal.ClearLine+"\r\n") + terminal.ClearLine + terminal.ClearBelow
+}
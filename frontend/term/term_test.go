@@ -0,0 +1,105 @@
+package term
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/terminal"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testROM draws the 0 digit at the top left once key 5 is pressed, then beeps and exits when the delay timer,
+// started with the sound timer, runs out:
+//
+//	0200: SKNP V0
+//	0202: JUMP 0x0208
+//	0204: JUMP 0x0200
+//	0206: -
+//	0208: LOAD I,0x0050
+//	020a: DRAW V1,V1,5
+//	020c: LOAD V2,a
+//	020e: LOAD ST,V2
+//	0210: LOAD DT,V2
+//	0212: LOAD V3,DT
+//	0214: SKE V3,0
+//	0216: JUMP 0x0212
+//	0218: EXIT
+var testROM = []byte{
+	0xe0, 0xa1, 0x12, 0x08, 0x12, 0x00, 0x00, 0x00,
+	0xa0, 0x50, 0xd1, 0x15, 0x62, 0x0a, 0xf2, 0x18,
+	0xf2, 0x15, 0xf3, 0x07, 0x33, 0x00, 0x12, 0x12, 0x00, 0xfd,
+}
+
+func newTestFrontend(t *testing.T, sound Sound) *Frontend {
+	c := chip8.NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, c.LoadFont())
+	require.NoError(t, c.LoadROM(bytes.NewReader(testROM)))
+	c.SetRegister(0, 0x5)
+
+	return New(c, terminal.HalfBlocks, sound)
+}
+
+func TestKeysPressKeypad(t *testing.T) {
+	f := newTestFrontend(t, SoundBell)
+
+	assert.NoError(t, f.Update())
+	assert.False(t, f.emulator.Screen().Get(0, 0))
+
+	f.HandleKey("W")
+	assert.NoError(t, f.Update())
+	assert.True(t, f.emulator.Screen().Get(0, 0))
+	assert.False(t, f.ring, "the frame ends waiting for the display")
+
+	assert.NoError(t, f.Update())
+	assert.True(t, f.ring)
+}
+
+func TestExitQuits(t *testing.T) {
+	f := newTestFrontend(t, SoundBell)
+
+	f.HandleKey("w")
+	for j := 0; j < 20 && !f.Quit(); j++ {
+		assert.NoError(t, f.Update())
+	}
+	assert.True(t, f.Quit())
+}
+
+func TestEscQuits(t *testing.T) {
+	f := newTestFrontend(t, SoundBell)
+
+	f.HandleKey("esc")
+	assert.True(t, f.Quit())
+}
+
+func TestRenderFlashes(t *testing.T) {
+	f := newTestFrontend(t, SoundFlash)
+
+	f.HandleKey("w")
+	assert.NoError(t, f.Update())
+	assert.NoError(t, f.Update())
+
+	frame := f.Render()
+	assert.False(t, f.ring)
+	assert.True(t, strings.HasPrefix(frame, terminal.Reverse+"█▀▀█"), frame)
+	assert.Contains(t, frame, "esc quit")
+
+	f.SetBeep(false)
+	assert.True(t, strings.HasPrefix(f.Render(), "█▀▀█"))
+}
+
+func TestRendererByName(t *testing.T) {
+	_, err := RendererByName("braille")
+	assert.NoError(t, err)
+
+	_, err = RendererByName("sixel")
+	assert.ErrorContains(t, err, "braille, halfblock")
+
+	sound, err := SoundByName("flash")
+	assert.NoError(t, err)
+	assert.Equal(t, SoundFlash, sound)
+}
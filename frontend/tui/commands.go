@@ -0,0 +1,184 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type command struct {
+	name     string
+	alias    string
+	usage    string
+	args     int
+	variadic bool // more arguments than args are accepted
+	run      func(u *UI, args []string) error
+}
+
+// commands are typed after : while paused. Addresses and values are hex, with or without 0x
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "break", alias: "b", usage: "<address>", args: 1, run: func(u *UI, args []string) error {
+			address, err := parseHex(args[0], 0xFFFF)
+			if err != nil {
+				return err
+			}
+			u.debugger.SetBreakpoint(uint16(address))
+			return nil
+		}},
+		{name: "clear", alias: "cl", usage: "<address>", args: 1, run: func(u *UI, args []string) error {
+			address, err := parseHex(args[0], 0xFFFF)
+			if err != nil {
+				return err
+			}
+			u.debugger.ClearBreakpoint(uint16(address))
+			return nil
+		}},
+		{name: "opbreak", alias: "ob", usage: "<pattern like DXYN>", args: 1, run: func(u *UI, args []string) error {
+			return u.debugger.SetOpcodeBreakpoint(args[0])
+		}},
+		{name: "opclear", alias: "oc", usage: "<pattern like DXYN>", args: 1, run: func(u *UI, args []string) error {
+			u.debugger.ClearOpcodeBreakpoint(args[0])
+			return nil
+		}},
+		{name: "watch", alias: "w", usage: "<condition like V3 == 0x10 or mem[I] changed>", args: 2, variadic: true, run: func(u *UI, args []string) error {
+			id, err := u.debugger.AddWatchpoint(strings.Join(args, " "))
+			if err == nil {
+				u.message = fmt.Sprintf("watchpoint %d", id)
+			}
+			return err
+		}},
+		{name: "unwatch", alias: "uw", usage: "<id>", args: 1, run: func(u *UI, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid watchpoint id %q", args[0])
+			}
+			u.debugger.RemoveWatchpoint(id)
+			return nil
+		}},
+		{name: "run", alias: "r", usage: "<address>", args: 1, run: func(u *UI, args []string) error {
+			address, err := parseHex(args[0], 0xFFFF)
+			if err != nil {
+				return err
+			}
+			u.resume(func() { u.debugger.RunTo(uint16(address)) })
+			return nil
+		}},
+		{name: "continue", alias: "c", run: func(u *UI, _ []string) error {
+			u.resume(u.debugger.Continue)
+			return nil
+		}},
+		{name: "step", alias: "s", run: func(u *UI, _ []string) error {
+			return u.step(u.debugger.Step)
+		}},
+		{name: "next", alias: "n", run: func(u *UI, _ []string) error {
+			return u.step(u.debugger.StepOver)
+		}},
+		{name: "out", alias: "o", run: func(u *UI, _ []string) error {
+			return u.step(u.debugger.StepOut)
+		}},
+		{name: "mem", alias: "m", usage: "<address>", args: 1, run: func(u *UI, args []string) error {
+			address, err := parseHex(args[0], 0xFFFF)
+			if err != nil {
+				return err
+			}
+			u.memory = uint16(address)
+			return nil
+		}},
+		{name: "set", usage: "<address> <byte>...", args: 2, variadic: true, run: func(u *UI, args []string) error {
+			return u.setMemory(args[0], args[1:])
+		}},
+		{name: "reg", usage: "<V0-VF|I|PC> <value>", args: 2, run: func(u *UI, args []string) error {
+			return u.setRegister(args[0], args[1])
+		}},
+		{name: "goto", alias: "g", usage: "<address>", args: 1, run: func(u *UI, args []string) error {
+			address, err := parseHex(args[0], 0xFFFF)
+			if err != nil {
+				return err
+			}
+			u.follow, u.cursor = false, uint16(address)
+			return nil
+		}},
+		{name: "quit", alias: "q", run: func(u *UI, _ []string) error {
+			u.quit = true
+			return nil
+		}},
+		{name: "help", alias: "h", run: func(u *UI, _ []string) error {
+			names := make([]string, len(commands))
+			for j, c := range commands {
+				names[j] = c.name
+			}
+			u.message = "commands: " + strings.Join(names, " ")
+			return nil
+		}},
+	}
+}
+
+func (u *UI) setMemory(address string, values []string) error {
+	start, err := parseHex(address, 0xFFFF)
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, len(values))
+	for j, value := range values {
+		b, err := parseHex(value, 0xFF)
+		if err != nil {
+			return err
+		}
+		data[j] = uint8(b)
+	}
+
+	dst, err := u.emulator.Memory().Slice(uint16(start), len(data))
+	if err != nil {
+		return err
+	}
+	copy(dst, data)
+
+	u.memory = uint16(start)
+	return nil
+}
+
+func (u *UI) setRegister(name, value string) error {
+	switch upper := strings.ToUpper(name); {
+	case upper == "I" || upper == "PC":
+		v, err := parseHex(value, 0xFFFF)
+		if err != nil {
+			return err
+		}
+		if upper == "I" {
+			u.emulator.SetIndex(uint16(v))
+		} else {
+			u.emulator.SetPC(uint16(v))
+			u.follow = true
+		}
+		return nil
+	case len(upper) == 2 && upper[0] == 'V':
+		x, err := parseHex(upper[1:], 0xF)
+		if err != nil {
+			return fmt.Errorf("unknown register %q", name)
+		}
+		v, err := parseHex(value, 0xFF)
+		if err != nil {
+			return err
+		}
+		u.emulator.SetRegister(uint8(x), uint8(v))
+		return nil
+	}
+
+	return fmt.Errorf("unknown register %q", name)
+}
+
+// parseHex reads a hex number, with or without 0x, up to max
+func parseHex(text string, max uint64) (uint64, error) {
+	digits := strings.TrimPrefix(strings.ToLower(text), "0x")
+
+	value, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil || value > max {
+		return 0, fmt.Errorf("invalid value %q, expected hex up to %x", text, max)
+	}
+
+	return value, nil
+}
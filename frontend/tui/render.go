@@ -0,0 +1,204 @@
+package tui
+
+import (
+	"chip8/chip8"
+	"chip8/terminal"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const disassemblyWidth = 40
+
+// Render returns the whole screen: disassembly next to the machine state, the framebuffer and the command line
+func (u *UI) Render() string {
+	var lines []string
+
+	lines = append(lines, columns(u.disassembly(), u.state(), disassemblyWidth)...)
+	lines = append(lines, "")
+	lines = append(lines, terminal.HalfBlocks(u.emulator.Screen())...)
+	lines = append(lines, "", u.status(), u.footer())
+
+	return strings.Join(lines, terminal.ClearLine+"\r\n") + terminal.ClearLine + terminal.ClearBelow
+}
+
+// disassembly decodes the instructions around the cursor, marking the PC with => and breakpoints with *
+func (u *UI) disassembly() []string {
+	pc := u.emulator.PC()
+	if u.follow {
+		u.cursor = pc
+	}
+
+	if u.cursor < u.top || int(u.cursor) >= int(u.top)+2*disassemblyLines {
+		u.top = u.cursor - min(u.cursor, 2*disassemblyContext)
+	}
+
+	breakpoints := map[uint16]bool{}
+	for _, b := range u.debugger.Breakpoints() {
+		breakpoints[b] = true
+	}
+
+	lines := []string{terminal.Bold + "Disassembly" + terminal.Reset}
+	memory := u.emulator.Memory()
+	for j := 0; j < disassemblyLines; j++ {
+		address := u.top + uint16(2*j)
+
+		marker := "  "
+		if address == pc {
+			marker = "=>"
+		}
+		if breakpoints[address] {
+			marker += "*"
+		} else {
+			marker += " "
+		}
+
+		line := marker + fmt.Sprintf("%04x  ", address)
+		if opcode, err := memory.ReadWord(address); err != nil {
+			line += "----"
+		} else if instruction, ok := chip8.Decode(opcode); ok {
+			line += fmt.Sprintf("%04x  %s", opcode, instruction)
+		} else {
+			line += fmt.Sprintf("%04x  ???", opcode)
+		}
+
+		if address == u.cursor {
+			line = terminal.Reverse + pad(line, disassemblyWidth-1) + terminal.Reset
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// state shows registers, stack, timers, breakpoints, watchpoints and memory
+func (u *UI) state() []string {
+	e := u.emulator
+	v := e.Registers()
+
+	lines := []string{
+		terminal.Bold + "Machine" + terminal.Reset,
+		fmt.Sprintf("PC %04x  I %04x  DT %02x  ST %02x  frame %d", e.PC(), e.Index(), e.DelayTimer(), e.SoundTimer(), e.Frames()),
+	}
+	for row := 0; row < len(v); row += 4 {
+		lines = append(lines, fmt.Sprintf("V%X %02x  V%X %02x  V%X %02x  V%X %02x",
+			row, v[row], row+1, v[row+1], row+2, v[row+2], row+3, v[row+3]))
+	}
+
+	stack := e.Stack()
+	frames := make([]string, len(stack))
+	for j, address := range stack {
+		frames[j] = fmt.Sprintf("%04x", address)
+	}
+	lines = append(lines, fmt.Sprintf("Stack %d: %s", len(stack), strings.Join(frames, " ")))
+
+	breakpoints := u.debugger.Breakpoints()
+	addresses := make([]string, len(breakpoints))
+	for j, address := range breakpoints {
+		addresses[j] = fmt.Sprintf("%04x", address)
+	}
+	addresses = append(addresses, u.debugger.OpcodeBreakpoints()...)
+	lines = append(lines, "Break: "+strings.Join(addresses, " "))
+
+	var watches []string
+	for _, w := range u.debugger.Watchpoints() {
+		watches = append(watches, fmt.Sprintf("%d: %s", w.ID, w.Condition))
+	}
+	lines = append(lines, "Watch: "+strings.Join(watches, ", "))
+
+	lines = append(lines, "", terminal.Bold+"Memory"+terminal.Reset)
+	return append(lines, u.hexView()...)
+}
+
+// hexView dumps memory from the view address, the byte at I in reverse video
+func (u *UI) hexView() []string {
+	memory := u.emulator.Memory()
+	index := int(u.emulator.Index())
+	start := int(u.memory) &^ (memoryRowBytes - 1)
+
+	var lines []string
+	for row := 0; row < memoryRows; row++ {
+		address := start + row*memoryRowBytes
+		if address >= len(memory) {
+			break
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%04x ", address)
+		for j := address; j < address+memoryRowBytes && j < len(memory); j++ {
+			if j == index {
+				fmt.Fprintf(&sb, " %s%02x%s", terminal.Reverse, memory[j], terminal.Reset)
+			} else {
+				fmt.Fprintf(&sb, " %02x", memory[j])
+			}
+		}
+		lines = append(lines, sb.String())
+	}
+
+	return lines
+}
+
+func (u *UI) status() string {
+	switch stop, paused := u.debugger.Stopped(); {
+	case u.message != "":
+		return u.message
+	case !paused:
+		return "running"
+	case stop.Reason == chip8.StopWatchpoint:
+		return fmt.Sprintf("paused at %04x: watchpoint %d", stop.PC, stop.Watchpoint)
+	case stop.Reason == chip8.StopFault:
+		return fmt.Sprintf("paused at %04x: %s", stop.PC, stop.Err)
+	default:
+		return fmt.Sprintf("paused at %04x: %s", stop.PC, stop.Reason)
+	}
+}
+
+func (u *UI) footer() string {
+	switch {
+	case u.editing:
+		return ":" + u.input + terminal.Reverse + " " + terminal.Reset
+	case u.debugger.Paused():
+		return "s step  n over  o out  c continue  b breakpoint  up/down cursor  . to PC  : command  q quit"
+	default:
+		return "esc pause  keypad 1234 qwer asdf zxcv  ctrl-c quit"
+	}
+}
+
+// columns puts two blocks of lines side by side, the left one padded to width
+func columns(left, right []string, width int) []string {
+	lines := make([]string, max(len(left), len(right)))
+	for j := range lines {
+		var l, r string
+		if j < len(left) {
+			l = left[j]
+		}
+		if j < len(right) {
+			r = right[j]
+		}
+		lines[j] = pad(l, width) + r
+	}
+
+	return lines
+}
+
+// pad fills a line with spaces up to a visible width, escape sequences don't count
+func pad(line string, width int) string {
+	visible := utf8.RuneCountInString(line)
+	for rest := line; ; {
+		start := strings.Index(rest, "\x1b[")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexAny(rest[start+2:], "ABCDHJKlhm")
+		if end < 0 {
+			break
+		}
+		visible -= end + 3
+		rest = rest[start+end+3:]
+	}
+
+	if visible >= width {
+		return line
+	}
+	return line + strings.Repeat(" ", width-visible)
+}
@@ -0,0 +1,260 @@
+// Package tui is a full-screen terminal debugger, for machines reached over SSH where no window can open
+package tui
+
+import (
+	"chip8/chip8"
+	"chip8/terminal"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	framesPerSecond    = 60
+	disassemblyLines   = 20
+	disassemblyContext = 6
+	memoryRows         = 8
+	memoryRowBytes     = 16
+)
+
+// UI shows the machine state and drives the debugger: the keypad belongs to the program while it runs,
+// debugger commands are available while it's paused
+type UI struct {
+	emulator *chip8.Chip8
+	debugger *chip8.Debugger
+	keypad   *terminal.Keypad
+
+	cursor  uint16 // disassembly line the breakpoint key toggles
+	top     uint16 // first disassembly line
+	follow  bool   // the cursor follows the PC
+	memory  uint16 // first hex view address
+	input   string
+	editing bool
+	message string
+	exited  bool
+	quit    bool
+}
+
+// New creates a debugger UI for a machine, whose keypad it replaces. The machine starts paused
+func New(emulator *chip8.Chip8) *UI {
+	u := &UI{
+		emulator: emulator,
+		debugger: chip8.NewDebugger(emulator),
+		keypad:   terminal.NewKeypad(terminal.DefaultHoldFrames),
+		follow:   true,
+		memory:   emulator.PC(),
+	}
+	emulator.SetKeypad(u.keypad)
+	u.debugger.Pause()
+
+	return u
+}
+
+// Debugger returns the debugger the UI drives, to set breakpoints before running
+func (u *UI) Debugger() *chip8.Debugger {
+	return u.debugger
+}
+
+// Run takes over the terminal of in and out until the user quits
+func (u *UI) Run(in *os.File, out io.Writer) error {
+	restore, err := terminal.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	fmt.Fprint(out, terminal.AlternateScreen, terminal.HideCursor, terminal.ClearScreen)
+	defer fmt.Fprint(out, terminal.ShowCursor, terminal.MainScreen)
+
+	reader := terminal.NewKeyReader(in)
+	defer reader.Stop()
+
+	ticker := time.NewTicker(time.Second / framesPerSecond)
+	defer ticker.Stop()
+
+	var last string
+	for !u.quit {
+		select {
+		case key := <-reader.Keys():
+			u.HandleKey(key)
+		case <-ticker.C:
+			u.Update()
+		}
+
+		if frame := u.Render(); frame != last {
+			fmt.Fprint(out, terminal.Home, frame)
+			last = frame
+		}
+	}
+
+	return nil
+}
+
+// Quit returns true once the user asked to quit, false otherwise
+func (u *UI) Quit() bool {
+	return u.quit
+}
+
+// Update runs a frame unless the debugger is paused
+func (u *UI) Update() {
+	if u.exited || u.debugger.Paused() {
+		return
+	}
+
+	err := u.emulator.Update()
+	switch {
+	case errors.Is(err, chip8.ErrExit):
+		u.exited = true
+		u.debugger.Pause()
+		u.message = "program exited"
+	case err != nil:
+		u.message = err.Error()
+	}
+
+	if u.debugger.Paused() {
+		u.follow = true
+	}
+}
+
+// HandleKey reacts to a key decoded by terminal.DecodeKeys
+func (u *UI) HandleKey(key string) {
+	switch {
+	case key == "ctrl-c":
+		u.quit = true
+	case u.editing:
+		u.edit(key)
+	case !u.debugger.Paused():
+		if key == "esc" || key == "f6" {
+			u.debugger.Pause()
+			u.follow = true
+		} else {
+			u.keypad.Press(key)
+		}
+	default:
+		u.command(key)
+	}
+}
+
+// command handles a key while paused
+func (u *UI) command(key string) {
+	var err error
+
+	switch key {
+	case "s", "f7":
+		err = u.step(u.debugger.Step)
+	case "n", "f8":
+		err = u.step(u.debugger.StepOver)
+	case "o", "shift-f8":
+		err = u.step(u.debugger.StepOut)
+	case "c", "f5":
+		u.resume(u.debugger.Continue)
+	case "b", "f9":
+		u.toggleBreakpoint(u.cursor)
+	case "up":
+		u.moveCursor(-1)
+	case "down":
+		u.moveCursor(1)
+	case "pgup":
+		u.moveCursor(-disassemblyLines)
+	case "pgdn":
+		u.moveCursor(disassemblyLines)
+	case ".", "home":
+		u.follow = true
+	case ":":
+		u.editing, u.input = true, ""
+	case "q":
+		u.quit = true
+	}
+
+	if err != nil {
+		u.message = err.Error()
+	}
+}
+
+// edit handles a key typed in the command line
+func (u *UI) edit(key string) {
+	switch key {
+	case "enter":
+		u.editing = false
+		if err := u.Execute(u.input); err != nil {
+			u.message = err.Error()
+		}
+	case "esc":
+		u.editing = false
+	case "backspace":
+		if len(u.input) > 0 {
+			u.input = u.input[:len(u.input)-1]
+		}
+	default:
+		if len([]rune(key)) == 1 {
+			u.input += key
+		}
+	}
+}
+
+func (u *UI) step(step func() error) error {
+	if u.exited {
+		return chip8.ErrExit
+	}
+
+	u.message, u.follow = "", true
+	err := step()
+	if errors.Is(err, chip8.ErrExit) {
+		u.exited = true
+		u.message = "program exited"
+		return nil
+	}
+
+	return err
+}
+
+func (u *UI) resume(resume func()) {
+	if u.exited {
+		u.message = "program exited"
+		return
+	}
+
+	u.message, u.follow = "", true
+	resume()
+}
+
+func (u *UI) toggleBreakpoint(address uint16) {
+	for _, b := range u.debugger.Breakpoints() {
+		if b == address {
+			u.debugger.ClearBreakpoint(address)
+			return
+		}
+	}
+	u.debugger.SetBreakpoint(address)
+}
+
+func (u *UI) moveCursor(lines int) {
+	if u.follow {
+		u.cursor = u.emulator.PC()
+	}
+	u.follow = false
+	u.cursor = uint16(int(u.cursor) + 2*lines)
+}
+
+// Execute runs a command line, see the help command
+func (u *UI) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	command, args := fields[0], fields[1:]
+	for _, c := range commands {
+		if c.name == command || c.alias == command {
+			if len(args) < c.args || (!c.variadic && len(args) > c.args) {
+				return fmt.Errorf("usage: %s %s", c.name, c.usage)
+			}
+			return c.run(u, args)
+		}
+	}
+
+	return fmt.Errorf("unknown command %q, try help", command)
+}
@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testROM counts V0 up in a subroutine:
+//
+//	0200: CALL 0x0206
+//	0202: JUMP 0x0200
+//	0204: -
+//	0206: ADD V0,1
+//	0208: RTS
+var testROM = []byte{0x22, 0x06, 0x12, 0x00, 0x00, 0x00, 0x70, 0x01, 0x00, 0xee}
+
+func newTestUI(t *testing.T) *UI {
+	c := chip8.NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, c.LoadFont())
+	require.NoError(t, c.LoadROM(bytes.NewReader(testROM)))
+
+	return New(c)
+}
+
+func keys(u *UI, keys ...string) {
+	for _, key := range keys {
+		u.HandleKey(key)
+	}
+}
+
+func TestStartsPaused(t *testing.T) {
+	u := newTestUI(t)
+
+	u.Update()
+	assert.True(t, u.debugger.Paused())
+	assert.Equal(t, uint16(0x200), u.emulator.PC())
+}
+
+func TestStepKeys(t *testing.T) {
+	u := newTestUI(t)
+
+	keys(u, "s")
+	assert.Equal(t, uint16(0x206), u.emulator.PC())
+
+	keys(u, "o")
+	for j := 0; j < 10 && !u.debugger.Paused(); j++ {
+		u.Update()
+	}
+	assert.Equal(t, uint16(0x202), u.emulator.PC())
+	assert.Equal(t, uint8(1), u.emulator.Registers()[0])
+
+	keys(u, "s", "n")
+	for j := 0; j < 10 && !u.debugger.Paused(); j++ {
+		u.Update()
+	}
+	assert.Equal(t, uint16(0x202), u.emulator.PC())
+	assert.Equal(t, uint8(2), u.emulator.Registers()[0])
+}
+
+func TestToggleBreakpointAndContinue(t *testing.T) {
+	u := newTestUI(t)
+
+	keys(u, "down", "down", "down", "b")
+	assert.Equal(t, []uint16{0x206}, u.debugger.Breakpoints())
+
+	keys(u, "c")
+	assert.False(t, u.debugger.Paused())
+	u.Update()
+	stop, paused := u.debugger.Stopped()
+	require.True(t, paused)
+	assert.Equal(t, chip8.Stop{Reason: chip8.StopBreakpoint, PC: 0x206}, stop)
+
+	keys(u, "b")
+	assert.Empty(t, u.debugger.Breakpoints())
+}
+
+func TestRunningKeysGoToKeypad(t *testing.T) {
+	u := newTestUI(t)
+	keys(u, "c", "s")
+
+	assert.False(t, u.debugger.Paused())
+	assert.True(t, u.keypad.Pressed()[0x8])
+
+	keys(u, "esc")
+	assert.True(t, u.debugger.Paused())
+}
+
+func TestCommandLine(t *testing.T) {
+	u := newTestUI(t)
+
+	keys(u, ":", "b", "r", "e", "a", "k", " ", "2", "0", "8", "x", "backspace", "enter")
+	assert.False(t, u.editing)
+	assert.Empty(t, u.message)
+	assert.Equal(t, []uint16{0x208}, u.debugger.Breakpoints())
+}
+
+func TestExecute(t *testing.T) {
+	u := newTestUI(t)
+
+	require.NoError(t, u.Execute("set 0x300 aa BB"))
+	assert.Equal(t, []uint8{0xaa, 0xbb}, []uint8(u.emulator.Memory()[0x300:0x302]))
+	assert.Equal(t, uint16(0x300), u.memory)
+
+	require.NoError(t, u.Execute("reg v3 42"))
+	require.NoError(t, u.Execute("reg I 300"))
+	assert.Equal(t, uint8(0x42), u.emulator.Registers()[3])
+	assert.Equal(t, uint16(0x300), u.emulator.Index())
+
+	require.NoError(t, u.Execute("ob dxyn"))
+	assert.Equal(t, []string{"DXYN"}, u.debugger.OpcodeBreakpoints())
+
+	require.NoError(t, u.Execute("watch V0 == 3"))
+	assert.Equal(t, []chip8.Watchpoint{{ID: 1, Condition: "V0 == 3"}}, u.debugger.Watchpoints())
+	require.NoError(t, u.Execute("uw 1"))
+	assert.Empty(t, u.debugger.Watchpoints())
+
+	require.NoError(t, u.Execute("run 208"))
+	for j := 0; j < 10 && !u.debugger.Paused(); j++ {
+		u.Update()
+	}
+	assert.Equal(t, uint16(0x208), u.emulator.PC())
+}
+
+func TestExecuteErrors(t *testing.T) {
+	u := newTestUI(t)
+
+	assert.ErrorContains(t, u.Execute("jump 200"), "unknown command")
+	assert.ErrorContains(t, u.Execute("break"), "usage: break")
+	assert.ErrorContains(t, u.Execute("break 200 202"), "usage: break")
+	assert.ErrorContains(t, u.Execute("set 300 100"), "invalid value")
+	assert.ErrorContains(t, u.Execute("reg VG 1"), "unknown register")
+	assert.ErrorIs(t, u.Execute("ob DXYZ"), chip8.ErrInvalidOpcodePattern)
+	assert.ErrorIs(t, u.Execute("watch V0 ~ 1"), chip8.ErrInvalidWatchpoint)
+
+	assert.Equal(t, uint8(0), u.emulator.Memory()[0x300])
+}
+
+func TestRender(t *testing.T) {
+	u := newTestUI(t)
+	u.debugger.SetBreakpoint(0x206)
+	require.NoError(t, u.Execute("reg V5 7f"))
+
+	frame := u.Render()
+	assert.Contains(t, frame, "=> 0200  2206  CALL 0x0206")
+	assert.Contains(t, frame, "  *0206  7001  ADD V0,1")
+	assert.Contains(t, frame, "V5 7f")
+	assert.Contains(t, frame, "Break: 0206")
+	assert.Contains(t, frame, "0200  22 06 12 00")
+	assert.Contains(t, frame, "paused at 0200: paused")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"chip8/gdb"
+	"flag"
+	"log/slog"
+)
+
+const defaultGDBAddress = "localhost:1234"
+
+// gdbCommand serves a ROM to GDB remote protocol clients, paused until one continues it
+func gdbCommand(args []string) int {
+	var (
+		machine machineFlags
+		address string
+	)
+
+	flags := flag.NewFlagSet("gdb", flag.ExitOnError)
+	machine.register(flags)
+	flags.StringVar(&address, "listen", defaultGDBAddress, "TCP address to listen on")
+	machine.parse(flags, args)
+
+	log := slog.Default()
+
+	emulator, err := machine.build(log)
+	if err != nil {
+		log.Error(err.Error())
+		return 1
+	}
+
+	if err := gdb.NewServer(emulator, log).ListenAndServe(address); err != nil {
+		log.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
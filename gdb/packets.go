@@ -0,0 +1,104 @@
+package gdb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const interrupt = 0x03
+
+var ErrChecksum = errors.New("packet checksum mismatch")
+
+// event is a packet or an interrupt read from the client, or the error that ended the connection
+type event struct {
+	packet    string
+	interrupt bool
+	err       error
+}
+
+// readEvents reads packets and interrupts until r fails or done is closed. Packets with a wrong checksum are
+// sent as ErrChecksum errors with the packet, so they can be rejected without ending the session
+func readEvents(r io.Reader, events chan<- event, done <-chan struct{}) {
+	br := bufio.NewReader(r)
+	for {
+		e := readEvent(br)
+		select {
+		case events <- e:
+		case <-done:
+			return
+		}
+		if e.err != nil && !errors.Is(e.err, ErrChecksum) {
+			return
+		}
+	}
+}
+
+func readEvent(r *bufio.Reader) event {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return event{err: err}
+		}
+
+		switch b {
+		case interrupt:
+			return event{interrupt: true}
+		case '$':
+			return readPacket(r)
+		}
+		// acks and noise between packets
+	}
+}
+
+func readPacket(r *bufio.Reader) event {
+	data, err := r.ReadBytes('#')
+	if err != nil {
+		return event{err: err}
+	}
+	data = data[:len(data)-1]
+
+	var digits [2]byte
+	if _, err := io.ReadFull(r, digits[:]); err != nil {
+		return event{err: err}
+	}
+
+	sum, err := strconv.ParseUint(string(digits[:]), 16, 8)
+	if err != nil || uint8(sum) != checksum(data) {
+		return event{packet: string(data), err: ErrChecksum}
+	}
+
+	return event{packet: string(unescape(data))}
+}
+
+// unescape undoes the binary escaping of } followed by the byte XOR 0x20
+func unescape(data []byte) []byte {
+	out := data[:0]
+	for j := 0; j < len(data); j++ {
+		if data[j] == '}' && j+1 < len(data) {
+			j++
+			out = append(out, data[j]^0x20)
+		} else {
+			out = append(out, data[j])
+		}
+	}
+
+	return out
+}
+
+func checksum(data []byte) uint8 {
+	var sum uint8
+	for _, b := range data {
+		sum += b
+	}
+
+	return sum
+}
+
+// writePacket frames data as $data#checksum
+func writePacket(w io.Writer, data string) error {
+	_, err := fmt.Fprintf(w, "$%s#%02x", data, checksum([]byte(data)))
+	return err
+}
@@ -0,0 +1,131 @@
+package gdb
+
+import (
+	"chip8/chip8"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownRegister = errors.New("unknown register")
+
+// register numbers as sent in p and P packets, g and G send them all in this order
+const (
+	registerI  = 16
+	registerPC = 17
+	registerSP = 18
+	registerDT = 19
+	registerST = 20
+
+	registerCount = 21
+)
+
+// registerSize returns the size in bytes of a register. Values are sent little-endian, unlike CHIP-8 memory: the
+// target description can't name an architecture for GDB to take a byte order from, so it decodes them as its default
+func registerSize(n int) int {
+	if n == registerI || n == registerPC {
+		return 2
+	}
+	return 1
+}
+
+func readRegister(c *chip8.Chip8, n int) (uint16, error) {
+	switch {
+	case n < registerI:
+		return uint16(c.Registers()[n]), nil
+	case n == registerI:
+		return c.Index(), nil
+	case n == registerPC:
+		return c.PC(), nil
+	case n == registerSP:
+		return uint16(c.StackPointer()), nil
+	case n == registerDT:
+		return uint16(c.DelayTimer()), nil
+	case n == registerST:
+		return uint16(c.SoundTimer()), nil
+	}
+
+	return 0, fmt.Errorf("%w: %d", ErrUnknownRegister, n)
+}
+
+func writeRegister(c *chip8.Chip8, n int, value uint16) error {
+	switch {
+	case n < registerI:
+		c.SetRegister(uint8(n), uint8(value))
+	case n == registerI:
+		c.SetIndex(value)
+	case n == registerPC:
+		c.SetPC(value)
+	case n == registerSP:
+		return c.SetStackPointer(uint8(value))
+	case n == registerDT:
+		c.SetDelayTimer(uint8(value))
+	case n == registerST:
+		c.SetSoundTimer(uint8(value))
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownRegister, n)
+	}
+
+	return nil
+}
+
+// encodeRegister returns the hex of a register value in its size
+func encodeRegister(n int, value uint16) string {
+	if registerSize(n) == 2 {
+		return fmt.Sprintf("%02x%02x", uint8(value), uint8(value>>8))
+	}
+	return fmt.Sprintf("%02x", uint8(value))
+}
+
+// decodeRegister parses the hex of a register value, which must have the register size
+func decodeRegister(n int, text string) (uint16, error) {
+	data, err := hex.DecodeString(text)
+	if err != nil || len(data) != registerSize(n) {
+		return 0, fmt.Errorf("invalid value %q for register %d", text, n)
+	}
+
+	var value uint16
+	for j, b := range data {
+		value |= uint16(b) << (8 * j)
+	}
+
+	return value, nil
+}
+
+// targetXML describes the registers to clients that read target.xml
+var targetXML = func() string {
+	var sb strings.Builder
+
+	sb.WriteString(`<?xml version="1.0"?><!DOCTYPE target SYSTEM "gdb-target.dtd"><target version="1.0">`)
+	sb.WriteString(`<feature name="org.chip8.core">`)
+	for n := 0; n < registerCount; n++ {
+		name, kind := registerName(n), "uint8"
+		if registerSize(n) == 2 {
+			kind = "uint16"
+		}
+		if n == registerPC {
+			kind = "code_ptr"
+		}
+		fmt.Fprintf(&sb, `<reg name="%s" bitsize="%d" type="%s"/>`, name, 8*registerSize(n), kind)
+	}
+	sb.WriteString(`</feature></target>`)
+
+	return sb.String()
+}()
+
+func registerName(n int) string {
+	switch n {
+	case registerI:
+		return "i"
+	case registerPC:
+		return "pc"
+	case registerSP:
+		return "sp"
+	case registerDT:
+		return "dt"
+	case registerST:
+		return "st"
+	}
+	return fmt.Sprintf("v%x", n)
+}
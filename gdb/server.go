@@ -0,0 +1,473 @@
+// Package gdb serves the GDB remote serial protocol, so existing debugger front ends can drive the emulator
+//
+// Registers are numbered V0-VF (0-15), I (16), PC (17), SP (18), DT (19) and ST (20). I and PC are 2 bytes,
+// the others 1, and values are little-endian, the byte order GDB assumes. Clients can also read them from target.xml
+package gdb
+
+import (
+	"chip8/chip8"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	framesPerSecond = 60
+	packetSize      = 0x1000
+)
+
+// stop signals reported to the client
+const (
+	sigint  = 2
+	sigill  = 4
+	sigtrap = 5
+	sigsegv = 11
+)
+
+var ErrKilled = errors.New("killed by the client")
+
+// Server debugs a machine for one client at a time. The machine is paused while the client inspects it and runs
+// at 60 frames per second after a continue, until a breakpoint or an interrupt
+type Server struct {
+	log      *slog.Logger
+	emulator *chip8.Chip8
+	debugger *chip8.Debugger
+	frame    time.Duration
+	exited   bool
+}
+
+// NewServer attaches a debugger to a machine, which stays paused until a client continues it
+func NewServer(emulator *chip8.Chip8, log *slog.Logger) *Server {
+	s := &Server{
+		log:      log,
+		emulator: emulator,
+		debugger: chip8.NewDebugger(emulator),
+		frame:    time.Second / framesPerSecond,
+	}
+	s.debugger.Pause()
+
+	return s
+}
+
+// ListenAndServe accepts clients on a TCP address, like localhost:1234, until one kills the machine
+func (s *Server) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	for {
+		s.log.Info("Waiting for a debugger", slog.String("address", listener.Addr().String()))
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		s.log.Info("Debugger attached", slog.String("client", conn.RemoteAddr().String()))
+		err = s.Serve(conn)
+		_ = conn.Close()
+
+		switch {
+		case errors.Is(err, ErrKilled):
+			return nil
+		case err != nil:
+			s.log.Error("Debugger connection failed", slog.String("error", err.Error()))
+		default:
+			s.log.Info("Debugger detached")
+		}
+	}
+}
+
+// session is the state of one client connection
+type session struct {
+	*Server
+	conn    io.ReadWriter
+	noAck   bool
+	running bool
+}
+
+// Serve talks to a client until it detaches, returning nil, kills the machine, returning ErrKilled,
+// or the connection fails
+func (s *Server) Serve(conn io.ReadWriter) error {
+	events := make(chan event)
+	done := make(chan struct{})
+	defer close(done)
+	go readEvents(conn, events, done)
+
+	ticker := time.NewTicker(s.frame)
+	defer ticker.Stop()
+
+	sess := &session{Server: s, conn: conn}
+	defer func() {
+		if !s.debugger.Paused() {
+			s.debugger.Pause()
+		}
+	}()
+
+	for {
+		var tick <-chan time.Time
+		if sess.running {
+			tick = ticker.C
+		}
+
+		select {
+		case e := <-events:
+			switch {
+			case errors.Is(e.err, ErrChecksum):
+				if err := sess.ack('-'); err != nil {
+					return err
+				}
+			case errors.Is(e.err, io.EOF):
+				return nil
+			case e.err != nil:
+				return e.err
+			case e.interrupt:
+				if sess.running {
+					s.debugger.Pause()
+					if err := sess.stopped(); err != nil {
+						return err
+					}
+				}
+			default:
+				if err := sess.ack('+'); err != nil {
+					return err
+				}
+				reply, err := sess.handle(e.packet)
+				if err != nil {
+					return err
+				}
+				if reply != nil {
+					if err := writePacket(conn, *reply); err != nil {
+						return err
+					}
+				}
+			}
+		case <-tick:
+			if err := sess.update(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *session) ack(b byte) error {
+	if s.noAck {
+		return nil
+	}
+
+	_, err := s.conn.Write([]byte{b})
+	return err
+}
+
+// update runs a frame, replying to the client if the machine stopped
+func (s *session) update() error {
+	err := s.emulator.Update()
+	if errors.Is(err, chip8.ErrExit) {
+		s.exited = true
+		return s.stopped()
+	}
+	if s.debugger.Paused() {
+		return s.stopped()
+	}
+
+	return nil
+}
+
+// stopped tells the client the machine isn't running anymore
+func (s *session) stopped() error {
+	s.running = false
+	return writePacket(s.conn, s.stopReply())
+}
+
+func (s *session) stopReply() string {
+	if s.exited {
+		return "W00"
+	}
+
+	stop, _ := s.debugger.Stopped()
+	switch stop.Reason {
+	case chip8.StopPaused:
+		return fmt.Sprintf("S%02x", sigint)
+	case chip8.StopFault:
+		if errors.Is(stop.Err, chip8.ErrInvalidOpcode) {
+			return fmt.Sprintf("S%02x", sigill)
+		}
+		return fmt.Sprintf("S%02x", sigsegv)
+	}
+
+	return fmt.Sprintf("S%02x", sigtrap)
+}
+
+// handle executes a packet and returns the reply, nil if it comes later, when the machine stops.
+// Unsupported packets get an empty reply and malformed ones an error reply, as the protocol asks
+func (s *session) handle(packet string) (*string, error) {
+	reply := func(text string) (*string, error) {
+		return &text, nil
+	}
+	failed := func(err error) (*string, error) {
+		s.log.Debug("Debugger request failed", slog.String("packet", packet), slog.String("error", err.Error()))
+		return reply("E01")
+	}
+
+	if packet == "" {
+		return reply("")
+	}
+
+	command, args := packet[0], packet[1:]
+	switch command {
+	case '?':
+		return reply(s.stopReply())
+	case 'q', 'Q':
+		return reply(s.query(packet))
+	case 'H', 'T':
+		return reply("OK")
+	case 'g':
+		var sb strings.Builder
+		for n := 0; n < registerCount; n++ {
+			value, _ := readRegister(s.emulator, n)
+			sb.WriteString(encodeRegister(n, value))
+		}
+		return reply(sb.String())
+	case 'G':
+		if err := s.writeRegisters(args); err != nil {
+			return failed(err)
+		}
+		return reply("OK")
+	case 'p':
+		n, err := strconv.ParseUint(args, 16, 8)
+		if err != nil {
+			return failed(err)
+		}
+		value, err := readRegister(s.emulator, int(n))
+		if err != nil {
+			return failed(err)
+		}
+		return reply(encodeRegister(int(n), value))
+	case 'P':
+		if err := s.writeRegister(args); err != nil {
+			return failed(err)
+		}
+		return reply("OK")
+	case 'm':
+		data, err := s.readMemory(args)
+		if err != nil {
+			return failed(err)
+		}
+		return reply(hex.EncodeToString(data))
+	case 'M':
+		if err := s.writeMemory(args); err != nil {
+			return failed(err)
+		}
+		return reply("OK")
+	case 'Z', 'z':
+		return s.breakpoint(command == 'Z', args)
+	case 's', 'c':
+		if s.exited {
+			return failed(chip8.ErrExit)
+		}
+		if args != "" {
+			address, err := strconv.ParseUint(args, 16, 16)
+			if err != nil {
+				return failed(err)
+			}
+			s.emulator.SetPC(uint16(address))
+		}
+		if command == 's' {
+			return s.step()
+		}
+		s.debugger.Continue()
+		s.running = true
+		return nil, nil
+	case 'D':
+		return reply("OK")
+	case 'k':
+		return nil, ErrKilled
+	}
+
+	return reply("")
+}
+
+func (s *session) query(packet string) string {
+	name, args, _ := strings.Cut(packet, ":")
+	switch name {
+	case "qSupported":
+		return fmt.Sprintf("PacketSize=%x;QStartNoAckMode+;qXfer:features:read+", packetSize)
+	case "QStartNoAckMode":
+		// the OK reply itself is still acknowledged
+		s.noAck = true
+		return "OK"
+	case "qAttached":
+		return "1"
+	case "qC":
+		return "QC1"
+	case "qfThreadInfo":
+		return "m1"
+	case "qsThreadInfo":
+		return "l"
+	case "qXfer":
+		return readTargetXML(args)
+	}
+
+	return ""
+}
+
+// readTargetXML replies to qXfer:features:read:target.xml:offset,length with a chunk of the target description
+func readTargetXML(args string) string {
+	spec, ok := strings.CutPrefix(args, "features:read:target.xml:")
+	if !ok {
+		return ""
+	}
+
+	offset, length, err := parseRange(spec)
+	if err != nil {
+		return "E01"
+	}
+	if offset >= len(targetXML) {
+		return "l"
+	}
+
+	end := min(offset+length, len(targetXML))
+	if end == len(targetXML) {
+		return "l" + targetXML[offset:end]
+	}
+	return "m" + targetXML[offset:end]
+}
+
+func (s *session) step() (*string, error) {
+	err := s.debugger.Step()
+	if errors.Is(err, chip8.ErrExit) {
+		s.exited = true
+	}
+
+	reply := s.stopReply()
+	return &reply, nil
+}
+
+func (s *session) writeRegisters(args string) error {
+	for n := 0; n < registerCount; n++ {
+		size := 2 * registerSize(n)
+		if len(args) < size {
+			return fmt.Errorf("missing register %d", n)
+		}
+
+		value, err := decodeRegister(n, args[:size])
+		if err != nil {
+			return err
+		}
+		if err := writeRegister(s.emulator, n, value); err != nil {
+			return err
+		}
+		args = args[size:]
+	}
+
+	return nil
+}
+
+func (s *session) writeRegister(args string) error {
+	number, text, ok := strings.Cut(args, "=")
+	if !ok {
+		return fmt.Errorf("invalid register write %q", args)
+	}
+
+	n, err := strconv.ParseUint(number, 16, 8)
+	if err != nil {
+		return err
+	}
+	value, err := decodeRegister(int(n), text)
+	if err != nil {
+		return err
+	}
+
+	return writeRegister(s.emulator, int(n), value)
+}
+
+func (s *session) readMemory(args string) ([]byte, error) {
+	address, length, err := parseRange(args)
+	if err != nil {
+		return nil, err
+	}
+	if address > 0xFFFF {
+		return nil, fmt.Errorf("invalid address %x", address)
+	}
+
+	return s.emulator.Memory().Slice(uint16(address), min(length, packetSize/2))
+}
+
+func (s *session) writeMemory(args string) error {
+	spec, text, ok := strings.Cut(args, ":")
+	if !ok {
+		return fmt.Errorf("invalid memory write %q", args)
+	}
+
+	address, length, err := parseRange(spec)
+	if err != nil {
+		return err
+	}
+	if address > 0xFFFF {
+		return fmt.Errorf("invalid address %x", address)
+	}
+	data, err := hex.DecodeString(text)
+	if err != nil || len(data) != length {
+		return fmt.Errorf("invalid memory write %q", args)
+	}
+
+	dst, err := s.emulator.Memory().Slice(uint16(address), length)
+	if err != nil {
+		return err
+	}
+	copy(dst, data)
+
+	return nil
+}
+
+// breakpoint sets or clears a breakpoint, hardware ones being the same as software ones
+func (s *session) breakpoint(set bool, args string) (*string, error) {
+	reply := func(text string) (*string, error) {
+		return &text, nil
+	}
+
+	kind, spec, ok := strings.Cut(args, ",")
+	if !ok || (kind != "0" && kind != "1") {
+		return reply("")
+	}
+
+	address, _, err := parseRange(spec)
+	if err != nil || address > 0xFFFF {
+		return reply("E01")
+	}
+
+	if set {
+		s.debugger.SetBreakpoint(uint16(address))
+	} else {
+		s.debugger.ClearBreakpoint(uint16(address))
+	}
+
+	return reply("OK")
+}
+
+// parseRange parses the hex pair address,length
+func parseRange(text string) (int, int, error) {
+	first, second, ok := strings.Cut(text, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q", text)
+	}
+
+	a, err := strconv.ParseUint(first, 16, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q", text)
+	}
+	b, err := strconv.ParseUint(second, 16, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q", text)
+	}
+
+	return int(a), int(b), nil
+}
@@ -0,0 +1,223 @@
+package gdb
+
+import (
+	"bufio"
+	"bytes"
+	"chip8/chip8"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testROM counts V0 up in a subroutine:
+//
+//	0200: CALL 0x0206
+//	0202: JUMP 0x0200
+//	0204: -
+//	0206: ADD V0,1
+//	0208: RTS
+var testROM = []byte{0x22, 0x06, 0x12, 0x00, 0x00, 0x00, 0x70, 0x01, 0x00, 0xee}
+
+// client is a minimal protocol client, acknowledging every reply
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+	done chan error
+}
+
+func newTestClient(t *testing.T, rom ...byte) (*client, *chip8.Chip8) {
+	c := chip8.NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, c.LoadFont())
+	require.NoError(t, c.LoadROM(bytes.NewReader(rom)))
+
+	s := NewServer(c, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.frame = time.Millisecond
+
+	server, conn := net.Pipe()
+	cl := &client{t: t, conn: conn, r: bufio.NewReader(conn), done: make(chan error, 1)}
+	go func() {
+		cl.done <- s.Serve(server)
+		_ = server.Close()
+	}()
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return cl, c
+}
+
+// send sends a packet and returns the reply
+func (c *client) send(packet string) string {
+	c.t.Helper()
+
+	_, err := fmt.Fprintf(c.conn, "$%s#%02x", packet, checksum([]byte(packet)))
+	require.NoError(c.t, err)
+
+	ack, err := c.r.ReadByte()
+	require.NoError(c.t, err)
+	require.Equal(c.t, byte('+'), ack)
+
+	return c.receive()
+}
+
+// receive reads a packet and acknowledges it
+func (c *client) receive() string {
+	c.t.Helper()
+
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err := c.r.ReadString('$')
+	require.NoError(c.t, err)
+	data, err := c.r.ReadString('#')
+	require.NoError(c.t, err)
+	_, err = c.r.Discard(2)
+	require.NoError(c.t, err)
+
+	_, err = c.conn.Write([]byte{'+'})
+	require.NoError(c.t, err)
+
+	return strings.TrimSuffix(data, "#")
+}
+
+func TestHandshake(t *testing.T) {
+	c, _ := newTestClient(t, testROM...)
+
+	assert.Contains(t, c.send("qSupported:multiprocess+;swbreak+"), "qXfer:features:read+")
+	assert.Equal(t, "", c.send("vMustReplyEmpty"))
+	assert.Equal(t, "OK", c.send("Hg0"))
+	assert.Equal(t, "1", c.send("qAttached"))
+	assert.Equal(t, "S02", c.send("?"))
+}
+
+func TestTargetXML(t *testing.T) {
+	c, _ := newTestClient(t, testROM...)
+
+	var xml string
+	for {
+		reply := c.send(fmt.Sprintf("qXfer:features:read:target.xml:%x,40", len(xml)))
+		require.NotEmpty(t, reply)
+		xml += reply[1:]
+		if reply[0] == 'l' {
+			break
+		}
+		require.Equal(t, byte('m'), reply[0])
+	}
+
+	assert.Equal(t, targetXML, xml)
+	assert.Contains(t, xml, `<reg name="pc" bitsize="16" type="code_ptr"/>`)
+}
+
+func TestRegisters(t *testing.T) {
+	c, emulator := newTestClient(t, testROM...)
+	emulator.SetRegister(0xA, 0x42)
+	emulator.SetIndex(0x0123)
+
+	// I and PC are little-endian, the byte order GDB assumes
+	registers := c.send("g")
+	assert.Equal(t, strings.Repeat("00", 10)+"42"+strings.Repeat("00", 5)+"2301"+"0002"+"000000", registers)
+
+	assert.Equal(t, "2301", c.send("p10"))
+	assert.Equal(t, "0002", c.send("p11"))
+	assert.Equal(t, "OK", c.send("P3=7f"))
+	assert.Equal(t, "OK", c.send("P13=30"))
+	assert.Equal(t, "OK", c.send("P10=bc0a"))
+	assert.Equal(t, uint8(0x7f), emulator.Registers()[3])
+	assert.Equal(t, uint8(0x30), emulator.DelayTimer())
+	assert.Equal(t, uint16(0x0abc), emulator.Index())
+
+	assert.Equal(t, "E01", c.send("p15"))
+	assert.Equal(t, "E01", c.send("P10=12"))
+	assert.Equal(t, "E01", c.send("P12=11"))
+
+	registers = strings.Repeat("01", 16) + "0003" + "0602" + "01" + "02" + "03"
+	assert.Equal(t, "OK", c.send("G"+registers))
+	assert.Equal(t, registers, c.send("g"))
+	assert.Equal(t, uint16(0x206), emulator.PC())
+	assert.Equal(t, uint8(3), emulator.SoundTimer())
+}
+
+func TestMemory(t *testing.T) {
+	c, emulator := newTestClient(t, testROM...)
+
+	assert.Equal(t, "22061200", c.send("m200,4"))
+	assert.Equal(t, "OK", c.send("M300,3:a1b2c3"))
+	assert.Equal(t, []byte{0xa1, 0xb2, 0xc3}, []byte(emulator.Memory()[0x300:0x303]))
+
+	assert.Equal(t, "E01", c.send("mfff,2"))
+	assert.Equal(t, "E01", c.send("M300,2:a1"))
+	assert.Equal(t, "E01", c.send("m300"))
+}
+
+func TestStepAndContinue(t *testing.T) {
+	c, emulator := newTestClient(t, testROM...)
+
+	assert.Equal(t, "S05", c.send("s"))
+	assert.Equal(t, uint16(0x206), emulator.PC())
+
+	assert.Equal(t, "OK", c.send("Z0,202,2"))
+	_, err := fmt.Fprintf(c.conn, "$c#%02x", checksum([]byte("c")))
+	require.NoError(t, err)
+	ack, err := c.r.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, byte('+'), ack)
+	assert.Equal(t, "S05", c.receive())
+	assert.Equal(t, "0202", c.send("p11"))
+	assert.Equal(t, "01", c.send("p0"))
+
+	assert.Equal(t, "OK", c.send("z0,202,2"))
+	assert.Equal(t, "", c.send("Z2,300,1"))
+}
+
+func TestInterrupt(t *testing.T) {
+	c, _ := newTestClient(t, testROM...)
+
+	_, err := fmt.Fprintf(c.conn, "$c#%02x", checksum([]byte("c")))
+	require.NoError(t, err)
+	_, err = c.r.ReadByte()
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	_, err = c.conn.Write([]byte{interrupt})
+	require.NoError(t, err)
+	assert.Equal(t, "S02", c.receive())
+}
+
+func TestFaultAndExit(t *testing.T) {
+	c, _ := newTestClient(t, 0x00, 0xee)
+	assert.Equal(t, "S0b", c.send("s"))
+
+	c, _ = newTestClient(t, 0x00, 0xfd)
+	assert.Equal(t, "W00", c.send("s"))
+	assert.Equal(t, "W00", c.send("?"))
+	assert.Equal(t, "E01", c.send("c"))
+}
+
+func TestChecksumAndNoAck(t *testing.T) {
+	c, _ := newTestClient(t, testROM...)
+
+	_, err := c.conn.Write([]byte("$g#00"))
+	require.NoError(t, err)
+	nack, err := c.r.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, byte('-'), nack)
+
+	assert.Equal(t, "OK", c.send("QStartNoAckMode"))
+	_, err = fmt.Fprintf(c.conn, "$p11#%02x", checksum([]byte("p11")))
+	require.NoError(t, err)
+	assert.Equal(t, "0002", c.receive())
+}
+
+func TestKill(t *testing.T) {
+	c, _ := newTestClient(t, testROM...)
+
+	_, err := fmt.Fprintf(c.conn, "$k#%02x", checksum([]byte("k")))
+	require.NoError(t, err)
+	_, err = c.r.ReadByte()
+	require.NoError(t, err)
+	assert.ErrorIs(t, <-c.done, ErrKilled)
+}
@@ -5,6 +5,7 @@ go 1.23.0
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.8.6
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/sys v0.25.0
 )
 
 require (
@@ -16,6 +17,5 @@ require (
 	github.com/jezek/xgb v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"chip8/chip8"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	pcgRandom = "pcg"
+	vipRandom = "vip"
+)
+
+// machineFlags are the flags of every command that runs a ROM
+type machineFlags struct {
+	ipf      int
+	rom      string
+	platform string
+	quirks   string
+	seed     seedFlag
+	random   string
+	vip      string // COSMAC VIP interpreter image the vip random source reads
+}
+
+// seedFlag is a seed that tells whether it was given, 0 being a seed like any other
+type seedFlag struct {
+	value uint64
+	set   bool
+}
+
+func (s *seedFlag) String() string {
+	return strconv.FormatUint(s.value, 10)
+}
+
+func (s *seedFlag) Set(text string) error {
+	value, err := strconv.ParseUint(text, 0, 64)
+	if err != nil {
+		return err
+	}
+
+	s.value, s.set = value, true
+	return nil
+}
+
+func (m *machineFlags) register(flags *flag.FlagSet) {
+	flags.IntVar(&m.ipf, "ipf", defaultIPF, "instructions per frame, at 60 frames per second")
+	flags.StringVar(&m.rom, "rom", defaultRom, "rom path")
+	flags.StringVar(&m.platform, "platform", defaultPlatform, "platform: "+strings.Join(chip8.PlatformNames(), ", "))
+	flags.StringVar(&m.quirks, "quirks", "", "quirks profile overriding the platform ones: "+strings.Join(chip8.QuirkProfileNames(), ", "))
+	flags.Var(&m.seed, "seed", "CXNN random seed, picked at random and logged if not given")
+	flags.StringVar(&m.random, "random", pcgRandom, "CXNN random source: pcg, or vip for the COSMAC VIP interpreter algorithm")
+	flags.StringVar(&m.vip, "vip-interpreter", "", "COSMAC VIP interpreter image, 512 bytes, the vip random source reads")
+}
+
+// parse parses the flags, the only positional argument being the ROM
+func (m *machineFlags) parse(flags *flag.FlagSet, args []string) {
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		m.rom = flags.Arg(0)
+	}
+}
+
+// build creates a machine with the ROM loaded, picking a random seed if none was given
+func (m *machineFlags) build(log *slog.Logger) (*chip8.Chip8, error) {
+	emulator, err := m.machine(log)
+	if err != nil {
+		return nil, err
+	}
+
+	romReader, err := openROM(m.rom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ROM file: %w", err)
+	}
+
+	if err := emulator.LoadROM(romReader); err != nil {
+		return nil, fmt.Errorf("failed to load ROM file: %w", err)
+	}
+
+	return emulator, nil
+}
+
+// machine creates a machine with the font loaded but no ROM, picking a random seed if none was given
+func (m *machineFlags) machine(log *slog.Logger) (*chip8.Chip8, error) {
+	if err := checkIPF(m.ipf); err != nil {
+		return nil, err
+	}
+	if !m.seed.set {
+		m.seed = seedFlag{value: chip8.RandomSeed(), set: true}
+	}
+
+	random, err := m.randomSource()
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := chip8.PlatformByName(m.platform)
+	if err != nil {
+		return nil, err
+	}
+
+	emulator := chip8.NewChip8(log)
+	emulator.SetPlatform(variant)
+	emulator.SetRandom(random)
+	emulator.SetIPF(uint(m.ipf))
+
+	if m.quirks != "" {
+		profile, err := chip8.QuirksByName(m.quirks)
+		if err != nil {
+			return nil, err
+		}
+		emulator.SetQuirks(profile)
+	}
+
+	if err := emulator.LoadFont(); err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+
+	return emulator, nil
+}
+
+// checkIPF rejects IPF values that would run no instruction at all, or overflow into a frame that never ends
+func checkIPF(ipf int) error {
+	if ipf <= 0 {
+		return fmt.Errorf("invalid IPF %d, expected at least 1", ipf)
+	}
+
+	return nil
+}
+
+// randomSource returns the CXNN random source seeded with the seed, the VIP one using its low 16 bits
+func (m *machineFlags) randomSource() (chip8.RandomSource, error) {
+	switch m.random {
+	case pcgRandom:
+		return chip8.NewRandom(m.seed.value), nil
+	case vipRandom:
+		if m.vip == "" {
+			return nil, errors.New("the vip random source needs the interpreter image, given with -vip-interpreter")
+		}
+		interpreter, err := os.ReadFile(m.vip)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read VIP interpreter: %w", err)
+		}
+		return chip8.NewVIPRandom(interpreter, uint16(m.seed.value))
+	default:
+		return nil, fmt.Errorf("unknown random source %q, expected one of: pcg, vip", m.random)
+	}
+}
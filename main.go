@@ -1,53 +1,33 @@
 package main
 
 import (
-	"chip8/chip8"
-	"flag"
-	"log/slog"
+	"fmt"
 	"os"
+	"strings"
 )
 
-const (
-	defaultTPS = 1000
-	defaultRom = "roms/1-chip8-logo.ch8"
-)
-
-var (
-	tps int
-	rom string
-)
+// commands maps subcommand names to their entry points, which take the remaining arguments and return the exit code
+var commands = map[string]func(args []string) int{
+	"run":       runCommand,
+	"asm":       asmCommand,
+	"disasm":    disasmCommand,
+	"debug":     debugCommand,
+	"gdb":       gdbCommand,
+	"dap":       dapCommand,
+	"tracediff": traceDiffCommand,
+}
 
 func main() {
-	flag.IntVar(&tps, "tps", defaultTPS, "ticks per second (clock Hz)")
-	flag.StringVar(&rom, "rom", defaultRom, "rom path")
-	flag.Parse()
-
-	log := slog.Default()
-	log.Info("CHIP-8 starting...", slog.Int("tps", tps))
-
-	emulator := chip8.NewChip8(uint(tps), log)
-
-	if err := emulator.LoadFont(); err != nil {
-		log.Error("Failed to load font")
-		os.Exit(1)
-	}
-
-	romReader, err := os.Open(rom)
-	if err != nil {
-		log.Error("Failed to open ROM file")
-		os.Exit(1)
-	}
-
-	if err := emulator.LoadROM(romReader); err != nil {
-		log.Error("Failed to load ROM file")
-		os.Exit(1)
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	if err := emulator.Run(); err != nil {
-		log.Error(err.Error())
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q, available: run, asm, disasm, debug, gdb, dap, tracediff\n", name)
 		os.Exit(2)
 	}
 
-	log.Info("CHIP-8 stopping...")
-	os.Exit(0)
+	os.Exit(command(args))
 }
@@ -0,0 +1,142 @@
+package octo
+
+import (
+	"fmt"
+	"math"
+)
+
+var binaryOperators = map[string]func(a, b float64) float64{
+	"+":   func(a, b float64) float64 { return a + b },
+	"-":   func(a, b float64) float64 { return a - b },
+	"*":   func(a, b float64) float64 { return a * b },
+	"/":   func(a, b float64) float64 { return a / b },
+	"%":   func(a, b float64) float64 { return math.Mod(a, b) },
+	"&":   func(a, b float64) float64 { return float64(int64(a) & int64(b)) },
+	"|":   func(a, b float64) float64 { return float64(int64(a) | int64(b)) },
+	"^":   func(a, b float64) float64 { return float64(int64(a) ^ int64(b)) },
+	"<<":  func(a, b float64) float64 { return float64(int64(a) << uint64(b)) },
+	">>":  func(a, b float64) float64 { return float64(int64(a) >> uint64(b)) },
+	"pow": math.Pow,
+	"min": math.Min,
+	"max": math.Max,
+	"<":   func(a, b float64) float64 { return truth(a < b) },
+	"<=":  func(a, b float64) float64 { return truth(a <= b) },
+	"==":  func(a, b float64) float64 { return truth(a == b) },
+	"!=":  func(a, b float64) float64 { return truth(a != b) },
+	">=":  func(a, b float64) float64 { return truth(a >= b) },
+	">":   func(a, b float64) float64 { return truth(a > b) },
+}
+
+var unaryOperators = map[string]func(a float64) float64{
+	"-":     func(a float64) float64 { return -a },
+	"~":     func(a float64) float64 { return float64(^int64(a)) },
+	"!":     func(a float64) float64 { return truth(a == 0) },
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"exp":   math.Exp,
+	"log":   math.Log,
+	"abs":   math.Abs,
+	"sqrt":  math.Sqrt,
+	"ceil":  math.Ceil,
+	"floor": math.Floor,
+	"sign": func(a float64) float64 {
+		switch {
+		case a > 0:
+			return 1
+		case a < 0:
+			return -1
+		}
+		return 0
+	},
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// calc evaluates :calc expressions. Like in Octo there is no operator precedence, binary operators are
+// evaluated right to left: { 2 * 3 + 4 } is 14. Use parentheses to group
+type calc struct {
+	c      *compiler
+	tokens []token
+	pos    int
+}
+
+func (c *compiler) calculate(tokens []token) (float64, error) {
+	e := &calc{c: c, tokens: tokens}
+
+	value, err := e.expression()
+	if err != nil {
+		return 0, err
+	}
+	if e.pos != len(e.tokens) {
+		return 0, fmt.Errorf("%w: unexpected %q in expression", ErrSyntax, e.tokens[e.pos].text)
+	}
+
+	return value, nil
+}
+
+func (e *calc) expression() (float64, error) {
+	left, err := e.term()
+	if err != nil || e.pos == len(e.tokens) || e.tokens[e.pos].text == ")" {
+		return left, err
+	}
+
+	op := e.tokens[e.pos].text
+	apply, ok := binaryOperators[op]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown operator %q", ErrSyntax, op)
+	}
+	e.pos++
+
+	right, err := e.expression()
+	if err != nil {
+		return 0, err
+	}
+
+	return apply(left, right), nil
+}
+
+func (e *calc) term() (float64, error) {
+	if e.pos == len(e.tokens) {
+		return 0, fmt.Errorf("%w: incomplete expression", ErrSyntax)
+	}
+
+	text := e.tokens[e.pos].text
+	e.pos++
+
+	if text == "(" {
+		value, err := e.expression()
+		if err != nil {
+			return 0, err
+		}
+		if e.pos == len(e.tokens) || e.tokens[e.pos].text != ")" {
+			return 0, fmt.Errorf("%w: missing )", ErrSyntax)
+		}
+		e.pos++
+		return value, nil
+	}
+
+	if apply, ok := unaryOperators[text]; ok {
+		value, err := e.term()
+		return apply(value), err
+	}
+
+	if text == "@" {
+		address, err := e.term()
+		if err != nil {
+			return 0, err
+		}
+		return float64(e.c.byteAt(int(address))), nil
+	}
+
+	if text == "HERE" {
+		return float64(e.c.here), nil
+	}
+
+	return e.c.constant(text)
+}
@@ -0,0 +1,926 @@
+// Package octo compiles Octo assembly language into ROMs
+//
+// It covers labels, :alias, :const, :calc, :macro, :next, :unpack, :org, :byte and :call, the register
+// operator statements (v0 := 5, v1 += v2, i := long label...), if ... then, if ... begin ... else ... end
+// and loop ... while ... again. Programs start with a jump to the main label
+package octo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Origin is the address ROMs are loaded at
+const Origin = 0x200
+
+const (
+	maxAddress = 0xFFFF
+	// maxExpansions stops macros that expand themselves forever
+	maxExpansions = 100000
+)
+
+var (
+	ErrSyntax      = errors.New("syntax error")
+	ErrUndefined   = errors.New("undefined name")
+	ErrDuplicate   = errors.New("name already defined")
+	ErrOutOfRange  = errors.New("value out of range")
+	ErrUnbalanced  = errors.New("unbalanced block")
+	ErrMissingMain = errors.New("missing main label")
+)
+
+// Error is a compilation error with the source line it was found at
+type Error struct {
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type macro struct {
+	args []string
+	body []token
+}
+
+type patchKind uint8
+
+const (
+	patchNNN      patchKind = iota // low 12 bits of an opcode
+	patchWord                      // both bytes
+	patchNibble                    // low nibble of a byte, bits 8-11 of the value
+	patchHighByte                  // a byte, bits 8-15 of the value
+	patchLowByte                   // a byte, bits 0-7 of the value
+)
+
+// patch fills a value into the ROM once every label is known
+type patch struct {
+	address int
+	kind    patchKind
+	name    string
+	value   int
+	line    int
+}
+
+type blockKind uint8
+
+const (
+	blockIf blockKind = iota
+	blockElse
+	blockLoop
+)
+
+// block is an open if or loop, jump is the JUMP of an if waiting for its else or end
+type block struct {
+	kind   blockKind
+	jump   int
+	start  int
+	breaks []int
+}
+
+type compiler struct {
+	stream     *stream
+	rom        []byte
+	here       int
+	labels     map[string]int
+	constants  map[string]float64
+	aliases    map[string]uint8
+	macros     map[string]macro
+	patches    []patch
+	blocks     []block
+	expansions int
+	lines      map[int]uint16
+}
+
+// Compile reads Octo source and returns the ROM it describes, loaded at Origin
+func Compile(r io.Reader) ([]byte, error) {
+	rom, _, err := CompileWithLines(r)
+	return rom, err
+}
+
+// CompileWithLines is Compile also returning the address of the first byte each source line emits, for debuggers.
+// Macro expansions belong to the line that invokes them
+func CompileWithLines(r io.Reader) ([]byte, map[int]uint16, error) {
+	tokens, err := tokenize(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := &compiler{
+		stream:    &stream{tokens: tokens},
+		here:      Origin,
+		labels:    map[string]int{},
+		constants: map[string]float64{},
+		aliases:   map[string]uint8{},
+		macros:    map[string]macro{},
+		lines:     map[int]uint16{},
+	}
+
+	// jump main
+	c.patches = append(c.patches, patch{address: c.here, kind: patchNNN, name: "main", line: 1})
+	c.emit(0x10, 0x00)
+
+	for !c.stream.done() {
+		if err := c.statement(); err != nil {
+			return nil, nil, &Error{Line: c.stream.line, Err: err}
+		}
+	}
+
+	if len(c.blocks) > 0 {
+		return nil, nil, &Error{Line: c.stream.line, Err: fmt.Errorf("%w: missing end or again", ErrUnbalanced)}
+	}
+
+	if _, ok := c.labels["main"]; !ok {
+		return nil, nil, &Error{Line: 1, Err: ErrMissingMain}
+	}
+
+	for _, p := range c.patches {
+		if err := c.apply(p); err != nil {
+			return nil, nil, &Error{Line: p.line, Err: err}
+		}
+	}
+
+	return c.rom, c.lines, nil
+}
+
+func (c *compiler) statement() error {
+	t := c.stream.next()
+
+	switch t.text {
+	case ":":
+		return c.defineLabel(c.stream.next().text, c.here)
+	case ":next":
+		return c.defineLabel(c.stream.next().text, c.here+1)
+	case ":alias":
+		name := c.stream.next().text
+		r, err := c.register(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		if err := c.checkName(name); err != nil {
+			return err
+		}
+		c.aliases[name] = r
+		return nil
+	case ":const":
+		name := c.stream.next().text
+		value, err := c.number(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		return c.defineConstant(name, value)
+	case ":calc":
+		name := c.stream.next().text
+		value, err := c.braces()
+		if err != nil {
+			return err
+		}
+		return c.defineConstant(name, value)
+	case ":macro":
+		return c.defineMacro()
+	case ":unpack":
+		return c.unpack()
+	case ":org":
+		address, err := c.value(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		if address < Origin || address > maxAddress {
+			return fmt.Errorf("%w: :org 0x%x", ErrOutOfRange, address)
+		}
+		c.here = address
+		return nil
+	case ":byte":
+		return c.data(c.stream.next().text)
+	case ":call":
+		return c.instructionNNN(0x2000, c.stream.next().text)
+	case ":breakpoint":
+		c.stream.next()
+		return nil
+	case ":monitor":
+		c.stream.next()
+		c.stream.next()
+		return nil
+	case ";", "return":
+		return c.emit(0x00, 0xEE)
+	case "clear":
+		return c.emit(0x00, 0xE0)
+	case "hires":
+		return c.emit(0x00, 0xFF)
+	case "lores":
+		return c.emit(0x00, 0xFE)
+	case "scroll-right":
+		return c.emit(0x00, 0xFB)
+	case "scroll-left":
+		return c.emit(0x00, 0xFC)
+	case "exit":
+		return c.emit(0x00, 0xFD)
+	case "audio":
+		return c.emit(0xF0, 0x02)
+	case "scroll-down", "scroll-up", "plane":
+		n, err := c.bits(c.stream.next().text, 0xF)
+		if err != nil {
+			return err
+		}
+		switch t.text {
+		case "scroll-down":
+			return c.emit(0x00, 0xC0|uint8(n))
+		case "scroll-up":
+			return c.emit(0x00, 0xD0|uint8(n))
+		}
+		return c.emit(0xF0|uint8(n), 0x01)
+	case "bcd", "saveflags", "loadflags":
+		x, err := c.register(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		return c.emit(0xF0|x, map[string]uint8{"bcd": 0x33, "saveflags": 0x75, "loadflags": 0x85}[t.text])
+	case "save", "load":
+		return c.saveLoad(t.text)
+	case "sprite":
+		return c.sprite()
+	case "jump":
+		return c.instructionNNN(0x1000, c.stream.next().text)
+	case "jump0":
+		return c.instructionNNN(0xB000, c.stream.next().text)
+	case "native":
+		return c.instructionNNN(0x0000, c.stream.next().text)
+	case "delay", "buzzer", "pitch":
+		if op := c.stream.next().text; op != ":=" {
+			return fmt.Errorf("%w: expected := after %s, got %q", ErrSyntax, t.text, op)
+		}
+		x, err := c.register(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		return c.emit(0xF0|x, map[string]uint8{"delay": 0x15, "buzzer": 0x18, "pitch": 0x3A}[t.text])
+	case "i":
+		return c.index()
+	case "if":
+		return c.conditional()
+	case "else":
+		return c.elseBlock()
+	case "end":
+		return c.endBlock()
+	case "loop":
+		c.blocks = append(c.blocks, block{kind: blockLoop, start: c.here})
+		return nil
+	case "while":
+		return c.while()
+	case "again":
+		return c.again()
+	}
+
+	if x, err := c.register(t.text); err == nil {
+		return c.registerOperation(x)
+	}
+
+	if m, ok := c.macros[t.text]; ok {
+		return c.expand(m)
+	}
+
+	if _, constant := c.constants[t.text]; constant || isNumber(t.text) || t.text == "{" {
+		return c.data(t.text)
+	}
+
+	if strings.HasPrefix(t.text, ":") {
+		return fmt.Errorf("%w: unexpected %q", ErrSyntax, t.text)
+	}
+
+	// a bare name calls a subroutine
+	return c.instructionNNN(0x2000, t.text)
+}
+
+// registerOperation compiles vx := ..., vx += ... and the other register operators
+func (c *compiler) registerOperation(x uint8) error {
+	op := c.stream.next().text
+	operand := c.stream.next().text
+
+	if y, err := c.register(operand); err == nil {
+		codes := map[string]uint8{":=": 0x0, "|=": 0x1, "&=": 0x2, "^=": 0x3, "+=": 0x4, "-=": 0x5, ">>=": 0x6, "=-": 0x7, "<<=": 0xE}
+		code, ok := codes[op]
+		if !ok {
+			return fmt.Errorf("%w: unknown operator %q", ErrSyntax, op)
+		}
+		return c.emit(0x80|x, y<<4|code)
+	}
+
+	switch {
+	case op == ":=" && operand == "delay":
+		return c.emit(0xF0|x, 0x07)
+	case op == ":=" && operand == "key":
+		return c.emit(0xF0|x, 0x0A)
+	case op == ":=" && operand == "random":
+		nn, err := c.byteValue(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		return c.emit(0xC0|x, nn)
+	}
+
+	nn, err := c.byteValue(operand)
+	if err != nil {
+		return err
+	}
+
+	switch op {
+	case ":=":
+		return c.emit(0x60|x, nn)
+	case "+=":
+		return c.emit(0x70|x, nn)
+	case "-=":
+		return c.emit(0x70|x, -nn)
+	}
+
+	return fmt.Errorf("%w: operator %q needs a register", ErrSyntax, op)
+}
+
+// index compiles i := nnn, i := long nnnn, i := hex vx, i := bighex vx and i += vx
+func (c *compiler) index() error {
+	op := c.stream.next().text
+	operand := c.stream.next().text
+
+	switch {
+	case op == "+=":
+		x, err := c.register(operand)
+		if err != nil {
+			return err
+		}
+		return c.emit(0xF0|x, 0x1E)
+	case op != ":=":
+		return fmt.Errorf("%w: unknown operator %q for i", ErrSyntax, op)
+	case operand == "hex" || operand == "bighex":
+		x, err := c.register(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		if operand == "hex" {
+			return c.emit(0xF0|x, 0x29)
+		}
+		return c.emit(0xF0|x, 0x30)
+	case operand == "long":
+		if err := c.emit(0xF0, 0x00); err != nil {
+			return err
+		}
+		if err := c.refer(c.stream.next().text, c.here, patchWord); err != nil {
+			return err
+		}
+		return c.emit(0x00, 0x00)
+	}
+
+	return c.instructionNNN(0xA000, operand)
+}
+
+func (c *compiler) saveLoad(name string) error {
+	x, err := c.register(c.stream.next().text)
+	if err != nil {
+		return err
+	}
+
+	if c.stream.peek() == "-" {
+		c.stream.next()
+		y, err := c.register(c.stream.next().text)
+		if err != nil {
+			return err
+		}
+		if name == "save" {
+			return c.emit(0x50|x, y<<4|0x2)
+		}
+		return c.emit(0x50|x, y<<4|0x3)
+	}
+
+	if name == "save" {
+		return c.emit(0xF0|x, 0x55)
+	}
+	return c.emit(0xF0|x, 0x65)
+}
+
+func (c *compiler) sprite() error {
+	x, err := c.register(c.stream.next().text)
+	if err != nil {
+		return err
+	}
+	y, err := c.register(c.stream.next().text)
+	if err != nil {
+		return err
+	}
+	n, err := c.bits(c.stream.next().text, 0xF)
+	if err != nil {
+		return err
+	}
+
+	return c.emit(0xD0|x, y<<4|uint8(n))
+}
+
+// unpack compiles :unpack n label, loading v0 with n and the high nibble of label and v1 with its low byte
+// :unpack long label loads v0 with the whole high byte instead
+func (c *compiler) unpack() error {
+	high := c.stream.next().text
+	name := c.stream.next().text
+
+	kind := patchHighByte
+	var nibble uint8
+	if high != "long" {
+		n, err := c.bits(high, 0xF)
+		if err != nil {
+			return err
+		}
+		kind, nibble = patchNibble, uint8(n)<<4
+	}
+
+	if err := c.refer(name, c.here+1, kind); err != nil {
+		return err
+	}
+	if err := c.emit(0x60, nibble); err != nil {
+		return err
+	}
+	if err := c.refer(name, c.here+1, patchLowByte); err != nil {
+		return err
+	}
+	return c.emit(0x61, 0x00)
+}
+
+// condition returns the instructions testing a condition and the skips taken when it's true or false
+// Comparisons with <, >, <= and >= subtract into vf, which is clobbered
+func (c *compiler) condition() (prefix []byte, skipTrue, skipFalse uint16, err error) {
+	x, err := c.register(c.stream.next().text)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	op := c.stream.next().text
+	switch op {
+	case "key":
+		return nil, 0xE09E | uint16(x)<<8, 0xE0A1 | uint16(x)<<8, nil
+	case "-key":
+		return nil, 0xE0A1 | uint16(x)<<8, 0xE09E | uint16(x)<<8, nil
+	}
+
+	operand := c.stream.next().text
+	y, isRegister := uint8(0), false
+	if r, err := c.register(operand); err == nil {
+		y, isRegister = r, true
+	}
+
+	var nn uint8
+	if !isRegister {
+		if nn, err = c.byteValue(operand); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	switch op {
+	case "==", "!=":
+		equal, notEqual := 0x3000|uint16(x)<<8|uint16(nn), 0x4000|uint16(x)<<8|uint16(nn)
+		if isRegister {
+			equal, notEqual = 0x5000|uint16(x)<<8|uint16(y)<<4, 0x9000|uint16(x)<<8|uint16(y)<<4
+		}
+		if op == "==" {
+			return nil, equal, notEqual, nil
+		}
+		return nil, notEqual, equal, nil
+	case "<", ">", "<=", ">=":
+		// after these vf holds the no borrow flag: x >= operand for < and >=, operand >= x for > and <=
+		ge := op == "<" || op == ">="
+		switch {
+		case isRegister && ge:
+			prefix = []byte{0x8F, x << 4, 0x8F, y<<4 | 0x5}
+		case isRegister:
+			prefix = []byte{0x8F, x << 4, 0x8F, y<<4 | 0x7}
+		case ge:
+			prefix = []byte{0x6F, nn, 0x8F, x<<4 | 0x7}
+		default:
+			prefix = []byte{0x6F, nn, 0x8F, x<<4 | 0x5}
+		}
+		flagSet, flagClear := uint16(0x3F01), uint16(0x4F01)
+		if op == ">=" || op == "<=" {
+			return prefix, flagSet, flagClear, nil
+		}
+		return prefix, flagClear, flagSet, nil
+	}
+
+	return nil, 0, 0, fmt.Errorf("%w: unknown comparison %q", ErrSyntax, op)
+}
+
+func (c *compiler) conditional() error {
+	prefix, skipTrue, skipFalse, err := c.condition()
+	if err != nil {
+		return err
+	}
+	if err := c.emit(prefix...); err != nil {
+		return err
+	}
+
+	switch word := c.stream.next().text; word {
+	case "then":
+		return c.emitWord(skipFalse)
+	case "begin":
+		if err := c.emitWord(skipTrue); err != nil {
+			return err
+		}
+		c.blocks = append(c.blocks, block{kind: blockIf, jump: c.here})
+		return c.emit(0x10, 0x00)
+	default:
+		return fmt.Errorf("%w: expected then or begin, got %q", ErrSyntax, word)
+	}
+}
+
+func (c *compiler) elseBlock() error {
+	if len(c.blocks) == 0 || c.blocks[len(c.blocks)-1].kind != blockIf {
+		return fmt.Errorf("%w: else without if ... begin", ErrUnbalanced)
+	}
+
+	b := &c.blocks[len(c.blocks)-1]
+	jump := c.here
+	if err := c.emit(0x10, 0x00); err != nil {
+		return err
+	}
+	c.jumpTo(b.jump, c.here)
+	b.kind, b.jump = blockElse, jump
+	return nil
+}
+
+func (c *compiler) endBlock() error {
+	if len(c.blocks) == 0 || c.blocks[len(c.blocks)-1].kind == blockLoop {
+		return fmt.Errorf("%w: end without if ... begin", ErrUnbalanced)
+	}
+
+	c.jumpTo(c.blocks[len(c.blocks)-1].jump, c.here)
+	c.blocks = c.blocks[:len(c.blocks)-1]
+	return nil
+}
+
+func (c *compiler) while() error {
+	loop := -1
+	for j := len(c.blocks) - 1; j >= 0 && loop < 0; j-- {
+		if c.blocks[j].kind == blockLoop {
+			loop = j
+		}
+	}
+	if loop < 0 {
+		return fmt.Errorf("%w: while outside a loop", ErrUnbalanced)
+	}
+
+	prefix, skipTrue, _, err := c.condition()
+	if err != nil {
+		return err
+	}
+	if err := c.emit(prefix...); err != nil {
+		return err
+	}
+	if err := c.emitWord(skipTrue); err != nil {
+		return err
+	}
+
+	c.blocks[loop].breaks = append(c.blocks[loop].breaks, c.here)
+	return c.emit(0x10, 0x00)
+}
+
+func (c *compiler) again() error {
+	if len(c.blocks) == 0 || c.blocks[len(c.blocks)-1].kind != blockLoop {
+		return fmt.Errorf("%w: again without loop", ErrUnbalanced)
+	}
+
+	b := c.blocks[len(c.blocks)-1]
+	c.blocks = c.blocks[:len(c.blocks)-1]
+
+	if err := c.emitWord(0x1000 | uint16(b.start)); err != nil {
+		return err
+	}
+	for _, jump := range b.breaks {
+		c.jumpTo(jump, c.here)
+	}
+	return nil
+}
+
+func (c *compiler) defineMacro() error {
+	name := c.stream.next().text
+	if err := c.checkName(name); err != nil {
+		return err
+	}
+
+	var m macro
+	for {
+		t := c.stream.next()
+		if t.text == "{" {
+			break
+		}
+		if t.text == "" {
+			return fmt.Errorf("%w: macro %s has no body", ErrSyntax, name)
+		}
+		m.args = append(m.args, t.text)
+	}
+
+	body, ok := c.stream.block()
+	if !ok {
+		return fmt.Errorf("%w: missing } in macro %s", ErrUnbalanced, name)
+	}
+	m.body = body
+
+	c.macros[name] = m
+	return nil
+}
+
+// expand replaces a macro invocation with its body, arguments substituted
+func (c *compiler) expand(m macro) error {
+	c.expansions++
+	if c.expansions > maxExpansions {
+		return fmt.Errorf("%w: too many macro expansions", ErrSyntax)
+	}
+
+	args := map[string]string{}
+	for _, name := range m.args {
+		args[name] = c.stream.next().text
+	}
+
+	body := make([]token, len(m.body))
+	for j, t := range m.body {
+		if arg, ok := args[t.text]; ok {
+			t.text = arg
+		}
+		body[j] = token{text: t.text, line: c.stream.line}
+	}
+
+	c.stream.insert(body)
+	return nil
+}
+
+// data emits a byte, negative values down to -128 are two's complement
+func (c *compiler) data(text string) error {
+	b, err := c.byteValue(text)
+	if err != nil {
+		return err
+	}
+	return c.emit(b)
+}
+
+// instructionNNN emits an opcode whose low 12 bits are an address, labels may be defined later
+func (c *compiler) instructionNNN(opcode uint16, operand string) error {
+	if err := c.refer(operand, c.here, patchNNN); err != nil {
+		return err
+	}
+	return c.emitWord(opcode)
+}
+
+// refer records a value to fill in at address, names not defined yet are taken to be labels
+func (c *compiler) refer(text string, address int, kind patchKind) error {
+	p := patch{address: address, kind: kind, line: c.stream.line}
+
+	value, err := c.value(text)
+	switch {
+	case err == nil:
+		p.value = value
+	case errors.Is(err, ErrUndefined) && !isNumber(text):
+		p.name = text
+	default:
+		return err
+	}
+
+	c.patches = append(c.patches, p)
+	return nil
+}
+
+func (c *compiler) apply(p patch) error {
+	value := p.value
+	if p.name != "" {
+		label, ok := c.labels[p.name]
+		if !ok {
+			return fmt.Errorf("%w %q", ErrUndefined, p.name)
+		}
+		value = label
+	}
+
+	limit := 0xFFFF
+	if p.kind == patchNNN || p.kind == patchNibble {
+		limit = 0xFFF
+	}
+	if value < 0 || value > limit {
+		return fmt.Errorf("%w: address 0x%x is greater than 0x%x", ErrOutOfRange, value, limit)
+	}
+
+	offset := p.address - Origin
+	switch p.kind {
+	case patchNNN:
+		c.rom[offset] = c.rom[offset]&0xF0 | uint8(value>>8)
+		c.rom[offset+1] = uint8(value)
+	case patchWord:
+		c.rom[offset] = uint8(value >> 8)
+		c.rom[offset+1] = uint8(value)
+	case patchNibble:
+		c.rom[offset] = c.rom[offset]&0xF0 | uint8(value>>8)
+	case patchHighByte:
+		c.rom[offset] = uint8(value >> 8)
+	case patchLowByte:
+		c.rom[offset] = uint8(value)
+	}
+
+	return nil
+}
+
+// jumpTo points the JUMP emitted at address to target
+func (c *compiler) jumpTo(address, target int) {
+	c.rom[address-Origin] = 0x10 | uint8(target>>8)
+	c.rom[address-Origin+1] = uint8(target)
+}
+
+func (c *compiler) emit(data ...byte) error {
+	for _, b := range data {
+		if c.here > maxAddress {
+			return fmt.Errorf("%w: program doesn't fit in memory", ErrOutOfRange)
+		}
+
+		if _, ok := c.lines[c.stream.line]; !ok && c.stream.line > 0 {
+			c.lines[c.stream.line] = uint16(c.here)
+		}
+
+		offset := c.here - Origin
+		if offset >= len(c.rom) {
+			c.rom = append(c.rom, make([]byte, offset+1-len(c.rom))...)
+		}
+		c.rom[offset] = b
+		c.here++
+	}
+
+	return nil
+}
+
+func (c *compiler) emitWord(opcode uint16) error {
+	return c.emit(uint8(opcode>>8), uint8(opcode))
+}
+
+func (c *compiler) byteAt(address int) uint8 {
+	offset := address - Origin
+	if offset < 0 || offset >= len(c.rom) {
+		return 0
+	}
+	return c.rom[offset]
+}
+
+func (c *compiler) defineLabel(name string, address int) error {
+	if err := c.checkName(name); err != nil {
+		return err
+	}
+	c.labels[name] = address
+	return nil
+}
+
+func (c *compiler) defineConstant(name string, value float64) error {
+	if err := c.checkName(name); err != nil {
+		return err
+	}
+	c.constants[name] = value
+	return nil
+}
+
+// checkName rejects names that are empty, numbers, registers, punctuation or already defined
+func (c *compiler) checkName(name string) error {
+	if name == "" || isNumber(name) || isRegister(name) || strings.HasPrefix(name, ":") || strings.ContainsAny(name, "{}()") {
+		return fmt.Errorf("%w: invalid name %q", ErrSyntax, name)
+	}
+
+	_, label := c.labels[name]
+	_, constant := c.constants[name]
+	_, alias := c.aliases[name]
+	_, macro := c.macros[name]
+	if label || constant || alias || macro {
+		return fmt.Errorf("%w %q", ErrDuplicate, name)
+	}
+
+	return nil
+}
+
+// register reads v0 to vf or an alias
+func (c *compiler) register(text string) (uint8, error) {
+	if r, ok := c.aliases[text]; ok {
+		return r, nil
+	}
+	if !isRegister(text) {
+		return 0, fmt.Errorf("%w: expected a register, got %q", ErrSyntax, text)
+	}
+
+	r, _ := strconv.ParseUint(text[1:], 16, 4)
+	return uint8(r), nil
+}
+
+// value returns the integer value of a number, constant, label already defined or { expression }
+func (c *compiler) value(text string) (int, error) {
+	value, err := c.number(text)
+	return int(math.Floor(value)), err
+}
+
+func (c *compiler) number(text string) (float64, error) {
+	if text == "{" {
+		return c.braces()
+	}
+	return c.constant(text)
+}
+
+// constant returns the value of a number literal, constant or label already defined
+func (c *compiler) constant(text string) (float64, error) {
+	if value, ok := parseNumber(text); ok {
+		return value, nil
+	}
+	if value, ok := c.constants[text]; ok {
+		return value, nil
+	}
+	if value, ok := c.labels[text]; ok {
+		return float64(value), nil
+	}
+
+	if text == "" {
+		return 0, fmt.Errorf("%w: unexpected end of source", ErrSyntax)
+	}
+	return 0, fmt.Errorf("%w %q", ErrUndefined, text)
+}
+
+// braces evaluates the expression between an already consumed { and its }
+func (c *compiler) braces() (float64, error) {
+	if c.stream.peek() == "{" {
+		c.stream.next()
+	}
+
+	tokens, ok := c.stream.block()
+	if !ok {
+		return 0, fmt.Errorf("%w: missing }", ErrUnbalanced)
+	}
+	return c.calculate(tokens)
+}
+
+// bits returns a value that must fit in max
+func (c *compiler) bits(text string, max int) (int, error) {
+	value, err := c.value(text)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 || value > max {
+		return 0, fmt.Errorf("%w: %d is greater than %d", ErrOutOfRange, value, max)
+	}
+	return value, nil
+}
+
+// byteValue returns a byte, negative values down to -128 are two's complement
+func (c *compiler) byteValue(text string) (uint8, error) {
+	value, err := c.value(text)
+	if err != nil {
+		return 0, err
+	}
+	if value < -128 || value > 255 {
+		return 0, fmt.Errorf("%w: %d doesn't fit in a byte", ErrOutOfRange, value)
+	}
+	return uint8(value), nil
+}
+
+func isRegister(text string) bool {
+	if len(text) != 2 || (text[0] != 'v' && text[0] != 'V') {
+		return false
+	}
+	_, err := strconv.ParseUint(text[1:], 16, 4)
+	return err == nil
+}
+
+func isNumber(text string) bool {
+	_, ok := parseNumber(text)
+	return ok
+}
+
+// parseNumber reads decimal, 0x hexadecimal and 0b binary numbers, optionally negative
+func parseNumber(text string) (float64, bool) {
+	digits, negative := strings.CutPrefix(text, "-")
+
+	base := 10
+	switch {
+	case strings.HasPrefix(digits, "0x"), strings.HasPrefix(digits, "0X"):
+		base, digits = 16, digits[2:]
+	case strings.HasPrefix(digits, "0b"), strings.HasPrefix(digits, "0B"):
+		base, digits = 2, digits[2:]
+	}
+
+	value, err := strconv.ParseUint(digits, base, 32)
+	if err != nil {
+		if base != 10 {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(digits, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || strings.ContainsAny(digits, "nNiI") {
+			return 0, false
+		}
+		return sign(f, negative), true
+	}
+
+	return sign(float64(value), negative), true
+}
+
+func sign(value float64, negative bool) float64 {
+	if negative {
+		return -value
+	}
+	return value
+}
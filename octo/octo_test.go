@@ -0,0 +1,227 @@
+package octo
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compile(t *testing.T, source string) []byte {
+	t.Helper()
+
+	rom, err := Compile(strings.NewReader(source))
+	require.NoError(t, err)
+	return rom
+}
+
+func TestCompileStatements(t *testing.T) {
+	tests := map[string][]byte{
+		"clear return ;":               {0x00, 0xe0, 0x00, 0xee, 0x00, 0xee},
+		"v3 := 0x2a v3 += 1 v3 -= 1":   {0x63, 0x2a, 0x73, 0x01, 0x73, 0xff},
+		"v1 := v2 v1 |= v2 v1 &= v2":   {0x81, 0x20, 0x81, 0x21, 0x81, 0x22},
+		"v1 ^= v2 v1 += v2 v1 -= v2":   {0x81, 0x23, 0x81, 0x24, 0x81, 0x25},
+		"v1 >>= v2 v1 =- v2 v1 <<= v2": {0x81, 0x26, 0x81, 0x27, 0x81, 0x2e},
+		"v4 := random 0x0f":            {0xc4, 0x0f},
+		"v5 := delay v5 := key":        {0xf5, 0x07, 0xf5, 0x0a},
+		"delay := v6 buzzer := v6":     {0xf6, 0x15, 0xf6, 0x18},
+		"i := 0x300 i += v7":           {0xa3, 0x00, 0xf7, 0x1e},
+		"i := hex v8 i := bighex v8":   {0xf8, 0x29, 0xf8, 0x30},
+		"i := long 0x1234":             {0xf0, 0x00, 0x12, 0x34},
+		"bcd v9 save v9 load v9":       {0xf9, 0x33, 0xf9, 0x55, 0xf9, 0x65},
+		"save v1 - v3 load v3 - v1":    {0x51, 0x32, 0x53, 0x13},
+		"saveflags va loadflags va":    {0xfa, 0x75, 0xfa, 0x85},
+		"sprite v0 v1 5":               {0xd0, 0x15},
+		"jump 0x300 jump0 0x300":       {0x13, 0x00, 0xb3, 0x00},
+		"native 0x300 :call 0x300":     {0x03, 0x00, 0x23, 0x00},
+		"hires lores exit":             {0x00, 0xff, 0x00, 0xfe, 0x00, 0xfd},
+		"scroll-down 4 scroll-up 2":    {0x00, 0xc4, 0x00, 0xd2},
+		"scroll-left scroll-right":     {0x00, 0xfc, 0x00, 0xfb},
+		"plane 3 audio pitch := v1":    {0xf3, 0x01, 0xf0, 0x02, 0xf1, 0x3a},
+		"1 0xff 0b101 -1 :byte 7":      {0x01, 0xff, 0x05, 0xff, 0x07},
+	}
+
+	for source, expected := range tests {
+		rom := compile(t, ": main "+source)
+		assert.Equal(t, append([]byte{0x12, 0x02}, expected...), rom, source)
+	}
+}
+
+func TestCompileWithLines(t *testing.T) {
+	source := ": main\n  v0 := 1\n\n  loop v0 += 1 again\n: data\n  1 2\n"
+
+	_, lines, err := CompileWithLines(strings.NewReader(source))
+	require.NoError(t, err)
+	assert.Equal(t, map[int]uint16{2: 0x202, 4: 0x204, 6: 0x208}, lines)
+}
+
+func TestCompileLabels(t *testing.T) {
+	source := `
+: sub
+	return
+: main
+	sub
+	i := data
+	jump main
+: data
+	0x3c 0x42
+`
+
+	assert.Equal(t, []byte{
+		0x12, 0x04, // jump main
+		0x00, 0xee, // sub
+		0x22, 0x02, // main
+		0xa2, 0x0a,
+		0x12, 0x04,
+		0x3c, 0x42, // data
+	}, compile(t, source))
+}
+
+func TestCompileDirectives(t *testing.T) {
+	source := `
+:alias x v3
+:const SPEED 2
+:calc DOUBLE { SPEED * 2 + 1 }
+:macro move r amount { r += amount }
+: main
+	x := SPEED
+	x := DOUBLE
+	move x 4
+	:next target v0 := 0
+	:unpack 0xa target
+	:unpack long target
+	:org 0x220
+	:byte { HERE - 0x200 }
+`
+
+	assert.Equal(t, []byte{
+		0x12, 0x02,
+		0x63, 0x02, // x := SPEED
+		0x63, 0x06, // 2 * (2 + 1), right to left
+		0x73, 0x04, // move x 4
+		0x60, 0x00, // :next target, target is 0x0209
+		0x60, 0xa2, 0x61, 0x09, // :unpack 0xa target
+		0x60, 0x02, 0x61, 0x09, // :unpack long target
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x20, // :byte at 0x220
+	}, compile(t, source))
+}
+
+func TestCompileConditionals(t *testing.T) {
+	tests := map[string][]byte{
+		"if v1 == 5 then clear":  {0x41, 0x05, 0x00, 0xe0},
+		"if v1 != 5 then clear":  {0x31, 0x05, 0x00, 0xe0},
+		"if v1 == v2 then clear": {0x91, 0x20, 0x00, 0xe0},
+		"if v1 != v2 then clear": {0x51, 0x20, 0x00, 0xe0},
+		"if v1 key then clear":   {0xe1, 0xa1, 0x00, 0xe0},
+		"if v1 -key then clear":  {0xe1, 0x9e, 0x00, 0xe0},
+		"if v1 < v2 then clear":  {0x8f, 0x10, 0x8f, 0x25, 0x3f, 0x01, 0x00, 0xe0},
+		"if v1 >= v2 then clear": {0x8f, 0x10, 0x8f, 0x25, 0x4f, 0x01, 0x00, 0xe0},
+		"if v1 > v2 then clear":  {0x8f, 0x10, 0x8f, 0x27, 0x3f, 0x01, 0x00, 0xe0},
+		"if v1 <= 9 then clear":  {0x6f, 0x09, 0x8f, 0x15, 0x4f, 0x01, 0x00, 0xe0},
+		"if v1 == 5 begin clear else exit end": {
+			0x31, 0x05, 0x12, 0x0a, // skip the jump to else when true
+			0x00, 0xe0, 0x12, 0x0c,
+			0x00, 0xfd,
+		},
+		"loop v1 += 1 while v1 != 9 again": {
+			0x71, 0x01,
+			0x41, 0x09, 0x12, 0x0a, // leave the loop when false
+			0x12, 0x02,
+		},
+	}
+
+	for source, expected := range tests {
+		rom := compile(t, ": main "+source)
+		assert.Equal(t, append([]byte{0x12, 0x02}, expected...), rom, source)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := map[string]error{
+		"clear":                      ErrMissingMain,
+		": main jump nowhere":        ErrUndefined,
+		": main v0 := 256":           ErrOutOfRange,
+		": main sprite v0 v1 16":     ErrOutOfRange,
+		": main : main":              ErrDuplicate,
+		": main v0 ** 1":             ErrSyntax,
+		": main if v0 == 1 begin":    ErrUnbalanced,
+		": main again":               ErrUnbalanced,
+		": main else":                ErrUnbalanced,
+		": main :calc X { 1 + }":     ErrSyntax,
+		": main :const X undefined":  ErrUndefined,
+		": main :macro m { m } m":    ErrSyntax,
+		": main if v0 ~= 1 then ;":   ErrSyntax,
+		": main i := 0x1000":         ErrOutOfRange,
+		": main :unpack 16 main":     ErrOutOfRange,
+		": main :alias x 5":          ErrSyntax,
+		": main :org 0x100":          ErrOutOfRange,
+		": main :calc { 1 }":         ErrSyntax,
+		": main :monitor x y v0 :=":  ErrSyntax,
+		": main :next v0 v0 := 1":    ErrSyntax,
+		": main i := long undefined": ErrUndefined,
+	}
+
+	for source, expected := range tests {
+		_, err := Compile(strings.NewReader(source))
+		assert.ErrorIs(t, err, expected, source)
+
+		var octoErr *Error
+		assert.ErrorAs(t, err, &octoErr, source)
+	}
+}
+
+func TestCompileErrorLine(t *testing.T) {
+	_, err := Compile(strings.NewReader(": main\n\tclear\n\tjump nowhere\n"))
+
+	var octoErr *Error
+	require.ErrorAs(t, err, &octoErr)
+	assert.Equal(t, 3, octoErr.Line)
+}
+
+func TestCompiledProgramRuns(t *testing.T) {
+	source := `
+: draw-row
+	loop
+		sprite v0 v1 1
+		v0 += 8
+		while v0 != 32
+	again
+	return
+
+: main
+	i := pixel
+	v0 := 0
+	v1 := 0
+	draw-row
+	if v0 == 32 begin
+		exit
+	else
+		clear
+	end
+	loop again
+
+: pixel
+	0b10000000
+`
+
+	rom := compile(t, source)
+
+	c := chip8.NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, c.LoadFont())
+	require.NoError(t, c.LoadROM(bytes.NewReader(rom)))
+
+	var err error
+	for frame := 0; frame < 60 && err == nil; frame++ {
+		err = c.Update()
+	}
+	assert.ErrorIs(t, err, chip8.ErrExit)
+	for x := 0; x < 64; x++ {
+		assert.Equal(t, x < 32 && x%8 == 0, c.Screen().Get(x, 0), "x=%d", x)
+	}
+}
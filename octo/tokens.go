@@ -0,0 +1,91 @@
+package octo
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// token is a whitespace separated word of the source, with the line it was read from
+type token struct {
+	text string
+	line int
+}
+
+// tokenize splits source into tokens, dropping # comments
+func tokenize(r io.Reader) ([]token, error) {
+	var tokens []token
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if j := strings.IndexByte(text, '#'); j >= 0 {
+			text = text[:j]
+		}
+
+		for _, word := range strings.Fields(text) {
+			tokens = append(tokens, token{text: word, line: line})
+		}
+	}
+
+	return tokens, scanner.Err()
+}
+
+// stream is the token source of the compiler, macros expand by inserting tokens at the current position
+type stream struct {
+	tokens []token
+	pos    int
+	line   int
+}
+
+func (s *stream) done() bool {
+	return s.pos >= len(s.tokens)
+}
+
+// next returns the next token, an empty one at the end of the source
+func (s *stream) next() token {
+	if s.done() {
+		return token{line: s.line}
+	}
+
+	t := s.tokens[s.pos]
+	s.pos++
+	s.line = t.line
+	return t
+}
+
+// peek returns the next token without consuming it
+func (s *stream) peek() string {
+	if s.done() {
+		return ""
+	}
+	return s.tokens[s.pos].text
+}
+
+// insert makes tokens the next ones read
+func (s *stream) insert(tokens []token) {
+	rest := append(tokens[:len(tokens):len(tokens)], s.tokens[s.pos:]...)
+	s.tokens = append(s.tokens[:s.pos], rest...)
+}
+
+// block returns the tokens up to the } matching an already consumed {
+func (s *stream) block() ([]token, bool) {
+	var tokens []token
+	depth := 1
+
+	for !s.done() {
+		t := s.next()
+		switch t.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return tokens, true
+			}
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, false
+}
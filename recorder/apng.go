@@ -0,0 +1,141 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image/png"
+	"io"
+)
+
+// pngSignature starts every PNG file
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// fcTL dispose and blend operations, frames replace the whole canvas, and the longest delay in 60 Hz frames
+const (
+	disposeNone  = 0
+	blendSource  = 0
+	maxAPNGDelay = 0xFFFF
+)
+
+var errInvalidPNG = errors.New("invalid PNG")
+
+// chunk is a PNG chunk, its length and CRC being computed when written
+type chunk struct {
+	kind string
+	data []byte
+}
+
+// WriteAPNG encodes the recording as an endlessly looping animated PNG, frame times being exact
+// Each frame is encoded by image/png, its image data then moved to fdAT chunks as APNG wants
+func (r *Recorder) WriteAPNG(w io.Writer) error {
+	images, err := r.canvas()
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, pngSignature); err != nil {
+		return err
+	}
+
+	sequence := uint32(0)
+	for j, img := range images {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return err
+		}
+		chunks, err := readChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		var out []chunk
+		if j == 0 {
+			for _, c := range chunks {
+				if c.kind == "IHDR" {
+					out = append(out, c, chunk{kind: "acTL", data: be32(uint32(len(images)), 0)})
+				}
+				if c.kind == "PLTE" {
+					out = append(out, c)
+				}
+			}
+		}
+
+		bounds, ticks := img.Bounds(), min(r.frames[j].ticks, maxAPNGDelay)
+		control := append(be32(sequence, uint32(bounds.Dx()), uint32(bounds.Dy()), 0, 0),
+			byte(ticks>>8), byte(ticks), 0, framesPerSecond, disposeNone, blendSource)
+		out = append(out, chunk{kind: "fcTL", data: control})
+		sequence++
+
+		for _, c := range chunks {
+			if c.kind != "IDAT" {
+				continue
+			}
+			if j == 0 {
+				out = append(out, c)
+				continue
+			}
+			out = append(out, chunk{kind: "fdAT", data: append(be32(sequence), c.data...)})
+			sequence++
+		}
+
+		for _, c := range out {
+			if err := writeChunk(w, c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeChunk(w, chunk{kind: "IEND"})
+}
+
+// readChunks splits a PNG file into its chunks
+func readChunks(data []byte) ([]chunk, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, errInvalidPNG
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []chunk
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return nil, errInvalidPNG
+		}
+		length := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+length {
+			return nil, errInvalidPNG
+		}
+
+		chunks = append(chunks, chunk{kind: string(data[4:8]), data: data[8 : 8+length]})
+		data = data[12+length:]
+	}
+
+	return chunks, nil
+}
+
+func writeChunk(w io.Writer, c chunk) error {
+	header := append(be32(uint32(len(c.data))), c.kind...)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(c.data)
+
+	for _, b := range [][]byte{header, c.data, be32(crc.Sum32())} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// be32 encodes values as consecutive big-endian 32-bit integers
+func be32(values ...uint32) []byte {
+	b := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+
+	return b
+}
@@ -0,0 +1,37 @@
+package recorder
+
+import (
+	"image/gif"
+	"io"
+)
+
+// minGIFDelay is the shortest frame delay viewers honour, in hundredths of a second, shorter ones being slowed down
+const minGIFDelay = 2
+
+// WriteGIF encodes the recording as an endlessly looping GIF
+// GIF delays are hundredths of a second, so frame times are rounded, never below minGIFDelay
+func (r *Recorder) WriteGIF(w io.Writer) error {
+	images, err := r.canvas()
+	if err != nil {
+		return err
+	}
+
+	animation := &gif.GIF{
+		Image: images,
+		Delay: make([]int, len(images)),
+	}
+
+	ticks := 0
+	for j, f := range r.frames {
+		start := centiseconds(ticks)
+		ticks += f.ticks
+		animation.Delay[j] = max(centiseconds(ticks)-start, minGIFDelay)
+	}
+
+	return gif.EncodeAll(w, animation)
+}
+
+// centiseconds converts 60 Hz frames to hundredths of a second, rounding to the nearest
+func centiseconds(ticks int) int {
+	return (ticks*100 + framesPerSecond/2) / framesPerSecond
+}
@@ -0,0 +1,139 @@
+// Package recorder captures the screen at 60 Hz into animated GIF or APNG files, to share clips of what a ROM draws
+package recorder
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const framesPerSecond = 60
+
+// ErrUnknownFormat is returned when saving to a file that isn't .gif, .png or .apng
+var ErrUnknownFormat = errors.New("unknown animation format")
+
+// ErrEmpty is returned when saving a recording without frames
+var ErrEmpty = errors.New("nothing recorded")
+
+// Recorder keeps the frames of a screen, a frame repeating the previous one only making it last longer
+type Recorder struct {
+	palette chip8.Palette
+	scale   int
+	frames  []frame
+}
+
+// frame is a picture at native resolution shown for ticks 60 Hz frames
+type frame struct {
+	image *image.Paletted
+	ticks int
+}
+
+// New returns an empty recording drawn with a palette, each pixel as a square of scale by scale
+func New(palette chip8.Palette, scale int) *Recorder {
+	return &Recorder{
+		palette: palette,
+		scale:   max(scale, 1),
+	}
+}
+
+// Capture adds a screen as the next 60 Hz frame
+func (r *Recorder) Capture(s *chip8.Screen) {
+	img := s.Image(r.palette, 1)
+
+	if n := len(r.frames); n > 0 {
+		last := r.frames[n-1].image
+		if last.Rect == img.Rect && bytes.Equal(last.Pix, img.Pix) {
+			r.frames[n-1].ticks++
+			return
+		}
+	}
+
+	r.frames = append(r.frames, frame{image: img, ticks: 1})
+}
+
+// Ticks returns how many 60 Hz frames were captured
+func (r *Recorder) Ticks() int {
+	ticks := 0
+	for _, f := range r.frames {
+		ticks += f.ticks
+	}
+
+	return ticks
+}
+
+// Frames returns how many different frames the animation has
+func (r *Recorder) Frames() int {
+	return len(r.frames)
+}
+
+// Save writes the animation to a file as GIF or APNG, as its extension tells: .gif, .png or .apng
+func (r *Recorder) Save(path string) error {
+	var write func(w io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gif":
+		write = r.WriteGIF
+	case ".png", ".apng":
+		write = r.WriteAPNG
+	default:
+		return fmt.Errorf("%w %q, expected .gif, .png or .apng", ErrUnknownFormat, filepath.Ext(path))
+	}
+
+	if len(r.frames) == 0 {
+		return ErrEmpty
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// canvas returns the scaled frames, all the size of the largest one: SUPER-CHIP programs switch resolution, and
+// low resolution frames are doubled to fill the high resolution canvas like the window does
+func (r *Recorder) canvas() ([]*image.Paletted, error) {
+	if len(r.frames) == 0 {
+		return nil, ErrEmpty
+	}
+
+	width := 0
+	for _, f := range r.frames {
+		width = max(width, f.image.Rect.Dx())
+	}
+
+	images := make([]*image.Paletted, len(r.frames))
+	for j, f := range r.frames {
+		images[j] = scale(f.image, r.scale*width/f.image.Rect.Dx())
+	}
+
+	return images, nil
+}
+
+// scale draws every pixel of an image as a square of factor by factor
+func scale(img *image.Paletted, factor int) *image.Paletted {
+	if factor == 1 {
+		return img
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	scaled := image.NewPaletted(image.Rect(0, 0, w*factor, h*factor), img.Palette)
+	for y := 0; y < h*factor; y++ {
+		for x := 0; x < w*factor; x++ {
+			scaled.Pix[y*scaled.Stride+x] = img.Pix[(y/factor)*img.Stride+x/factor]
+		}
+	}
+
+	return scaled
+}
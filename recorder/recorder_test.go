@@ -0,0 +1,120 @@
+package recorder
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRecording captures three identical frames, then one with a pixel turned on
+func newTestRecording(scale int) *Recorder {
+	r := New(chip8.DefaultPalette, scale)
+	s := chip8.NewScreen()
+
+	for j := 0; j < 3; j++ {
+		r.Capture(s)
+	}
+	s.Set(1, 2, true)
+	r.Capture(s)
+
+	return r
+}
+
+func TestCaptureDeduplicatesFrames(t *testing.T) {
+	r := newTestRecording(1)
+
+	assert.Equal(t, 2, r.Frames())
+	assert.Equal(t, 4, r.Ticks())
+}
+
+func TestWriteGIF(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, newTestRecording(2).WriteGIF(&buf))
+
+	animation, err := gif.DecodeAll(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, animation.Image, 2)
+	assert.Equal(t, []int{5, 2}, animation.Delay)
+	assert.Equal(t, image.Rect(0, 0, 128, 64), animation.Image[1].Bounds())
+	assert.Equal(t, uint8(1), animation.Image[1].ColorIndexAt(3, 5))
+	assert.Equal(t, uint8(0), animation.Image[0].ColorIndexAt(3, 5))
+}
+
+func TestWriteAPNG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, newTestRecording(1).WriteAPNG(&buf))
+
+	first, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 32), first.Bounds())
+
+	chunks, err := readChunks(buf.Bytes())
+	assert.NoError(t, err)
+
+	var kinds []string
+	var delays []uint16
+	var header, palette, second []chunk
+	for _, c := range chunks {
+		kinds = append(kinds, c.kind)
+		switch c.kind {
+		case "acTL":
+			assert.Equal(t, uint32(2), binary.BigEndian.Uint32(c.data), "frames")
+		case "fcTL":
+			delays = append(delays, binary.BigEndian.Uint16(c.data[20:]))
+			assert.Equal(t, uint16(60), binary.BigEndian.Uint16(c.data[22:]))
+		case "IHDR":
+			header = append(header, c)
+		case "PLTE":
+			palette = append(palette, c)
+		case "fdAT":
+			assert.Equal(t, uint32(2), binary.BigEndian.Uint32(c.data), "sequence")
+			second = append(second, chunk{kind: "IDAT", data: c.data[4:]})
+		}
+	}
+	assert.Equal(t, []string{"IHDR", "acTL", "PLTE", "fcTL", "IDAT", "fcTL", "fdAT", "IEND"}, kinds)
+	assert.Equal(t, []uint16{3, 1}, delays)
+
+	// the second frame is a PNG of its own once its fdAT chunks are IDAT again
+	var frame bytes.Buffer
+	frame.WriteString(pngSignature)
+	for _, c := range append(append(append(header, palette...), second...), chunk{kind: "IEND"}) {
+		assert.NoError(t, writeChunk(&frame, c))
+	}
+	img, err := png.Decode(&frame)
+	assert.NoError(t, err)
+	assert.Equal(t, chip8.DefaultPalette[1], img.At(1, 2))
+	assert.Equal(t, chip8.DefaultPalette[0], img.At(2, 2))
+}
+
+func TestCanvasDoublesLowResolution(t *testing.T) {
+	r := New(chip8.DefaultPalette, 1)
+	s := chip8.NewScreen()
+	s.Set(1, 1, true)
+	r.Capture(s)
+	s.SetHighResolution(true)
+	r.Capture(s)
+
+	images, err := r.canvas()
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 128, 64), images[0].Bounds())
+	assert.Equal(t, image.Rect(0, 0, 128, 64), images[1].Bounds())
+	assert.Equal(t, uint8(1), images[0].ColorIndexAt(3, 3))
+	assert.Equal(t, uint8(0), images[0].ColorIndexAt(4, 4))
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRecording(1)
+
+	assert.NoError(t, r.Save(filepath.Join(dir, "clip.gif")))
+	assert.NoError(t, r.Save(filepath.Join(dir, "clip.png")))
+	assert.ErrorIs(t, r.Save(filepath.Join(dir, "clip.mp4")), ErrUnknownFormat)
+	assert.ErrorIs(t, New(chip8.DefaultPalette, 1).Save(filepath.Join(dir, "empty.gif")), ErrEmpty)
+}
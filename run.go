@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/frontend/ebiten"
+	"chip8/frontend/term"
+	"chip8/octo"
+	"chip8/recorder"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultIPF      = 15
+	defaultRom      = "roms/1-chip8-logo.ch8"
+	defaultPlatform = "chip8"
+
+	octoExtension = ".8o"
+
+	defaultRewindInterval = 6
+	defaultRewindCapacity = 600
+
+	windowFrontend   = "window"
+	terminalFrontend = "term"
+)
+
+// runCommand runs a ROM, given with -rom or as the only argument. Octo sources (.8o) are compiled first
+func runCommand(args []string) int {
+	var (
+		machine machineFlags
+		trace   traceFlags
+
+		rewindInterval int
+		rewindCapacity int
+
+		recordMovie string
+		playMovie   string
+		headless    bool
+
+		screenshot      string
+		screenshotScale int
+
+		record      string
+		recordScale int
+		frames      int
+
+		frontend     string
+		termRenderer string
+		termSound    string
+	)
+
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	machine.register(flags)
+	trace.register(flags)
+	flags.IntVar(&rewindInterval, "rewind-interval", defaultRewindInterval, "frames between rewind snapshots")
+	flags.IntVar(&rewindCapacity, "rewind-capacity", defaultRewindCapacity, "rewind snapshots kept, 0 disables rewind")
+	flags.StringVar(&recordMovie, "record-movie", "", "record keypad input to a movie file")
+	flags.StringVar(&playMovie, "play-movie", "", "replay keypad input from a movie file, using its -ipf, -platform, -quirks and -seed")
+	flags.StringVar(&frontend, "frontend", windowFrontend, "where to play: window, or term for an ANSI terminal")
+	flags.StringVar(&termRenderer, "term-renderer", "halfblock", "how the terminal draws the screen: "+strings.Join(term.RendererNames(), ", "))
+	flags.StringVar(&termSound, "term-sound", "bell", "how the terminal plays the buzzer: "+strings.Join(term.SoundNames(), ", "))
+	flags.BoolVar(&headless, "headless", false, "run without a window until the program exits, the movie ends or -frames")
+	flags.IntVar(&frames, "frames", 0, "frames to run headless or to record, 0 for no limit")
+	flags.StringVar(&record, "record", "", "record the screen from the start to an animated .gif or .png, F10 starts and stops one while playing")
+	flags.IntVar(&recordScale, "record-scale", 1, "pixels per CHIP-8 pixel in recordings")
+	flags.StringVar(&screenshot, "screenshot", "", "save the screen when the run ends, as .png, .pbm or .txt")
+	flags.IntVar(&screenshotScale, "screenshot-scale", 1, "pixels per CHIP-8 pixel in PNG screenshots, F12 saves one while playing")
+	machine.parse(flags, args)
+
+	log := slog.Default()
+
+	var player *chip8.MoviePlayer
+	if playMovie != "" {
+		movie, err := readMovie(playMovie)
+		if err != nil {
+			log.Error("Failed to read movie file", slog.String("error", err.Error()))
+			return 1
+		}
+		if err := checkIPF(int(movie.IPF)); err != nil {
+			log.Error("Invalid movie file", slog.String("error", err.Error()))
+			return 1
+		}
+		machine.ipf, machine.platform, machine.quirks = int(movie.IPF), movie.Platform, movie.Quirks
+		machine.seed = seedFlag{value: movie.Seed, set: true}
+		if movie.Random != "" {
+			machine.random = movie.Random
+		}
+		player = chip8.NewMoviePlayer(movie)
+	}
+
+	if recordMovie != "" && headless {
+		log.Error("Recording a movie needs a window, -record-movie can't be used with -headless")
+		return 1
+	}
+
+	var (
+		renderer term.Renderer
+		sound    term.Sound
+	)
+	switch frontend {
+	case windowFrontend:
+	case terminalFrontend:
+		var err error
+		if renderer, err = term.RendererByName(termRenderer); err != nil {
+			log.Error(err.Error())
+			return 1
+		}
+		if sound, err = term.SoundByName(termSound); err != nil {
+			log.Error(err.Error())
+			return 1
+		}
+		if record != "" && !headless {
+			log.Error("The terminal can't record the screen, -record needs the window or -headless")
+			return 1
+		}
+		if trace.toStdout() && !headless {
+			log.Error("The terminal draws the screen on stdout, -trace - needs the window or -headless")
+			return 1
+		}
+	default:
+		log.Error("Unknown frontend, expected window or term", slog.String("frontend", frontend))
+		return 1
+	}
+
+	if record != "" && headless && frames == 0 && player == nil {
+		log.Error("Recording headless needs an end, use -frames or -play-movie with -record")
+		return 1
+	}
+
+	emulator, err := machine.build(log)
+	if err != nil {
+		log.Error(err.Error())
+		return 1
+	}
+
+	closeTrace, err := trace.attach(emulator)
+	if err != nil {
+		log.Error("Failed to open trace", slog.String("error", err.Error()))
+		return 1
+	}
+	defer func() {
+		if err := closeTrace(); err != nil {
+			log.Error("Failed to write trace", slog.String("error", err.Error()))
+		}
+	}()
+
+	log.Info(
+		"CHIP-8 starting...",
+		slog.Int("ipf", machine.ipf),
+		slog.String("platform", machine.platform),
+		slog.String("quirks", machine.quirks),
+		slog.String("random", machine.random),
+		slog.Uint64("seed", machine.seed.value),
+	)
+
+	if headless {
+		if player != nil {
+			emulator.SetKeypad(player)
+		}
+
+		var rec *recorder.Recorder
+		if record != "" {
+			rec = recorder.New(chip8.DefaultPalette, recordScale)
+		}
+		err = runHeadless(emulator, player, frames, rec)
+		if rec != nil {
+			if err := rec.Save(record); err != nil {
+				log.Error("Failed to save recording", slog.String("error", err.Error()))
+			}
+		}
+	} else if frontend == terminalFrontend {
+		ui := term.New(emulator, renderer, sound)
+		recording := attachMovie(emulator, ui.Keypad(), player, recordMovie, machine)
+
+		err = ui.Run(os.Stdin, os.Stdout)
+
+		saveMovie(log, recordMovie, recording)
+	} else {
+		game := ebiten.New(emulator, log)
+		game.SetSaveStatePath(machine.rom)
+		game.SetScreenshotPath(machine.rom, screenshotScale)
+		game.SetRecordingPath(machine.rom, recordScale)
+		if record != "" {
+			game.Record(record, frames, recordScale)
+		}
+		if rewindCapacity > 0 {
+			game.SetRewind(chip8.NewRewind(rewindInterval, rewindCapacity))
+		}
+		recording := attachMovie(emulator, game.Keypad(), player, recordMovie, machine)
+
+		err = game.Run()
+
+		saveMovie(log, recordMovie, recording)
+	}
+
+	if screenshot != "" {
+		if err := emulator.Screen().Export(screenshot, chip8.DefaultPalette, screenshotScale); err != nil {
+			log.Error("Failed to save screenshot", slog.String("error", err.Error()))
+		}
+	}
+
+	if err != nil {
+		log.Error(err.Error())
+		return 2
+	}
+
+	log.Info("CHIP-8 stopping...")
+	return 0
+}
+
+// attachMovie sets the keypad of the emulator: the movie player if any, else keypad, recorded in the returned movie
+// if recordMovie isn't empty
+func attachMovie(emulator *chip8.Chip8, keypad chip8.Keypad, player *chip8.MoviePlayer, recordMovie string, machine machineFlags) *chip8.Movie {
+	switch {
+	case player != nil:
+		emulator.SetKeypad(player)
+	case recordMovie != "":
+		recording := &chip8.Movie{Seed: machine.seed.value, IPF: uint32(machine.ipf), Platform: machine.platform, Quirks: machine.quirks}
+		if machine.random != pcgRandom {
+			recording.Random = machine.random
+		}
+		emulator.SetKeypad(chip8.NewMovieRecorder(keypad, recording))
+		return recording
+	}
+
+	return nil
+}
+
+func saveMovie(log *slog.Logger, path string, recording *chip8.Movie) {
+	if recording == nil {
+		return
+	}
+
+	if err := writeMovie(path, recording); err != nil {
+		log.Error("Failed to write movie file", slog.String("error", err.Error()))
+	}
+}
+
+// runHeadless updates the emulator until the program exits, a movie being played ends or, if not 0, for frames
+// frames, capturing every frame if recording
+func runHeadless(emulator *chip8.Chip8, player *chip8.MoviePlayer, frames int, rec *recorder.Recorder) error {
+	for frame := 0; (player == nil || !player.Done()) && (frames == 0 || frame < frames); frame++ {
+		if err := emulator.Update(); err != nil {
+			if errors.Is(err, chip8.ErrExit) {
+				return nil
+			}
+			return err
+		}
+
+		if rec != nil {
+			rec.Capture(emulator.Screen())
+		}
+	}
+
+	return nil
+}
+
+// openROM opens a ROM, compiling it first if it's Octo source
+func openROM(path string) (io.Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if filepath.Ext(path) == octoExtension {
+		rom, err := octo.Compile(f)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(rom), nil
+	}
+
+	rom, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(rom), nil
+}
+
+func readMovie(path string) (*chip8.Movie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return chip8.ReadMovie(f)
+}
+
+func writeMovie(path string, movie *chip8.Movie) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := movie.WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"chip8/chip8"
+	"strings"
+)
+
+// DefaultHoldFrames is how long a key stays pressed, covering the delay before the terminal repeats it
+const DefaultHoldFrames = 30
+
+// Keypad is a chip8.Keypad for terminals, which report key presses but no releases: a key stays pressed for a
+// number of frames after each press, which terminal auto repeat extends while the key is held down
+type Keypad struct {
+	hold   int
+	frames [chip8.KeyCount]int
+}
+
+func NewKeypad(holdFrames int) *Keypad {
+	return &Keypad{hold: holdFrames}
+}
+
+// Press presses the keypad key of a terminal key, returning false if it isn't one
+func (k *Keypad) Press(key string) bool {
+	if len(key) != 1 {
+		return false
+	}
+	j := strings.Index(chip8.KeyboardLayout, strings.ToLower(key))
+	if j < 0 {
+		return false
+	}
+
+	k.frames[j] = k.hold
+	return true
+}
+
+// Pressed implements chip8.Keypad, it's called once per frame and counts the held keys down
+func (k *Keypad) Pressed() [chip8.KeyCount]bool {
+	var keys [chip8.KeyCount]bool
+	for j := range k.frames {
+		keys[j] = k.frames[j] > 0
+		if k.frames[j] > 0 {
+			k.frames[j]--
+		}
+	}
+
+	return keys
+}
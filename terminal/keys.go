@@ -0,0 +1,178 @@
+package terminal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// pollInterval is how often a key reader waiting for input checks whether it was stopped
+const pollInterval = 50 * time.Millisecond
+
+// csiKeys names the keys sent as ESC [ number ~
+var csiKeys = map[int]string{
+	1: "home", 2: "insert", 3: "delete", 4: "end", 5: "pgup", 6: "pgdn",
+	15: "f5", 17: "f6", 18: "f7", 19: "f8", 20: "f9", 21: "f10", 23: "f11", 24: "f12",
+}
+
+// letterKeys names the keys sent as ESC [ letter or ESC O letter
+var letterKeys = map[byte]string{
+	'A': "up", 'B': "down", 'C': "right", 'D': "left", 'H': "home", 'F': "end",
+	'P': "f1", 'Q': "f2", 'R': "f3", 'S': "f4",
+}
+
+// KeyReader reads keys from a raw mode terminal in the background, see DecodeKeys
+type KeyReader struct {
+	keys    chan string
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewKeyReader starts reading keys from in, until it fails or the reader is stopped
+func NewKeyReader(in *os.File) *KeyReader {
+	r := &KeyReader{
+		keys:    make(chan string, 64),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.stopped)
+		_ = readKeys(in, r.keys, r.done)
+	}()
+
+	return r
+}
+
+// Keys returns the keys read
+func (r *KeyReader) Keys() <-chan string {
+	return r.keys
+}
+
+// Stop ends the reading and waits for it: call it before restoring the terminal, or the reader would swallow the
+// next input typed in it
+func (r *KeyReader) Stop() {
+	close(r.done)
+	<-r.stopped
+}
+
+// readKeys sends the keys read from in until it fails or done is closed, only reading once input is waiting so
+// that it notices done in time
+func readKeys(in *os.File, keys chan<- string, done <-chan struct{}) error {
+	buf := make([]byte, 256)
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		ready, err := waitInput(int(in.Fd()), pollInterval)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			continue
+		}
+
+		n, err := in.Read(buf)
+		for _, key := range DecodeKeys(buf[:n]) {
+			select {
+			case keys <- key:
+			case <-done:
+				return nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// DecodeKeys splits terminal input into key names: printable characters as themselves, and "enter", "esc",
+// "backspace", "tab", "up", "pgdn", "f5", "ctrl-c"... Modifiers of special keys are prefixed, as in "shift-f11"
+func DecodeKeys(data []byte) []string {
+	var keys []string
+
+	for len(data) > 0 {
+		key, n := decodeKey(data)
+		keys = append(keys, key)
+		data = data[n:]
+	}
+
+	return keys
+}
+
+func decodeKey(data []byte) (string, int) {
+	switch b := data[0]; {
+	case b == 0x1b:
+		return decodeEscape(data)
+	case b == '\r' || b == '\n':
+		return "enter", 1
+	case b == '\t':
+		return "tab", 1
+	case b == 0x7f || b == 0x08:
+		return "backspace", 1
+	case b < 0x20:
+		return "ctrl-" + string(rune('a'+b-1)), 1
+	}
+
+	r, n := utf8.DecodeRune(data)
+	return string(r), n
+}
+
+func decodeEscape(data []byte) (string, int) {
+	if len(data) < 3 || (data[1] != '[' && data[1] != 'O') {
+		return "esc", 1
+	}
+
+	// parameters then a final byte in 0x40-0x7e
+	end := 2
+	for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+		end++
+	}
+	if end == len(data) {
+		return "esc", 1
+	}
+
+	params := strings.Split(string(data[2:end]), ";")
+	final := data[end]
+
+	var name string
+	if final == '~' {
+		number, _ := strconv.Atoi(params[0])
+		name = csiKeys[number]
+	} else {
+		name = letterKeys[final]
+	}
+	if name == "" {
+		return "unknown", end + 1
+	}
+
+	if len(params) > 1 {
+		modifier, _ := strconv.Atoi(params[1])
+		name = modifierPrefix(modifier) + name
+	}
+
+	return name, end + 1
+}
+
+// modifierPrefix returns the prefix of an xterm modifier parameter, 1 plus a bit mask of shift, alt and ctrl
+func modifierPrefix(modifier int) string {
+	var sb strings.Builder
+
+	bits := modifier - 1
+	if bits&4 != 0 {
+		sb.WriteString("ctrl-")
+	}
+	if bits&2 != 0 {
+		sb.WriteString("alt-")
+	}
+	if bits&1 != 0 {
+		sb.WriteString("shift-")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyReaderStops(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = r.Close()
+		_ = w.Close()
+	})
+
+	reader := NewKeyReader(r)
+	_, err = w.WriteString("a\x1b[A")
+	require.NoError(t, err)
+	for _, expected := range []string{"a", "up"} {
+		select {
+		case key := <-reader.Keys():
+			assert.Equal(t, expected, key)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s key", expected)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		reader.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading didn't stop")
+	}
+
+	// input typed after stopping is left to whoever reads next
+	_, err = w.WriteString("b")
+	require.NoError(t, err)
+	buf := make([]byte, 1)
+	_, err = r.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(buf))
+}
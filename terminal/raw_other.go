@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package terminal
+
+import "time"
+
+func MakeRaw(fd int) (func() error, error) {
+	return nil, ErrUnsupported
+}
+
+func Size(fd int) (int, int, error) {
+	return 0, 0, ErrUnsupported
+}
+
+func waitInput(fd int, timeout time.Duration) (bool, error) {
+	return false, ErrUnsupported
+}
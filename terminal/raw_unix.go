@@ -0,0 +1,57 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// MakeRaw puts the terminal of fd in raw mode: no echo, no line buffering and no signals on Ctrl-C
+// It returns a function restoring the previous mode
+func MakeRaw(fd int) (func() error, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	previous := *termios
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return unix.IoctlSetTermios(fd, ioctlSetTermios, &previous)
+	}, nil
+}
+
+// Size returns the terminal width and height in characters
+func Size(fd int) (int, int, error) {
+	size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(size.Col), int(size.Row), nil
+}
+
+// waitInput returns true once fd has input to read, false if there is none after timeout
+func waitInput(fd int, timeout time.Duration) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+	if errors.Is(err, unix.EINTR) {
+		return false, nil
+	}
+
+	return n > 0, err
+}
@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"chip8/chip8"
+	"strings"
+)
+
+// halfBlocks draws two vertical pixels per character, indexed by top<<1 | bottom
+var halfBlocks = [4]rune{' ', '▄', '▀', '█'}
+
+// HalfBlocks draws a screen with one character per column and two rows
+func HalfBlocks(s *chip8.Screen) []string {
+	w, h := s.Layout()
+	lines := make([]string, 0, h/2)
+
+	for y := 0; y < h; y += 2 {
+		var sb strings.Builder
+		for x := 0; x < w; x++ {
+			sb.WriteRune(halfBlocks[pixel(s, x, y)<<1|pixel(s, x, y+1)])
+		}
+		lines = append(lines, sb.String())
+	}
+
+	return lines
+}
+
+// brailleDots are the bits of the braille pattern dots, indexed by row and column within a 2x4 cell
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// brailleBlank is the braille pattern without dots
+const brailleBlank = 0x2800
+
+// Braille draws a screen with one braille character per two columns and four rows, half as wide as HalfBlocks
+func Braille(s *chip8.Screen) []string {
+	w, h := s.Layout()
+	lines := make([]string, 0, h/4)
+
+	for y := 0; y < h; y += 4 {
+		var sb strings.Builder
+		for x := 0; x < w; x += 2 {
+			r := rune(brailleBlank)
+			for row, dots := range brailleDots {
+				for column, dot := range dots {
+					if pixel(s, x+column, y+row) == 1 {
+						r |= dot
+					}
+				}
+			}
+			sb.WriteRune(r)
+		}
+		lines = append(lines, sb.String())
+	}
+
+	return lines
+}
+
+func pixel(s *chip8.Screen, x, y int) int {
+	if s.Get(x, y) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+// Package terminal drives ANSI terminals: raw mode, key decoding, a keypad for terminals without key releases
+// and CHIP-8 screens drawn with Unicode block characters
+package terminal
+
+import "errors"
+
+// ErrUnsupported is returned by MakeRaw and Size where terminals can't be controlled
+var ErrUnsupported = errors.New("terminal control not supported on this platform")
+
+// ANSI escape sequences
+const (
+	ClearScreen     = "\x1b[2J"
+	ClearLine       = "\x1b[K"
+	ClearBelow      = "\x1b[J"
+	Home            = "\x1b[H"
+	HideCursor      = "\x1b[?25l"
+	ShowCursor      = "\x1b[?25h"
+	AlternateScreen = "\x1b[?1049h"
+	MainScreen      = "\x1b[?1049l"
+	Reverse         = "\x1b[7m"
+	Bold            = "\x1b[1m"
+	Reset           = "\x1b[0m"
+	Bell            = "\a"
+)
@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"chip8/chip8"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		keys  []string
+	}{
+		{"a", []string{"a"}},
+		{"ab\r", []string{"a", "b", "enter"}},
+		{"\x03\x7f\t", []string{"ctrl-c", "backspace", "tab"}},
+		{"\x1b", []string{"esc"}},
+		{"\x1b[A\x1b[B\x1bOC\x1b[D", []string{"up", "down", "right", "left"}},
+		{"\x1b[5~\x1b[6~", []string{"pgup", "pgdn"}},
+		{"\x1bOP\x1b[15~\x1b[24~", []string{"f1", "f5", "f12"}},
+		{"\x1b[19;2~", []string{"shift-f8"}},
+		{"é", []string{"é"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.keys, DecodeKeys([]byte(tt.input)))
+		})
+	}
+}
+
+func TestKeypadHold(t *testing.T) {
+	k := NewKeypad(2)
+
+	assert.True(t, k.Press("W"))
+	assert.False(t, k.Press("p"))
+	assert.False(t, k.Press("we"))
+
+	for frame := 0; frame < 2; frame++ {
+		pressed := k.Pressed()
+		assert.True(t, pressed[0x5], "frame %d", frame)
+		assert.False(t, pressed[0x4], "frame %d", frame)
+	}
+	assert.Equal(t, [chip8.KeyCount]bool{}, k.Pressed())
+}
+
+func TestHalfBlocks(t *testing.T) {
+	s := chip8.NewScreen()
+	s.Set(0, 0, true)
+	s.Set(1, 1, true)
+	s.Set(2, 0, true)
+	s.Set(2, 1, true)
+
+	lines := HalfBlocks(s)
+	assert.Len(t, lines, 16)
+	assert.Equal(t, "▀▄█ ", string([]rune(lines[0])[:4]))
+	assert.Equal(t, 64, len([]rune(lines[1])))
+}
+
+func TestBraille(t *testing.T) {
+	s := chip8.NewScreen()
+	s.Set(0, 0, true)
+	s.Set(1, 3, true)
+	s.Set(3, 1, true)
+
+	lines := Braille(s)
+	assert.Len(t, lines, 8)
+	assert.Equal(t, "⢁⠐⠀", string([]rune(lines[0])[:3]))
+	assert.Equal(t, 32, len([]rune(lines[1])))
+}
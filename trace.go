@@ -0,0 +1,92 @@
+package main
+
+import (
+	"chip8/chip8"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// traceFlags are the flags of commands that can trace execution
+type traceFlags struct {
+	path      string
+	format    string
+	addresses string
+	opcodes   string
+}
+
+func (t *traceFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&t.path, "trace", "", "write a record per instruction to a file, - for stdout")
+	flags.StringVar(&t.format, "trace-format", "text", "trace format: "+strings.Join(chip8.TraceFormatNames(), ", "))
+	flags.StringVar(&t.addresses, "trace-range", "", "trace only addresses in a hex range, like 200-2ff")
+	flags.StringVar(&t.opcodes, "trace-opcodes", "", "trace only opcodes matching comma separated patterns, like DXYN,8XY4")
+}
+
+// attach traces the machine if -trace was given, the returned function flushes and closes the trace
+func (t *traceFlags) attach(emulator *chip8.Chip8) (func() error, error) {
+	if t.path == "" {
+		return func() error { return nil }, nil
+	}
+
+	format, err := chip8.TraceFormatByName(t.format)
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.WriteCloser = os.Stdout
+	if !t.toStdout() {
+		if w, err = os.Create(t.path); err != nil {
+			return nil, err
+		}
+	}
+
+	tracer := chip8.NewTracer(w, format)
+	if err := t.filter(tracer); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	emulator.SetTrace(tracer)
+	return func() error {
+		err := tracer.Flush()
+		if !t.toStdout() {
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// toStdout returns true if the trace is written to stdout, false otherwise
+func (t *traceFlags) toStdout() bool {
+	return t.path == "-"
+}
+
+func (t *traceFlags) filter(tracer *chip8.Tracer) error {
+	if t.addresses != "" {
+		first, last, ok := strings.Cut(t.addresses, "-")
+		from, err := strconv.ParseUint(strings.TrimPrefix(first, "0x"), 16, 16)
+		if err != nil || !ok {
+			return fmt.Errorf("invalid trace range %q, expected a hex range like 200-2ff", t.addresses)
+		}
+		to, err := strconv.ParseUint(strings.TrimPrefix(last, "0x"), 16, 16)
+		if err != nil || to < from {
+			return fmt.Errorf("invalid trace range %q, expected a hex range like 200-2ff", t.addresses)
+		}
+		tracer.SetAddressRange(uint16(from), uint16(to))
+	}
+
+	if t.opcodes != "" {
+		for _, pattern := range strings.Split(t.opcodes, ",") {
+			if err := tracer.AddOpcodeFilter(strings.TrimSpace(pattern)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"chip8/tracediff"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const defaultTraceDiffFrames = 60 * 60
+
+// traceDiffCommand runs a ROM headless and compares its execution with a reference trace, exiting with 1 if they
+// diverge
+func traceDiffCommand(args []string) int {
+	var (
+		machine machineFlags
+		layout  string
+		frames  uint64
+		context int
+	)
+
+	flags := flag.NewFlagSet("tracediff", flag.ExitOnError)
+	machine.register(flags)
+	flags.StringVar(&layout, "layout", "auto", "reference trace layout: auto, "+strings.Join(tracediff.LayoutNames(), ", "))
+	flags.Uint64Var(&frames, "frames", defaultTraceDiffFrames, "frames to run at most")
+	flags.IntVar(&context, "context", tracediff.DefaultContext, "instructions shown before the divergence")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chip8 tracediff [flags] rom reference.trace")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	machine.rom = flags.Arg(0)
+
+	emulator, err := machine.build(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	f, err := os.Open(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer f.Close()
+
+	reference, err := tracediff.NewReader(f, layout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	result, err := tracediff.NewDiffer(emulator, reference, context).Run(frames)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	_, _ = result.WriteTo(os.Stdout)
+	if result.Diverged {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+// Package tracediff compares the execution of a ROM against a trace from another emulator, to find where they
+// diverge. Reference traces are read in the layouts DetectLayout describes
+package tracediff
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// DefaultContext is how many instructions before a divergence a Result keeps
+const DefaultContext = 8
+
+// Step is an executed instruction and the memory it changed
+type Step struct {
+	chip8.TraceRecord
+	Writes []Write
+}
+
+// Write is a memory byte an instruction changed
+type Write struct {
+	Address uint16
+	Before  uint8
+	After   uint8
+}
+
+// Result is the outcome of a comparison. Diverged is false if the emulator matched the whole reference, or
+// until it exited, Reason telling which. Otherwise Reference and Actual are the first records that differ, Fields
+// which of their fields differ, Stack the return addresses at that point and Recent the instructions before it
+type Result struct {
+	Matched   uint64
+	Diverged  bool
+	Reason    string
+	Reference Record
+	Actual    *chip8.TraceRecord
+	Fields    Field
+	Stack     []uint16
+	Recent    []Step
+}
+
+// Differ is a chip8.TraceSink comparing every instruction with the next reference record
+type Differ struct {
+	emulator  *chip8.Chip8
+	reference *Reader
+	context   int
+	memory    []byte
+	recent    []Step
+	result    Result
+	done      bool
+	err       error
+}
+
+// NewDiffer compares the instructions of a machine with a reference, keeping context instructions before a
+// divergence. It traces the machine itself
+func NewDiffer(emulator *chip8.Chip8, reference *Reader, context int) *Differ {
+	d := &Differ{
+		emulator:  emulator,
+		reference: reference,
+		context:   context,
+		memory:    bytes.Clone(emulator.Memory()),
+	}
+	emulator.SetTrace(d)
+
+	return d
+}
+
+// Run updates the machine until it diverges from the reference, the reference ends, the program exits or
+// maxFrames elapse
+func (d *Differ) Run(maxFrames uint64) (*Result, error) {
+	for frame := uint64(0); !d.done && frame < maxFrames; frame++ {
+		err := d.emulator.Update()
+		if d.err != nil {
+			return nil, d.err
+		}
+		if d.done {
+			break
+		}
+
+		switch {
+		case errors.Is(err, chip8.ErrExit):
+			d.stopped("the program exited")
+		case err != nil:
+			d.stopped(err.Error())
+		}
+	}
+
+	if !d.done {
+		d.result.Reason = fmt.Sprintf("no divergence in %d frames", maxFrames)
+	}
+	return &d.result, nil
+}
+
+// stopped ends the comparison when the emulator can't continue, which diverges if the reference goes on
+func (d *Differ) stopped(reason string) {
+	d.done = true
+	d.trackWrites()
+
+	next, err := d.reference.Next()
+	switch {
+	case errors.Is(err, io.EOF):
+		d.result.Reason = reason + " at the end of the reference"
+	case err != nil:
+		d.err = err
+	default:
+		d.result.Diverged = true
+		d.result.Reason = reason + ", the reference continues"
+		d.result.Reference = next
+		d.result.Stack = d.emulator.Stack()
+		d.result.Recent = slices.Clone(d.recent)
+	}
+}
+
+func (d *Differ) Trace(r *chip8.TraceRecord) {
+	if d.done {
+		return
+	}
+	d.trackWrites()
+
+	reference, err := d.reference.Next()
+	switch {
+	case errors.Is(err, io.EOF):
+		d.done = true
+		d.result.Reason = "the reference ended"
+		return
+	case err != nil:
+		d.done, d.err = true, err
+		return
+	}
+
+	if fields := compare(reference, r); fields != 0 {
+		actual := *r
+		d.done = true
+		d.result = Result{
+			Matched:   d.result.Matched,
+			Diverged:  true,
+			Reason:    "state differs",
+			Reference: reference,
+			Actual:    &actual,
+			Fields:    fields,
+			Stack:     d.emulator.Stack(),
+			Recent:    slices.Clone(d.recent),
+		}
+		return
+	}
+
+	d.result.Matched++
+	if len(d.recent) == d.context {
+		d.recent = d.recent[1:]
+	}
+	if d.context > 0 {
+		d.recent = append(d.recent, Step{TraceRecord: *r})
+	}
+}
+
+// trackWrites records the memory the last instruction changed
+func (d *Differ) trackWrites() {
+	memory := d.emulator.Memory()
+	if bytes.Equal(memory, d.memory) {
+		return
+	}
+
+	var writes []Write
+	for j := range memory {
+		if memory[j] != d.memory[j] {
+			writes = append(writes, Write{Address: uint16(j), Before: d.memory[j], After: memory[j]})
+			d.memory[j] = memory[j]
+		}
+	}
+	if len(d.recent) > 0 {
+		d.recent[len(d.recent)-1].Writes = writes
+	}
+}
+
+// compare returns the fields the reference has that differ from the actual record
+func compare(reference Record, actual *chip8.TraceRecord) Field {
+	var fields Field
+	differs := func(field Field, a, b uint16) {
+		if reference.Fields&field != 0 && a != b {
+			fields |= field
+		}
+	}
+
+	differs(FieldPC, reference.PC, actual.PC)
+	differs(FieldOpcode, reference.Opcode, actual.Opcode)
+	differs(FieldIndex, reference.Index, actual.Index)
+	differs(FieldSP, uint16(reference.SP), uint16(actual.SP))
+	differs(FieldDT, uint16(reference.DT), uint16(actual.DT))
+	differs(FieldST, uint16(reference.ST), uint16(actual.ST))
+	for x := range reference.Registers {
+		differs(FieldV0<<x, uint16(reference.Registers[x]), uint16(actual.Registers[x]))
+	}
+
+	return fields
+}
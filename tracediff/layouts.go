@@ -0,0 +1,291 @@
+package tracediff
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrSyntax = errors.New("unrecognized trace line")
+
+// Field is a set of the machine state fields a reference record has
+type Field uint32
+
+const (
+	FieldPC Field = 1 << iota
+	FieldOpcode
+	FieldIndex
+	FieldSP
+	FieldDT
+	FieldST
+	// FieldV0 is the first of 16 register fields, FieldV0<<x being VX
+	FieldV0
+)
+
+// fieldRegisters has every register field
+const fieldRegisters = 0xFFFF * FieldV0
+
+// Record is an instruction of a reference trace, the state before it executes. Fields tells which of the others
+// the layout provides, the missing ones aren't compared
+type Record struct {
+	Line      int
+	Fields    Field
+	PC        uint16
+	Opcode    uint16
+	Index     uint16
+	Registers chip8.Registers
+	SP        uint8
+	DT        uint8
+	ST        uint8
+}
+
+// Layout parses a line of a trace layout, returning false for lines without a record, like headers
+type Layout func(line string) (Record, bool, error)
+
+var layouts = map[string]Layout{
+	"text":     parseText,
+	"jsonl":    parseJSONL,
+	"keyvalue": parseKeyValue,
+	"columns":  parseColumns,
+}
+
+// LayoutNames returns the layout names in alphabetical order, auto not included
+func LayoutNames() []string {
+	return slices.Sorted(maps.Keys(layouts))
+}
+
+// DetectLayout guesses the layout of a trace line:
+//
+//	text      the chip8 -trace text format
+//	jsonl     the chip8 -trace jsonl format
+//	keyvalue  named fields in any order: PC:0200 OP:a050 V0=2a V1:00 ... I:0000 SP:0 DT:00 ST:00
+//	columns   hex columns in this order, trailing ones optional: PC opcode V0 ... VF I SP DT ST
+func DetectLayout(line string) string {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(line), "{"):
+		return "jsonl"
+	case textLine.MatchString(line):
+		return "text"
+	case len(keyValue.FindAllString(line, 2)) >= 2:
+		return "keyvalue"
+	}
+	return "columns"
+}
+
+// Reader reads the records of a reference trace
+type Reader struct {
+	scanner *bufio.Scanner
+	layout  Layout
+	line    int
+}
+
+// NewReader reads a trace in a layout, auto detecting it from the first non-empty line
+func NewReader(r io.Reader, layout string) (*Reader, error) {
+	reader := &Reader{scanner: bufio.NewScanner(r)}
+	if layout == "auto" {
+		return reader, nil
+	}
+
+	parse, ok := layouts[layout]
+	if !ok {
+		return nil, fmt.Errorf("unknown trace layout %q, expected auto or one of: %s", layout, strings.Join(LayoutNames(), ", "))
+	}
+	reader.layout = parse
+	return reader, nil
+}
+
+// Next returns the next record, io.EOF at the end of the trace
+func (r *Reader) Next() (Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := r.scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if r.layout == nil {
+			r.layout = layouts[DetectLayout(text)]
+		}
+
+		record, ok, err := r.layout(text)
+		if err != nil {
+			return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		if ok {
+			record.Line = r.line
+			return record, nil
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+var textLine = regexp.MustCompile(
+	`^\s*\d+\s+\d+\s+([0-9a-f]{4})\s+([0-9a-f]{4})\s.*V:([0-9a-f]{32}) I:([0-9a-f]{4}) SP:([0-9a-f]+) DT:([0-9a-f]{2}) ST:([0-9a-f]{2})\s*$`,
+)
+
+func parseText(line string) (Record, bool, error) {
+	m := textLine.FindStringSubmatch(line)
+	if m == nil {
+		return Record{}, false, fmt.Errorf("%w: %q", ErrSyntax, line)
+	}
+
+	r := Record{Fields: FieldPC | FieldOpcode | FieldIndex | FieldSP | FieldDT | FieldST | fieldRegisters}
+	r.PC, r.Opcode, r.Index = parse16(m[1]), parse16(m[2]), parse16(m[4])
+	r.SP, r.DT, r.ST = uint8(parse16(m[5])), uint8(parse16(m[6])), uint8(parse16(m[7]))
+	v, _ := hex.DecodeString(m[3])
+	copy(r.Registers[:], v)
+
+	return r, true, nil
+}
+
+func parseJSONL(line string) (Record, bool, error) {
+	var j struct {
+		PC     *uint16          `json:"pc"`
+		Opcode *uint16          `json:"opcode"`
+		V      *chip8.Registers `json:"v"`
+		I      *uint16          `json:"i"`
+		SP     *uint8           `json:"sp"`
+		DT     *uint8           `json:"dt"`
+		ST     *uint8           `json:"st"`
+	}
+	if err := json.Unmarshal([]byte(line), &j); err != nil {
+		return Record{}, false, fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+
+	var r Record
+	set16 := func(field Field, dst *uint16, src *uint16) {
+		if src != nil {
+			r.Fields, *dst = r.Fields|field, *src
+		}
+	}
+	set8 := func(field Field, dst *uint8, src *uint8) {
+		if src != nil {
+			r.Fields, *dst = r.Fields|field, *src
+		}
+	}
+	set16(FieldPC, &r.PC, j.PC)
+	set16(FieldOpcode, &r.Opcode, j.Opcode)
+	set16(FieldIndex, &r.Index, j.I)
+	set8(FieldSP, &r.SP, j.SP)
+	set8(FieldDT, &r.DT, j.DT)
+	set8(FieldST, &r.ST, j.ST)
+	if j.V != nil {
+		r.Fields, r.Registers = r.Fields|fieldRegisters, *j.V
+	}
+
+	return r, r.Fields != 0, nil
+}
+
+var keyValue = regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9]*)\s*[:=]\s*(?:0[xX]|\$)?([0-9A-Fa-f]+)\b`)
+
+// keyFields names the keys of the keyvalue layout, V0-VF and V, 32 hex digits with every register, aside
+var keyFields = map[string]Field{
+	"PC": FieldPC, "OP": FieldOpcode, "OPCODE": FieldOpcode, "I": FieldIndex, "SP": FieldSP, "DT": FieldDT, "ST": FieldST,
+}
+
+func parseKeyValue(line string) (Record, bool, error) {
+	var r Record
+
+	for _, m := range keyValue.FindAllStringSubmatch(line, -1) {
+		key, value := strings.ToUpper(m[1]), m[2]
+		if field, ok := keyFields[key]; ok {
+			n, err := strconv.ParseUint(value, 16, 16)
+			if err != nil {
+				return Record{}, false, fmt.Errorf("%w: invalid %s %q", ErrSyntax, m[1], value)
+			}
+			r.set(field, uint16(n))
+			continue
+		}
+
+		if key == "V" && len(value) == 2*len(r.Registers) {
+			v, _ := hex.DecodeString(value)
+			copy(r.Registers[:], v)
+			r.Fields |= fieldRegisters
+			continue
+		}
+
+		if len(key) == 2 && key[0] == 'V' {
+			x, err := strconv.ParseUint(key[1:], 16, 4)
+			n, valueErr := strconv.ParseUint(value, 16, 8)
+			if err == nil && valueErr == nil {
+				r.Registers[x] = uint8(n)
+				r.Fields |= FieldV0 << x
+			}
+		}
+	}
+
+	return r, r.Fields != 0, nil
+}
+
+// columnFields are the columns of the columns layout, in order
+var columnFields = []Field{
+	FieldPC, FieldOpcode,
+	FieldV0, FieldV0 << 1, FieldV0 << 2, FieldV0 << 3, FieldV0 << 4, FieldV0 << 5, FieldV0 << 6, FieldV0 << 7,
+	FieldV0 << 8, FieldV0 << 9, FieldV0 << 10, FieldV0 << 11, FieldV0 << 12, FieldV0 << 13, FieldV0 << 14, FieldV0 << 15,
+	FieldIndex, FieldSP, FieldDT, FieldST,
+}
+
+func parseColumns(line string) (Record, bool, error) {
+	var r Record
+
+	columns := strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' || r == '|' })
+	if len(columns) > len(columnFields) {
+		columns = columns[:len(columnFields)]
+	}
+	for j, column := range columns {
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(column), "0x"), 16, 16)
+		if err != nil {
+			if j == 0 {
+				// a header
+				return Record{}, false, nil
+			}
+			return Record{}, false, fmt.Errorf("%w: invalid column %d %q", ErrSyntax, j+1, column)
+		}
+		r.set(columnFields[j], uint16(n))
+	}
+
+	return r, r.Fields&(FieldPC|FieldOpcode) == FieldPC|FieldOpcode, nil
+}
+
+func (r *Record) set(field Field, value uint16) {
+	r.Fields |= field
+
+	switch field {
+	case FieldPC:
+		r.PC = value
+	case FieldOpcode:
+		r.Opcode = value
+	case FieldIndex:
+		r.Index = value
+	case FieldSP:
+		r.SP = uint8(value)
+	case FieldDT:
+		r.DT = uint8(value)
+	case FieldST:
+		r.ST = uint8(value)
+	default:
+		for x := range r.Registers {
+			if field == FieldV0<<x {
+				r.Registers[x] = uint8(value)
+			}
+		}
+	}
+}
+
+func parse16(text string) uint16 {
+	n, _ := strconv.ParseUint(text, 16, 16)
+	return uint16(n)
+}
@@ -0,0 +1,92 @@
+package tracediff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTo writes a human readable report: the matched count, and for divergences the expected and actual state,
+// the stack and the instructions leading to it with the memory they changed
+func (r *Result) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	if !r.Diverged {
+		fmt.Fprintf(&sb, "%d instructions match, %s\n", r.Matched, r.Reason)
+		n, err := io.WriteString(w, sb.String())
+		return int64(n), err
+	}
+
+	fmt.Fprintf(&sb, "diverged after %d matching instructions: %s, reference line %d\n", r.Matched, r.Reason, r.Reference.Line)
+
+	sb.WriteString("\n           PC   op    I    SP DT ST  V0 V1 V2 V3 V4 V5 V6 V7 V8 V9 VA VB VC VD VE VF\n")
+	sb.WriteString("expected   " + r.referenceState() + "\n")
+	if r.Actual != nil {
+		a := r.Actual
+		fmt.Fprintf(&sb, "actual     %04x %04x  %04x %02x %02x %02x  % x\n", a.PC, a.Opcode, a.Index, a.SP, a.DT, a.ST, a.Registers[:])
+		fmt.Fprintf(&sb, "differs    %s\n", r.differences())
+		fmt.Fprintf(&sb, "instruction %s\n", a.Instruction)
+	}
+
+	sb.WriteString("\nstack     ")
+	if len(r.Stack) == 0 {
+		sb.WriteString(" empty")
+	}
+	for _, address := range r.Stack {
+		fmt.Fprintf(&sb, " %04x", address)
+	}
+	sb.WriteString("\n")
+
+	if len(r.Recent) > 0 {
+		sb.WriteString("\nrecent instructions\n")
+	}
+	for _, s := range r.Recent {
+		fmt.Fprintf(&sb, "%8d %04x %04x %-24s I:%04x V:% x\n", s.Cycle, s.PC, s.Opcode, s.Instruction, s.Index, s.Registers[:])
+		for _, write := range s.Writes {
+			fmt.Fprintf(&sb, "         mem[%04x] %02x -> %02x\n", write.Address, write.Before, write.After)
+		}
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// referenceState formats the reference record like the actual one, with -- for missing fields
+func (r *Result) referenceState() string {
+	ref := r.Reference
+	field := func(f Field, width int, value uint16) string {
+		if ref.Fields&f == 0 {
+			return strings.Repeat("-", width)
+		}
+		return fmt.Sprintf("%0*x", width, value)
+	}
+
+	registers := make([]string, len(ref.Registers))
+	for x, v := range ref.Registers {
+		registers[x] = field(FieldV0<<x, 2, uint16(v))
+	}
+
+	return fmt.Sprintf("%s %s  %s %s %s %s  %s",
+		field(FieldPC, 4, ref.PC), field(FieldOpcode, 4, ref.Opcode), field(FieldIndex, 4, ref.Index),
+		field(FieldSP, 2, uint16(ref.SP)), field(FieldDT, 2, uint16(ref.DT)), field(FieldST, 2, uint16(ref.ST)),
+		strings.Join(registers, " "))
+}
+
+func (r *Result) differences() string {
+	var names []string
+	for _, f := range []struct {
+		field Field
+		name  string
+	}{{FieldPC, "PC"}, {FieldOpcode, "opcode"}, {FieldIndex, "I"}, {FieldSP, "SP"}, {FieldDT, "DT"}, {FieldST, "ST"}} {
+		if r.Fields&f.field != 0 {
+			names = append(names, f.name)
+		}
+	}
+	for x := 0; x < 16; x++ {
+		if r.Fields&(FieldV0<<x) != 0 {
+			names = append(names, fmt.Sprintf("V%X", x))
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
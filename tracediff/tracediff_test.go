@@ -0,0 +1,149 @@
+package tracediff
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testROM stores two registers and exits
+//
+//	0200: LOAD V0,05
+//	0202: LOAD V1,03
+//	0204: LOAD I,0x0300
+//	0206: STORE V0-V1
+//	0208: ADD V0,1
+//	020a: EXIT
+var testROM = []byte{0x60, 0x05, 0x61, 0x03, 0xa3, 0x00, 0xf1, 0x55, 0x70, 0x01, 0x00, 0xfd}
+
+func newMachine(t *testing.T) *chip8.Chip8 {
+	c := chip8.NewChip8(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, c.LoadFont())
+	require.NoError(t, c.LoadROM(bytes.NewReader(testROM)))
+	return c
+}
+
+// referenceTrace traces the test ROM with the chip8 tracer
+func referenceTrace(t *testing.T, format chip8.TraceFormat) string {
+	c := newMachine(t)
+
+	var buf bytes.Buffer
+	tracer := chip8.NewTracer(&buf, format)
+	c.SetTrace(tracer)
+	for err := error(nil); err == nil; {
+		err = c.Update()
+		if err != nil {
+			require.ErrorIs(t, err, chip8.ErrExit)
+		}
+	}
+	require.NoError(t, tracer.Flush())
+
+	return buf.String()
+}
+
+func diff(t *testing.T, reference string) *Result {
+	t.Helper()
+
+	r, err := NewReader(strings.NewReader(reference), "auto")
+	require.NoError(t, err)
+	result, err := NewDiffer(newMachine(t), r, DefaultContext).Run(10)
+	require.NoError(t, err)
+
+	return result
+}
+
+func TestMatchingTraces(t *testing.T) {
+	for _, format := range []chip8.TraceFormat{chip8.TraceText, chip8.TraceJSONL} {
+		result := diff(t, referenceTrace(t, format))
+		assert.False(t, result.Diverged)
+		assert.Equal(t, uint64(6), result.Matched)
+		assert.Equal(t, "the program exited at the end of the reference", result.Reason)
+	}
+
+	reference := referenceTrace(t, chip8.TraceText)
+	result := diff(t, reference[:strings.Index(reference, "\n")+1])
+	assert.False(t, result.Diverged)
+	assert.Equal(t, uint64(1), result.Matched)
+	assert.Equal(t, "the reference ended", result.Reason)
+}
+
+func TestDivergence(t *testing.T) {
+	lines := strings.Split(referenceTrace(t, chip8.TraceText), "\n")
+	// the reference's ADD sees V0 = 06 and I = 0302
+	lines[4] = strings.Replace(lines[4], "V:0503", "V:0603", 1)
+
+	result := diff(t, strings.Join(lines, "\n"))
+	require.True(t, result.Diverged)
+	assert.Equal(t, uint64(4), result.Matched)
+	assert.Equal(t, 5, result.Reference.Line)
+	assert.Equal(t, FieldV0, result.Fields)
+	assert.Equal(t, uint16(0x208), result.Actual.PC)
+	require.Len(t, result.Recent, 4)
+	assert.Equal(t, []Write{{Address: 0x300, Before: 0, After: 5}, {Address: 0x301, Before: 0, After: 3}}, result.Recent[3].Writes)
+
+	var report bytes.Buffer
+	_, err := result.WriteTo(&report)
+	require.NoError(t, err)
+	assert.Contains(t, report.String(), "diverged after 4 matching instructions: state differs, reference line 5")
+	assert.Contains(t, report.String(), "differs    V0")
+	assert.Contains(t, report.String(), "mem[0300] 00 -> 05")
+}
+
+func TestReferenceLayouts(t *testing.T) {
+	keyValue := `PC:0200 OP:6005
+PC=0x0202 OP=0x6103 V0=05 I=0000
+PC: 0204, OP: a300, V0: 05, V1: 03
+`
+	result := diff(t, keyValue)
+	assert.Equal(t, uint64(3), result.Matched)
+
+	columns := `PC   OP   V0 V1
+0200 6005 00 00
+0202 6103 05 00
+0204 a300 05 04
+`
+	result = diff(t, columns)
+	require.True(t, result.Diverged)
+	assert.Equal(t, FieldV0<<1, result.Fields)
+	assert.Equal(t, 4, result.Reference.Line)
+}
+
+func TestProgramExitsFirst(t *testing.T) {
+	reference := referenceTrace(t, chip8.TraceText) + "       6      0 020c 0000 ...  V:00000000000000000000000000000000 I:0000 SP:0 DT:00 ST:00\n"
+
+	result := diff(t, reference)
+	assert.True(t, result.Diverged)
+	assert.Equal(t, "the program exited, the reference continues", result.Reason)
+	assert.Equal(t, uint16(0x20c), result.Reference.PC)
+	assert.Nil(t, result.Actual)
+}
+
+func TestDetectLayout(t *testing.T) {
+	assert.Equal(t, "jsonl", DetectLayout(`{"pc":512}`))
+	assert.Equal(t, "text", DetectLayout("       0      0 0200 602a LOAD V0,2a               V:00000000000000000000000000000000 I:0000 SP:0 DT:00 ST:00"))
+	assert.Equal(t, "keyvalue", DetectLayout("PC:0200 I:0000"))
+	assert.Equal(t, "columns", DetectLayout("0200 602a 00"))
+}
+
+func TestReaderErrors(t *testing.T) {
+	_, err := NewReader(strings.NewReader(""), "xml")
+	assert.ErrorContains(t, err, "unknown trace layout")
+
+	r, err := NewReader(strings.NewReader("0200 zz\n"), "columns")
+	require.NoError(t, err)
+	_, err = r.Next()
+	assert.ErrorIs(t, err, ErrSyntax)
+	assert.ErrorContains(t, err, "line 1")
+
+	r, err = NewReader(strings.NewReader("\n"), "auto")
+	require.NoError(t, err)
+	_, err = r.Next()
+	assert.True(t, errors.Is(err, io.EOF))
+}